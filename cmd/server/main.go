@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,11 +15,20 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 
 	"example.com/monolithic/configs"
+	"example.com/monolithic/internal/core/ports"
 	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/core/validation"
 	"example.com/monolithic/internal/handlers"
 	custommw "example.com/monolithic/internal/middleware"
 	"example.com/monolithic/internal/platform/database"
 	"example.com/monolithic/internal/platform/database/migrations"
+	"example.com/monolithic/internal/platform/diagnostics"
+	"example.com/monolithic/internal/platform/email"
+	"example.com/monolithic/internal/platform/ids"
+	"example.com/monolithic/internal/platform/lifecycle"
+	"example.com/monolithic/internal/platform/security"
+	"example.com/monolithic/internal/platform/state"
+	"example.com/monolithic/internal/platform/storage"
 	"example.com/monolithic/internal/repositories"
 )
 
@@ -31,6 +42,15 @@ func main() {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Preflight checks for common misconfigurations
+	if err := diagnostics.CheckPort("DB_PORT", cfg.Database.Port); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := diagnostics.CheckListenAddress(cfg.Server.Address); err != nil {
+		logger.Fatalf("Cannot start server: %v", err)
+	}
+	dbTarget := diagnostics.DatabaseTarget(cfg.Database.User, cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName)
+
 	// Initialize database configuration
 	dbConfig := database.Config{
 		Host:        cfg.Database.Host,
@@ -44,18 +64,41 @@ func main() {
 		MaxLifetime: 1 * time.Hour,
 		HealthCheck: 30 * time.Second,
 		SSLMode:     cfg.Database.SSLMode,
+		MaxWaiters:  50,
+		// Saturating 20 times in a second means the pool isn't keeping up; shed load for 2s
+		Breaker: database.BreakerConfig{Threshold: 20, Window: time.Second, Cooldown: 2 * time.Second},
+	}
+	if cfg.Secrets.IsSecret("DB_PASSWORD") {
+		dbConfig.PasswordFunc = func(ctx context.Context) (string, error) {
+			return cfg.Secrets.Get(ctx, "DB_PASSWORD")
+		}
 	}
 
 	// Initialize database connection
 	db, err := database.NewConnection(dbConfig)
 	if err != nil {
-		logger.Fatalf("Failed to connect to database: %v", err)
+		logger.Fatalf("Failed to connect to database: %v", diagnostics.Database(err, dbTarget))
 	}
 	defer db.Close()
+	if cfg.Database.LeakThreshold > 0 {
+		db.SetLeakDetection(&database.LeakConfig{Threshold: cfg.Database.LeakThreshold, Logger: logger})
+	}
+	if cfg.Explain.SampleRate > 0 || cfg.Explain.SlowThreshold > 0 {
+		explain := database.ExplainConfig{
+			SampleRate:      cfg.Explain.SampleRate,
+			SlowThreshold:   cfg.Explain.SlowThreshold,
+			Analyze:         cfg.Explain.Analyze,
+			BudgetPerMinute: cfg.Explain.BudgetPerMinute,
+		}
+		if err := explain.Validate(); err != nil {
+			logger.Fatalf("Invalid configuration (check EXPLAIN_*): %v", err)
+		}
+		db.SetExplainConfig(&explain) // adjustable at runtime through /api/admin/explain
+	}
 
 	// Run database health check
 	if err := db.Ping(context.Background()); err != nil {
-		logger.Fatalf("Database health check failed: %v", err)
+		logger.Fatalf("Database health check failed: %v", diagnostics.Database(err, dbTarget))
 	}
 	logger.Println("Successfully connected to database")
 
@@ -65,38 +108,201 @@ func main() {
 	}
 
 	// Initialize repositories
-	userRepo := repositories.NewUserRepository(db)
+	var userRepo ports.UserRepository = repositories.NewUserRepository(db)
+	var shadowUsers ports.ShadowControl // left nil, so the admin shadow routes aren't served, unless configured
+	if cfg.ShadowUsers.DBName != "" {
+		// Shadow writes run outside the primary's transaction, so a rolled back
+		// primary write still reaches the shadow and later reads count it as divergence
+		shadowConfig := dbConfig
+		shadowConfig.Database = cfg.ShadowUsers.DBName
+		if err := migrations.RunMigrations(shadowConfig.GetConnectionURL()); err != nil {
+			logger.Fatalf("Failed to run shadow database migrations: %v", err)
+		}
+		shadowDB, err := database.NewConnection(shadowConfig)
+		if err != nil {
+			shadowTarget := diagnostics.DatabaseTarget(cfg.Database.User, cfg.Database.Host, cfg.Database.Port, cfg.ShadowUsers.DBName)
+			logger.Fatalf("Failed to connect to shadow database: %v", diagnostics.Database(err, shadowTarget))
+		}
+		defer shadowDB.Close()
+		shadow := repositories.NewShadow("users", cfg.ShadowUsers.Percent)
+		userRepo = repositories.NewShadowUserRepository(userRepo, repositories.NewUserRepository(shadowDB), shadow)
+		shadowUsers = shadow
+	}
+	tokenRepo := repositories.NewTokenRepository(db)
+	resetRepo := repositories.NewPasswordResetRepository(db)
+	refreshRepo := repositories.NewRefreshTokenRepository(db)
+	eventRepo := repositories.NewEventRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	savedFilterRepo := repositories.NewSavedFilterRepository(db)
 	//productRepo := repositories.NewProductRepository(db)
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	files, err := storage.NewLocalStorage(cfg.Storage.Dir)
+	if err != nil {
+		logger.Fatalf("Failed to initialize file storage: %v", err)
+	}
+	// Cap emails per recipient so nobody can flood an inbox through the verification or reset endpoints
+	mailer := email.NewRecipientLimiter(email.NewLogSender(logger), time.Hour, map[string]int{
+		ports.EmailVerification:  5,
+		ports.EmailPasswordReset: 5,
+	})
+	hasher, err := security.NewBcryptHasher(cfg.Auth.BcryptCost)
+	if err != nil {
+		logger.Fatalf("Invalid configuration (check BCRYPT_COST): %v", err)
+	}
+	userService := services.NewUserService(userRepo, tokenRepo, resetRepo, auditRepo, repositories.NewTransactor(db), hasher, ids.NewUUIDGenerator(), mailer, files, services.UserConfig{
+		VerificationTTL:        cfg.Auth.VerificationTTL,
+		VerifyURL:              cfg.Server.PublicURL + "/api/users/verify",
+		PasswordResetTTL:       cfg.Auth.PasswordResetTTL,
+		PasswordResetURL:       cfg.Auth.PasswordResetURL,
+		RestoreDeletedOnSignup: cfg.Auth.RestoreDeletedOnSignup,
+		ImportDeletedPolicy:    cfg.Auth.ImportDeletedPolicy,
+		AllowClientIDs:         cfg.Auth.AllowClientIDs,
+		AllowSelfDelete:        cfg.Auth.AllowSelfDelete,
+		PasswordPolicy: validation.PasswordPolicy{
+			MinLength:     cfg.Auth.PasswordMinLength,
+			RequireDigit:  cfg.Auth.PasswordRequireDigit,
+			RequireSymbol: cfg.Auth.PasswordRequireSymbol,
+			DenyList:      validation.CommonPasswords(),
+		},
+	})
+	eventService := services.NewEventService(eventRepo, services.EventConfig{
+		BufferSize:    10000,
+		FlushSize:     500,
+		FlushInterval: 2 * time.Second,
+		Retention:     90 * 24 * time.Hour,
+		PrecreateDays: 7,
+	})
+	savedFilterService := services.NewSavedFilterService(savedFilterRepo, ids.NewUUIDGenerator())
 	//productService := services.NewProductService(productRepo)
 
+	accessTokens, err := security.NewJWT([]byte(cfg.Auth.JWTSecret), cfg.Auth.AccessTokenTTL)
+	if err != nil {
+		logger.Fatalf("Invalid JWT configuration (set JWT_SECRET and ACCESS_TOKEN_TTL): %v", err)
+	}
+	authService := services.NewAuthService(userService, refreshRepo, accessTokens, services.AuthConfig{
+		RefreshTTL: cfg.Auth.RefreshTokenTTL,
+	})
+
 	// Initialize HTTP handlers
-	userHandler := handlers.NewUserHandler(userService)
+	idempotency := custommw.NewIdempotency(idempotencyRepo, custommw.IdempotencyConfig{
+		TTL:             24 * time.Hour,
+		CleanupInterval: time.Hour,
+		RetryAfter:      db.RetryAfter,
+		Logger:          logger,
+	})
+	authHandler := handlers.NewAuthHandler(authService, db.RetryAfter)
+	userHandler := handlers.NewUserHandler(userService, cfg.Storage.AvatarMaxBytes, idempotency.Handler, db.RetryAfter)
+	eventHandler := handlers.NewEventHandler(eventService)
+	savedFilterHandler := handlers.NewSavedFilterHandler(savedFilterService, userHandler, db.RetryAfter)
+	// The readiness probe reports the subsystems registered with app below
+	app := lifecycle.NewRegistry(logger)
+	healthHandler := handlers.NewHealthHandler(app)
 	//productHandler := handlers.NewProductHandler(productService)
 
 	// Create Chi router
 	r := chi.NewRouter()
 
 	// Middleware stack
-	r.Use(middleware.RequestID)
+	watchdog := custommw.NewWatchdog(custommw.WatchdogConfig{
+		Factor:         2,
+		DefaultTimeout: 60 * time.Second,
+		Interval:       10 * time.Second,
+		Logger:         logger,
+	})
+	r.Use(custommw.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second)) // maximum duration of 60 seconds for all HTTP requests handled by your server
+	r.Use(watchdog.Handler) // outside Recoverer and Timeout so every request is tracked
+	// Sampling rates are set once the routes exist and can be checked
+	logSampler := custommw.NewLogSampler(cfg.LogSampling.Slow, nil)
+	r.Use(logSampler.Handler)
+	r.Use(custommw.Recoverer)
+	r.Use(custommw.Timeout(60 * time.Second)) // maximum duration of 60 seconds for all HTTP requests handled by your server
 	r.Use(custommw.CORS)
-	r.Use(custommw.Authentication)
+	clientVersions, err := custommw.NewClientVersionGate(custommw.ClientVersionConfig{
+		Minimums:         clientMinimums(cfg),
+		UserAgentProduct: cfg.ClientVersions.UserAgentProduct,
+	})
+	if err != nil {
+		logger.Fatalf("Invalid configuration (check CLIENT_MIN_VERSIONS): %v", err)
+	}
+	r.Use(clientVersions.Handler)
+	r.Use(custommw.Authentication(accessTokens,
+		"POST /api/auth/login",
+		"POST /api/auth/refresh",
+		"POST /api/users", // sign up
+		"GET /api/users/verify",
+		"POST /api/users/password-reset",
+		"POST /api/users/password-reset/confirm",
+		"GET /health/ready",
+	))
+
+	// Mirror a share of user reads to a candidate deployment, when one is configured
+	var mirror *custommw.Mirror
+	if cfg.Mirror.URL != "" {
+		target, err := url.Parse(cfg.Mirror.URL)
+		if err != nil || !target.IsAbs() {
+			logger.Fatalf("Invalid configuration: MIRROR_URL must be an absolute URL, got %q", cfg.Mirror.URL)
+		}
+		mirror = custommw.NewMirror(custommw.MirrorConfig{
+			URL:          target,
+			Percent:      cfg.Mirror.Percent,
+			MaxBodyBytes: 64 << 10,
+		})
+	}
+
+	// Kept outside the users route group so its buckets can be saved on shutdown
+	var rateLimiter *custommw.MemoryRateLimiter
+	if cfg.RateLimit.PerSecond > 0 {
+		rateLimiter = custommw.NewMemoryRateLimiter(cfg.RateLimit.PerSecond, cfg.RateLimit.Burst)
+	}
+
+	// Admin endpoints tune the middleware above, so they are built with it
+	adminHandler := handlers.NewAdminHandler(handlers.AdminConfig{
+		Routes:         r,
+		LogSampler:     logSampler,
+		DB:             db,
+		ClientVersions: clientVersions,
+		Mirror:         mirror,
+		ShadowUsers:    shadowUsers,
+		Events:         eventService,
+		SavedFilters:   savedFilterHandler,
+	})
+
+	// Health probes
+	r.Mount("/health", healthHandler.Routes())
 
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		// Authentication endpoints
+		r.Mount("/auth", authHandler.Routes())
+
 		// Users endpoints
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.Timeout(200 * time.Second)) // route specific middleware
+			r.Use(custommw.Timeout(200 * time.Second)) // route specific middleware
+			if rateLimiter != nil {
+				r.Use(custommw.RateLimit(rateLimiter))
+			}
+			if mirror != nil {
+				r.Use(mirror.Handler)
+			}
 			r.Mount("/users", userHandler.Routes())
 		})
+
+		// Analytics events endpoints
+		r.Mount("/events", eventHandler.Routes())
+
+		// Operational endpoints for admins
+		r.Mount("/admin", adminHandler.Routes())
 	})
 
+	// Sampling is keyed by route pattern, so a rate naming no route would never apply
+	if err := custommw.CheckRates(r, cfg.LogSampling.Rates); err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	logSampler.SetRates(cfg.LogSampling.Rates)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         cfg.Server.Address,
@@ -107,42 +313,85 @@ func main() {
 		ErrorLog:     logger,
 	}
 
-	// Server run context
-	serverCtx, serverStopCtx := context.WithCancel(context.Background())
+	// Throttled clients and an open circuit stay that way across a graceful restart
+	saved := state.NewFile(cfg.State.File, cfg.State.MaxAge, logger)
+	saved.Register("db_breaker", db)
+	if rateLimiter != nil {
+		saved.Register("rate_limit", rateLimiter)
+	}
+
+	// Register subsystems; they start in dependency order and stop in reverse
+	serveErr := make(chan error, 1)
+	hooks := []lifecycle.Hook{
+		{
+			Name: "state",
+			Start: func(context.Context) error {
+				if cfg.State.File != "" {
+					saved.Restore(time.Now())
+				}
+				return nil
+			},
+			// Runs after http has stopped, so no request changes the state being saved
+			Stop: func(context.Context) error {
+				if cfg.State.File == "" {
+					return nil
+				}
+				return saved.Save(time.Now())
+			},
+		},
+		lifecycle.Go("events", eventService.Run), // flushes buffered events once stopped
+		lifecycle.Go("event-partitions", func(ctx context.Context) {
+			eventService.MaintainPartitions(ctx, 6*time.Hour)
+		}),
+		lifecycle.Go("watchdog", watchdog.Run),
+		lifecycle.Go("idempotency-cleanup", idempotency.Run),
+		{
+			Name:      "http",
+			DependsOn: []string{"state", "events", "event-partitions", "watchdog", "idempotency-cleanup"},
+			Timeout:   30 * time.Second, // grace period for in-flight requests
+			Start: func(context.Context) error {
+				ln, err := net.Listen("tcp", srv.Addr)
+				if err != nil {
+					return err
+				}
+				logger.Printf("Server is starting on %s\n", srv.Addr)
+				go func() { serveErr <- srv.Serve(ln) }()
+				return nil
+			},
+			Stop: srv.Shutdown,
+		},
+	}
+	for _, hook := range hooks {
+		if err := app.Register(hook); err != nil {
+			logger.Fatalf("Failed to register %s: %v", hook.Name, err)
+		}
+	}
+	if err := app.Start(context.Background()); err != nil {
+		logger.Fatalf("Startup failed: %v", err)
+	}
 
 	// Listen for syscall signals for graceful shutdown
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-	go func() {
-		<-sig
-
-		// Shutdown signal with grace period of 30 seconds
-		shutdownCtx, _ := context.WithTimeout(serverCtx, 30*time.Second)
-
-		go func() {
-			<-shutdownCtx.Done()
-			if shutdownCtx.Err() == context.DeadlineExceeded {
-				logger.Fatal("Graceful shutdown timed out.. forcing exit.")
-			}
-		}()
-
-		// Trigger graceful shutdown
-		err := srv.Shutdown(shutdownCtx)
-		if err != nil {
-			logger.Printf("Shutdown error: %v\n", err)
-		}
-		serverStopCtx()
-	}()
-
-	// Start server
-	logger.Printf("Server is starting on %s\n", srv.Addr)
-	err = srv.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		logger.Fatalf("Server error: %v\n", err)
+	select {
+	case <-sig:
+	case err := <-serveErr:
+		logger.Printf("Server error: %v\n", err)
 	}
 
-	// Wait for server context to be stopped
-	<-serverCtx.Done()
+	// Each hook's own timeout bounds its share of the shutdown
+	if err := app.Stop(context.Background()); err != nil {
+		logger.Printf("Shutdown error: %v\n", err)
+	}
 	logger.Println("Server stopped gracefully")
 }
+
+// clientMinimums pairs each platform's minimum app version with its upgrade URL
+func clientMinimums(cfg *configs.Config) map[string]custommw.ClientMinimum {
+	minimums := make(map[string]custommw.ClientMinimum, len(cfg.ClientVersions.MinVersions))
+	for platform, version := range cfg.ClientVersions.MinVersions {
+		minimums[platform] = custommw.ClientMinimum{Version: version, UpgradeURL: cfg.ClientVersions.UpgradeURLs[platform]}
+	}
+	return minimums
+}
@@ -0,0 +1,202 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManagerProvider reads secrets from AWS Secrets Manager using
+// SigV4-signed GetSecretValue calls with static or session credentials
+type AWSSecretsManagerProvider struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string // overrides the regional endpoint, e.g. for LocalStack
+	Client          *http.Client
+}
+
+func (p *AWSSecretsManagerProvider) Scheme() string {
+	return "awssm"
+}
+
+// Fetch reads awssm://<secret-id>[#<json-key>]. Without a fragment the whole SecretString is returned.
+func (p *AWSSecretsManagerProvider) Fetch(ctx context.Context, ref *url.URL) (string, error) {
+	if p.AccessKeyID == "" || p.SecretAccessKey == "" {
+		return "", fmt.Errorf("awssm: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+
+	secretID := strings.TrimPrefix(ref.Host+ref.Path, "/")
+	payload, _ := json.Marshal(map[string]string{"SecretId": secretID})
+
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + p.Region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	creds := awsCredentials{AccessKeyID: p.AccessKeyID, SecretAccessKey: p.SecretAccessKey, SessionToken: p.SessionToken}
+	signV4(req, payload, time.Now().UTC(), creds, p.Region, "secretsmanager")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("awssm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("awssm: GetSecretValue %s returned %s", secretID, resp.Status)
+	}
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("awssm: decode response: %w", err)
+	}
+
+	if ref.Fragment == "" {
+		return body.SecretString, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(body.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("awssm: secret %s is not a JSON object", secretID)
+	}
+	value, ok := fields[ref.Fragment].(string)
+	if !ok {
+		return "", fmt.Errorf("awssm: key %q not found in secret %s", ref.Fragment, secretID)
+	}
+	return value, nil
+}
+
+// awsCredentials are the keys a request is signed with
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signV4 adds AWS Signature Version 4 headers to req for service in region.
+// Every header already on req is signed, so set them all before signing.
+func signV4(req *http.Request, payload []byte, now time.Time, creds awsCredentials, region, service string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery encodes query sorted by name and then value, escaping everything but unreserved characters
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	escaped := make(map[string][]string, len(query))
+	for name, values := range query {
+		key := awsEscape(name)
+		names = append(names, key)
+		for _, value := range values {
+			escaped[key] = append(escaped[key], awsEscape(value))
+		}
+		sort.Strings(escaped[key])
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(query))
+	for _, name := range names {
+		for _, value := range escaped[name] {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes s as SigV4 requires, which unlike url.QueryEscape encodes spaces as %20
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
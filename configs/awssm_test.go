@@ -0,0 +1,212 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSignV4 checks the signer against cases from the AWS Signature Version 4 test suite
+func TestSignV4(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		header        http.Header
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name: "get-vanilla", method: http.MethodGet, target: "/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "post-vanilla", method: http.MethodPost, target: "/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name: "get-vanilla-query-order-key-case", method: http.MethodGet, target: "/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name: "post-x-www-form-urlencoded", method: http.MethodPost, target: "/",
+			header:        http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			body:          "Param1=value1",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "https://example.amazonaws.com"+tt.target, strings.NewReader(tt.body))
+			req.Header = tt.header.Clone()
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+
+			signV4(req, []byte(tt.body), now, creds, "us-east-1", "service")
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=" + tt.signedHeaders + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+// fakeSecretsManager serves GetSecretValue, refusing requests whose SigV4 signature
+// doesn't match the one it computes from the request it received
+type fakeSecretsManager struct {
+	*httptest.Server
+	creds  awsCredentials
+	region string
+
+	mu      sync.Mutex
+	secrets map[string]string // secret ID -> SecretString
+}
+
+func newFakeSecretsManager(t *testing.T, creds awsCredentials) *fakeSecretsManager {
+	t.Helper()
+	sm := &fakeSecretsManager{creds: creds, region: "eu-west-1", secrets: make(map[string]string)}
+	sm.Server = httptest.NewServer(http.HandlerFunc(sm.serve))
+	t.Cleanup(sm.Close)
+	return sm
+}
+
+func (sm *fakeSecretsManager) set(id, value string) {
+	sm.mu.Lock()
+	sm.secrets[id] = value
+	sm.mu.Unlock()
+}
+
+// provider returns an AWSSecretsManagerProvider pointed at the fake
+func (sm *fakeSecretsManager) provider(creds awsCredentials) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{
+		Region:          sm.region,
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Endpoint:        sm.URL + "/",
+	}
+}
+
+func (sm *fakeSecretsManager) serve(w http.ResponseWriter, r *http.Request) {
+	payload, _ := io.ReadAll(r.Body)
+	if r.Method != http.MethodPost || r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+		awsError(w, http.StatusBadRequest, "InvalidAction")
+		return
+	}
+	if !sm.verify(r, payload) {
+		awsError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	var req struct {
+		SecretID string `json:"SecretId"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		awsError(w, http.StatusBadRequest, "InvalidRequestException")
+		return
+	}
+	sm.mu.Lock()
+	value, ok := sm.secrets[req.SecretID]
+	sm.mu.Unlock()
+	if !ok {
+		awsError(w, http.StatusBadRequest, "ResourceNotFoundException")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	json.NewEncoder(w).Encode(map[string]string{"Name": req.SecretID, "SecretString": value})
+}
+
+// verify re-signs the headers r claims to have signed and compares the result
+func (sm *fakeSecretsManager) verify(r *http.Request, payload []byte) bool {
+	auth := r.Header.Get("Authorization")
+	_, signed, ok := strings.Cut(auth, "SignedHeaders=")
+	if !ok {
+		return false
+	}
+	signed, _, _ = strings.Cut(signed, ",")
+
+	now, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	resigned, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), bytes.NewReader(payload))
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" && name != "x-amz-date" && name != "x-amz-security-token" {
+			resigned.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+	if r.Header.Get("X-Amz-Security-Token") != sm.creds.SessionToken {
+		return false
+	}
+
+	signV4(resigned, payload, now, sm.creds, sm.region, "secretsmanager")
+	return resigned.Header.Get("Authorization") == auth
+}
+
+func awsError(w http.ResponseWriter, status int, kind string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"__type":%q}`, kind)
+}
+
+func TestAWSSecretsManagerFetch(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret-key", SessionToken: "session-token"}
+	sm := newFakeSecretsManager(t, creds)
+	sm.set("prod/db", `{"username":"app","password":"s3cret"}`)
+	sm.set("prod/plain", "not json")
+
+	tests := []struct {
+		name    string
+		creds   awsCredentials
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "whole secret", creds: creds, ref: "awssm://prod/db", want: `{"username":"app","password":"s3cret"}`},
+		{name: "json key", creds: creds, ref: "awssm://prod/db#password", want: "s3cret"},
+		{name: "missing key", creds: creds, ref: "awssm://prod/db#api_key", wantErr: `key "api_key" not found`},
+		{name: "key in a plain secret", creds: creds, ref: "awssm://prod/plain#password", wantErr: "not a JSON object"},
+		{name: "missing secret", creds: creds, ref: "awssm://prod/other", wantErr: "400"},
+		{name: "wrong secret key", creds: awsCredentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: "wrong", SessionToken: creds.SessionToken}, ref: "awssm://prod/db", wantErr: "403"},
+		{name: "missing session token", creds: awsCredentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: creds.SecretAccessKey}, ref: "awssm://prod/db", wantErr: "403"},
+		{name: "no credentials", ref: "awssm://prod/db", wantErr: "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, _ := url.Parse(tt.ref)
+
+			got, err := sm.provider(tt.creds).Fetch(context.Background(), ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Fetch() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,20 @@
 package configs
 
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Config struct {
 	Server struct {
 		Address string
 		Port    int
+		// PublicURL is the externally reachable base URL used in links sent to users
+		PublicURL string
 	}
 	Database struct {
 		Host     string
@@ -12,11 +23,374 @@ type Config struct {
 		Password string
 		DBName   string
 		SSLMode  string
+		// LeakThreshold enables Rows leak detection, logging result sets left open this long; 0 disables
+		LeakThreshold time.Duration
+	}
+	Storage struct {
+		// Dir is where uploaded files are kept on local disk
+		Dir            string
+		AvatarMaxBytes int64
+	}
+	Auth struct {
+		// JWTSecret signs access tokens; it must be at least 32 bytes
+		JWTSecret       string
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+
+		VerificationTTL  time.Duration
+		PasswordResetTTL time.Duration
+		// PasswordResetURL is the page reset links point at; it receives the token as ?token=
+		PasswordResetURL string
+		// BcryptCost is the bcrypt work factor for new password hashes; existing
+		// hashes with a different cost are rehashed on the user's next login
+		BcryptCost int
+		// PasswordMinLength, PasswordRequireDigit and PasswordRequireSymbol make up the
+		// password policy, together with a built-in list of common passwords
+		PasswordMinLength     int
+		PasswordRequireDigit  bool
+		PasswordRequireSymbol bool
+		// AllowClientIDs lets signup and bulk create requests choose the new user's ID
+		AllowClientIDs bool
+		// AllowSelfDelete lets an admin delete their own account
+		AllowSelfDelete bool
+		// RestoreDeletedOnSignup lets a signup with a deleted account's email restore that account
+		RestoreDeletedOnSignup bool
+		// ImportDeletedPolicy is what a CSV import does with a row whose email belongs to a
+		// deleted account: skip it, restore the account with the row's password, or report an error
+		ImportDeletedPolicy string
 	}
+
+	RateLimit struct {
+		// PerSecond is the sustained rate of mutating requests allowed per user; 0 disables limiting
+		PerSecond float64
+		// Burst is how many requests a user may send at once before PerSecond applies
+		Burst int
+	}
+
+	State struct {
+		// File keeps rate limiter buckets and the database circuit breaker across a graceful
+		// restart; empty disables saving them
+		File string
+		// MaxAge is how old a state file may be at startup and still be restored
+		MaxAge time.Duration
+	}
+
+	LogSampling struct {
+		// Slow is the duration from which a request is always logged, whatever its route's rate
+		Slow time.Duration
+		// Rates logs 1 in N successful requests per chi route pattern, e.g. "/api/events"
+		Rates map[string]int
+	}
+
+	ShadowUsers struct {
+		// DBName is a database on the same server that user writes are mirrored to and
+		// user reads compared against; empty disables shadowing
+		DBName string
+		// Percent is the share of user repository calls shadowed (0–100)
+		Percent int
+	}
+
+	Mirror struct {
+		// URL is where sampled read requests to /api/users are mirrored; empty disables mirroring
+		URL string
+		// Percent is the share of those requests mirrored (0–100)
+		Percent int
+	}
+
+	Explain struct {
+		// SampleRate is the fraction of statements whose plan is logged; with SlowThreshold 0, 0 disables
+		SampleRate float64
+		// SlowThreshold makes statements at least this slow always eligible; 0 disables
+		SlowThreshold time.Duration
+		// Analyze runs read-only statements under EXPLAIN ANALYZE
+		Analyze bool
+		// BudgetPerMinute caps the plans logged per minute
+		BudgetPerMinute int
+	}
+
+	ClientVersions struct {
+		// MinVersions is the oldest supported app build per platform; older builds get 426
+		MinVersions map[string]string
+		// UpgradeURLs tells each platform's too-old builds where to upgrade
+		UpgradeURLs map[string]string
+		// UserAgentProduct is the apps' User-Agent product token, read when X-Client-Version is absent
+		UserAgentProduct string
+	}
+
+	// Secrets resolves secret references (vault://..., awssm://...) in config values
+	Secrets *SecretResolver
 }
 
 func Load() (*Config, error) {
-	// Load configuration from environment variables or config file
-	// Implementation details here
-	return &Config{}, nil
+	cfg := &Config{}
+
+	// Load configuration from environment variables
+	port, err := getEnvInt("SERVER_PORT", 8080)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Server.Port = port
+	cfg.Server.Address = getEnv("SERVER_ADDRESS", fmt.Sprintf(":%d", port))
+	cfg.Server.PublicURL = strings.TrimSuffix(getEnv("PUBLIC_URL", fmt.Sprintf("http://localhost:%d", port)), "/")
+
+	dbPort, err := getEnvInt("DB_PORT", 5432)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Database.Host = getEnv("DB_HOST", "localhost")
+	cfg.Database.Port = dbPort
+	cfg.Database.User = getEnv("DB_USER", "postgres")
+	cfg.Database.Password = getEnv("DB_PASSWORD", "")
+	cfg.Database.DBName = getEnv("DB_NAME", "postgres")
+	cfg.Database.SSLMode = getEnv("DB_SSLMODE", "disable")
+	leakThreshold, err := getEnvDuration("DB_LEAK_THRESHOLD", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Database.LeakThreshold = leakThreshold
+
+	cfg.Storage.Dir = getEnv("STORAGE_DIR", "data/uploads")
+	avatarMaxBytes, err := getEnvInt("AVATAR_MAX_BYTES", 2<<20)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Storage.AvatarMaxBytes = int64(avatarMaxBytes)
+
+	cfg.Auth.JWTSecret = getEnv("JWT_SECRET", "")
+	accessTokenTTL, err := getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.AccessTokenTTL = accessTokenTTL
+	refreshTokenTTL, err := getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.RefreshTokenTTL = refreshTokenTTL
+
+	verificationTTL, err := getEnvDuration("VERIFICATION_TOKEN_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.VerificationTTL = verificationTTL
+	passwordResetTTL, err := getEnvDuration("PASSWORD_RESET_TOKEN_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.PasswordResetTTL = passwordResetTTL
+	cfg.Auth.PasswordResetURL = getEnv("PASSWORD_RESET_URL", cfg.Server.PublicURL+"/reset-password")
+	bcryptCost, err := getEnvInt("BCRYPT_COST", 10)
+	if err != nil {
+		return nil, err
+	}
+	if bcryptCost < 4 || bcryptCost > 31 {
+		return nil, fmt.Errorf("BCRYPT_COST must be between 4 and 31, got %d", bcryptCost)
+	}
+	cfg.Auth.BcryptCost = bcryptCost
+	passwordMinLength, err := getEnvInt("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return nil, err
+	}
+	if passwordMinLength < 1 {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be positive, got %d", passwordMinLength)
+	}
+	cfg.Auth.PasswordMinLength = passwordMinLength
+	if cfg.Auth.PasswordRequireDigit, err = getEnvBool("PASSWORD_REQUIRE_DIGIT", false); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.PasswordRequireSymbol, err = getEnvBool("PASSWORD_REQUIRE_SYMBOL", false); err != nil {
+		return nil, err
+	}
+	restoreOnSignup, err := getEnvBool("RESTORE_DELETED_ON_SIGNUP", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.RestoreDeletedOnSignup = restoreOnSignup
+	switch cfg.Auth.ImportDeletedPolicy = getEnv("IMPORT_DELETED_POLICY", "skip"); cfg.Auth.ImportDeletedPolicy {
+	case "skip", "restore", "error":
+	default:
+		return nil, fmt.Errorf("IMPORT_DELETED_POLICY must be skip, restore or error, got %q", cfg.Auth.ImportDeletedPolicy)
+	}
+	if cfg.Auth.AllowClientIDs, err = getEnvBool("ALLOW_CLIENT_IDS", false); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.AllowSelfDelete, err = getEnvBool("ALLOW_SELF_DELETE", false); err != nil {
+		return nil, err
+	}
+
+	rateLimit, err := getEnvFloat("RATE_LIMIT_PER_SECOND", 1)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.PerSecond = rateLimit
+	rateLimitBurst, err := getEnvInt("RATE_LIMIT_BURST", 10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.Burst = rateLimitBurst
+
+	cfg.State.File = getEnv("STATE_FILE", "")
+	if cfg.State.MaxAge, err = getEnvDuration("STATE_MAX_AGE", 5*time.Minute); err != nil {
+		return nil, err
+	}
+
+	if cfg.LogSampling.Slow, err = getEnvDuration("LOG_SLOW_THRESHOLD", time.Second); err != nil {
+		return nil, err
+	}
+	// High-volume analytics ingestion is sampled by default
+	if cfg.LogSampling.Rates, err = getEnvRates("LOG_SAMPLE_RATES", map[string]int{"/api/events": 100}); err != nil {
+		return nil, err
+	}
+
+	cfg.ShadowUsers.DBName = getEnv("SHADOW_USERS_DB_NAME", "")
+	if cfg.ShadowUsers.Percent, err = getEnvInt("SHADOW_USERS_PERCENT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ShadowUsers.Percent < 0 || cfg.ShadowUsers.Percent > 100 {
+		return nil, fmt.Errorf("SHADOW_USERS_PERCENT must be between 0 and 100, got %d", cfg.ShadowUsers.Percent)
+	}
+
+	cfg.Mirror.URL = getEnv("MIRROR_URL", "")
+	if cfg.Mirror.Percent, err = getEnvInt("MIRROR_PERCENT", 1); err != nil {
+		return nil, err
+	}
+	if cfg.Mirror.Percent < 0 || cfg.Mirror.Percent > 100 {
+		return nil, fmt.Errorf("MIRROR_PERCENT must be between 0 and 100, got %d", cfg.Mirror.Percent)
+	}
+
+	if cfg.Explain.SampleRate, err = getEnvFloat("EXPLAIN_SAMPLE_RATE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Explain.SlowThreshold, err = getEnvDuration("EXPLAIN_SLOW_THRESHOLD", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Explain.Analyze, err = getEnvBool("EXPLAIN_ANALYZE", false); err != nil {
+		return nil, err
+	}
+	if cfg.Explain.BudgetPerMinute, err = getEnvInt("EXPLAIN_BUDGET_PER_MINUTE", 10); err != nil {
+		return nil, err
+	}
+
+	if cfg.ClientVersions.MinVersions, err = getEnvMap("CLIENT_MIN_VERSIONS"); err != nil {
+		return nil, err
+	}
+	if cfg.ClientVersions.UpgradeURLs, err = getEnvMap("CLIENT_UPGRADE_URLS"); err != nil {
+		return nil, err
+	}
+	for platform := range cfg.ClientVersions.MinVersions {
+		if cfg.ClientVersions.UpgradeURLs[platform] == "" {
+			return nil, fmt.Errorf("CLIENT_UPGRADE_URLS has no entry for %s", platform)
+		}
+	}
+	cfg.ClientVersions.UserAgentProduct = getEnv("CLIENT_USER_AGENT_PRODUCT", "")
+
+	// Resolve secret references
+	cfg.Secrets = NewSecretResolver(5*time.Minute, DefaultSecretProviders()...)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	secrets := map[string]*string{
+		"DB_USER":     &cfg.Database.User,
+		"DB_PASSWORD": &cfg.Database.Password,
+		"JWT_SECRET":  &cfg.Auth.JWTSecret,
+	}
+	for key, value := range secrets {
+		if err := cfg.Secrets.Register(ctx, key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	return f, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvRates parses "pattern=N,pattern=N"; an empty value means no rates
+func getEnvRates(key string, fallback map[string]int) (map[string]int, error) {
+	if _, ok := os.LookupEnv(key); !ok {
+		return fallback, nil
+	}
+	entries, err := getEnvMap(key)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]int, len(entries))
+	for pattern, raw := range entries {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s entry for %q must be an integer: %w", key, pattern, err)
+		}
+		rates[pattern] = n
+	}
+	return rates, nil
+}
+
+// getEnvMap parses "key=value,key=value"; an unset or empty value means no entries
+func getEnvMap(key string) (map[string]string, error) {
+	entries := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s entry %q must be key=value", key, entry)
+		}
+		entries[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return entries, nil
 }
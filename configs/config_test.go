@@ -0,0 +1,81 @@
+package configs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadBcryptCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr string
+	}{
+		{name: "default", want: 10},
+		{name: "configured", value: "12", want: 12},
+		{name: "minimum", value: "4", want: 4},
+		{name: "below bcrypt's range", value: "3", wantErr: "BCRYPT_COST must be between 4 and 31, got 3"},
+		{name: "above bcrypt's range", value: "32", wantErr: "BCRYPT_COST must be between 4 and 31, got 32"},
+		{name: "not a number", value: "high", wantErr: "BCRYPT_COST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", tt.value) // restored after the test
+			if tt.value == "" {
+				os.Unsetenv("BCRYPT_COST")
+			}
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Auth.BcryptCost != tt.want {
+				t.Errorf("BcryptCost = %d, want %d", cfg.Auth.BcryptCost, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadImportDeletedPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "skip"},
+		{name: "restore", value: "restore", want: "restore"},
+		{name: "error", value: "error", want: "error"},
+		{name: "unknown", value: "overwrite", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMPORT_DELETED_POLICY", tt.value)
+			if tt.value == "" {
+				os.Unsetenv("IMPORT_DELETED_POLICY")
+			}
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "IMPORT_DELETED_POLICY") {
+					t.Fatalf("Load() error = %v, want IMPORT_DELETED_POLICY rejected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Auth.ImportDeletedPolicy != tt.want {
+				t.Errorf("ImportDeletedPolicy = %q, want %q", cfg.Auth.ImportDeletedPolicy, tt.want)
+			}
+		})
+	}
+}
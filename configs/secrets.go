@@ -0,0 +1,138 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SecretProvider fetches a secret addressed by a reference URI such as
+// vault://secret/data/app#db_password
+type SecretProvider interface {
+	Scheme() string
+	Fetch(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// secretSchemes are the URI schemes treated as secret references; any other value is used literally
+var secretSchemes = map[string]bool{
+	"vault": true,
+	"awssm": true,
+}
+
+// SecretResolver resolves config values that reference a secret provider.
+// Resolved values are cached for ttl; Refresh forces a re-fetch so rotated
+// credentials can be picked up without a restart.
+type SecretResolver struct {
+	ttl       time.Duration
+	providers map[string]SecretProvider
+
+	mu    sync.Mutex
+	refs  map[string]*url.URL // config key -> secret reference
+	cache map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+func NewSecretResolver(ttl time.Duration, providers ...SecretProvider) *SecretResolver {
+	r := &SecretResolver{
+		ttl:       ttl,
+		providers: make(map[string]SecretProvider),
+		refs:      make(map[string]*url.URL),
+		cache:     make(map[string]cachedSecret),
+	}
+	for _, p := range providers {
+		r.providers[p.Scheme()] = p
+	}
+	return r
+}
+
+// Register resolves *value in place if it is a secret reference and remembers
+// the reference under key for later Get/Refresh calls. Plain values are left untouched.
+func (r *SecretResolver) Register(ctx context.Context, key string, value *string) error {
+	ref, err := url.Parse(*value)
+	if err != nil || !secretSchemes[ref.Scheme] {
+		return nil
+	}
+
+	if _, ok := r.providers[ref.Scheme]; !ok {
+		return fmt.Errorf("resolve %s: no secret provider configured for %q", key, ref.Scheme)
+	}
+
+	r.mu.Lock()
+	r.refs[key] = ref
+	r.mu.Unlock()
+
+	resolved, err := r.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	*value = resolved
+	return nil
+}
+
+// Get returns the current value of a registered secret, fetching it when the cached copy is older than the TTL
+func (r *SecretResolver) Get(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	ref, ok := r.refs[key]
+	cached, fresh := r.cache[key]
+	r.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("resolve %s: not a registered secret", key)
+	}
+	if fresh && time.Since(cached.fetchedAt) < r.ttl {
+		return cached.value, nil
+	}
+
+	value, err := r.providers[ref.Scheme].Fetch(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", key, err)
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cachedSecret{value: value, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return value, nil
+}
+
+// Refresh drops the cached value of key and fetches it again
+func (r *SecretResolver) Refresh(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	delete(r.cache, key)
+	r.mu.Unlock()
+	return r.Get(ctx, key)
+}
+
+// IsSecret reports whether key was registered from a secret reference
+func (r *SecretResolver) IsSecret(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.refs[key]
+	return ok
+}
+
+// DefaultSecretProviders returns the providers that are configured through the environment
+func DefaultSecretProviders() []SecretProvider {
+	var providers []SecretProvider
+	if addr := getEnv("VAULT_ADDR", ""); addr != "" {
+		providers = append(providers, &VaultProvider{
+			Addr:    addr,
+			Token:   getEnv("VAULT_TOKEN", ""),
+			K8sRole: getEnv("VAULT_K8S_ROLE", ""),
+		})
+	}
+	if region := getEnv("AWS_REGION", ""); region != "" {
+		providers = append(providers, &AWSSecretsManagerProvider{
+			Region:          region,
+			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		})
+	}
+	return providers
+}
@@ -0,0 +1,80 @@
+package configs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecretResolverRegister(t *testing.T) {
+	vault := newFakeVault(t, "root")
+	vault.setKV2("kv/data/app", map[string]interface{}{"jwt_secret": "from-vault"})
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret-key"}
+	sm := newFakeSecretsManager(t, creds)
+	sm.set("prod/db", `{"password":"from-aws"}`)
+
+	tests := []struct {
+		name       string
+		providers  []SecretProvider
+		value      string
+		want       string
+		wantSecret bool
+		wantErr    string
+	}{
+		{name: "plain value", value: "literal", want: "literal"},
+		{name: "url that is not a reference", value: "postgres://db:5432/app", want: "postgres://db:5432/app"},
+		{name: "vault", providers: []SecretProvider{&VaultProvider{Addr: vault.URL, Token: "root"}}, value: "vault://kv/data/app#jwt_secret", want: "from-vault", wantSecret: true},
+		{name: "aws", providers: []SecretProvider{sm.provider(creds)}, value: "awssm://prod/db#password", want: "from-aws", wantSecret: true},
+		{name: "no provider", value: "vault://kv/data/app#jwt_secret", wantErr: `no secret provider configured for "vault"`},
+		{name: "fetch fails", providers: []SecretProvider{&VaultProvider{Addr: vault.URL, Token: "stale"}}, value: "vault://kv/data/app#jwt_secret", wantErr: "resolve KEY: vault:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSecretResolver(time.Minute, tt.providers...)
+			value := tt.value
+
+			err := r.Register(context.Background(), "KEY", &value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Register() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || value != tt.want {
+				t.Fatalf("Register() = %q, %v; want %q", value, err, tt.want)
+			}
+			if r.IsSecret("KEY") != tt.wantSecret {
+				t.Errorf("IsSecret() = %v, want %v", r.IsSecret("KEY"), tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestSecretResolverRotation(t *testing.T) {
+	vault := newFakeVault(t, "root")
+	vault.setKV1("secret/app", map[string]interface{}{"db_password": "first"})
+	ctx := context.Background()
+
+	r := NewSecretResolver(time.Hour, &VaultProvider{Addr: vault.URL, Token: "root"})
+	value := "vault://secret/app#db_password"
+	if err := r.Register(ctx, "DB_PASSWORD", &value); err != nil || value != "first" {
+		t.Fatalf("Register() = %q, %v; want first", value, err)
+	}
+
+	vault.setKV1("secret/app", map[string]interface{}{"db_password": "second"})
+	if got, err := r.Get(ctx, "DB_PASSWORD"); err != nil || got != "first" {
+		t.Errorf("Get() within the TTL = %q, %v; want the cached first", got, err)
+	}
+	if got, err := r.Refresh(ctx, "DB_PASSWORD"); err != nil || got != "second" {
+		t.Errorf("Refresh() = %q, %v; want the rotated second", got, err)
+	}
+	if got, err := r.Get(ctx, "DB_PASSWORD"); err != nil || got != "second" {
+		t.Errorf("Get() after refresh = %q, %v; want second", got, err)
+	}
+
+	if _, err := r.Get(ctx, "JWT_SECRET"); err == nil {
+		t.Error("Get() of an unregistered key succeeded")
+	}
+}
@@ -0,0 +1,164 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+const defaultK8sTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultProvider reads secrets from HashiCorp Vault KV engines (v1 or v2).
+// It authenticates with a static token, or with the Kubernetes auth method when K8sRole is set.
+type VaultProvider struct {
+	Addr         string
+	Token        string
+	K8sRole      string
+	K8sMountPath string // defaults to "kubernetes"
+	K8sTokenPath string // defaults to the in-cluster service account token
+	Client       *http.Client
+
+	mu          sync.Mutex
+	clientToken string
+}
+
+func (p *VaultProvider) Scheme() string {
+	return "vault"
+}
+
+// Fetch reads vault://<mount>/<path>#<field>
+func (p *VaultProvider) Fetch(ctx context.Context, ref *url.URL) (string, error) {
+	if ref.Fragment == "" {
+		return "", fmt.Errorf("vault reference %q must name a field after #", ref.Redacted())
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := p.read(ctx, ref.Host+ref.Path, &body); err != nil {
+		return "", err
+	}
+
+	// KV v2 nests the secret under data.data
+	fields := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		if _, hasMetadata := body.Data["metadata"]; hasMetadata {
+			fields = nil
+			if err := json.Unmarshal(nested, &fields); err != nil {
+				return "", fmt.Errorf("vault: decode secret: %w", err)
+			}
+		}
+	}
+
+	raw, ok := fields[ref.Fragment]
+	if !ok {
+		return "", fmt.Errorf("vault: field %q not found at %s", ref.Fragment, ref.Host+ref.Path)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("vault: field %q is not a string", ref.Fragment)
+	}
+	return value, nil
+}
+
+// read GETs path into out. A Kubernetes login token that Vault refuses may have
+// expired, so read logs in again and retries once.
+func (p *VaultProvider) read(ctx context.Context, path string, out interface{}) error {
+	endpoint := strings.TrimRight(p.Addr, "/") + "/v1/" + path
+	for retried := false; ; retried = true {
+		token, err := p.token(ctx)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Vault-Token", token)
+
+		status, err := p.do(req, out)
+		if status != http.StatusForbidden || p.K8sRole == "" || retried {
+			return err
+		}
+
+		p.mu.Lock()
+		if p.clientToken == token {
+			p.clientToken = ""
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *VaultProvider) token(ctx context.Context) (string, error) {
+	if p.K8sRole == "" {
+		if p.Token == "" {
+			return "", fmt.Errorf("vault: VAULT_TOKEN or VAULT_K8S_ROLE is required")
+		}
+		return p.Token, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clientToken != "" {
+		return p.clientToken, nil
+	}
+
+	tokenPath := p.K8sTokenPath
+	if tokenPath == "" {
+		tokenPath = defaultK8sTokenPath
+	}
+	jwt, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return "", fmt.Errorf("vault: read service account token: %w", err)
+	}
+
+	mount := p.K8sMountPath
+	if mount == "" {
+		mount = "kubernetes"
+	}
+	payload, _ := json.Marshal(map[string]string{"role": p.K8sRole, "jwt": strings.TrimSpace(string(jwt))})
+	endpoint := strings.TrimRight(p.Addr, "/") + "/v1/auth/" + mount + "/login"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var body struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if _, err := p.do(req, &body); err != nil {
+		return "", fmt.Errorf("vault: kubernetes login: %w", err)
+	}
+	p.clientToken = body.Auth.ClientToken
+	return p.clientToken, nil
+}
+
+func (p *VaultProvider) do(req *http.Request, out interface{}) (int, error) {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("vault: %s returned %s", req.URL.Path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("vault: decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,183 @@
+package configs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault serves KV reads and Kubernetes logins the way Vault's HTTP API does
+type fakeVault struct {
+	*httptest.Server
+	role string
+	jwt  string
+
+	mu      sync.Mutex
+	secrets map[string]string // path under /v1/ -> response body
+	tokens  map[string]bool   // tokens Vault currently accepts
+	logins  int
+}
+
+func newFakeVault(t *testing.T, rootToken string) *fakeVault {
+	t.Helper()
+	v := &fakeVault{
+		role:    "app",
+		jwt:     "service-account-jwt",
+		secrets: make(map[string]string),
+		tokens:  map[string]bool{rootToken: true},
+	}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.serve))
+	t.Cleanup(v.Close)
+	return v
+}
+
+// setKV1 stores data at path as a KV v1 engine returns it
+func (v *fakeVault) setKV1(path string, data map[string]interface{}) {
+	body, _ := json.Marshal(map[string]interface{}{"data": data})
+	v.mu.Lock()
+	v.secrets[path] = string(body)
+	v.mu.Unlock()
+}
+
+// setKV2 stores data at path as a KV v2 engine returns it, nested under data.data
+func (v *fakeVault) setKV2(path string, data map[string]interface{}) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 1}},
+	})
+	v.mu.Lock()
+	v.secrets[path] = string(body)
+	v.mu.Unlock()
+}
+
+// expireLogins revokes every token issued by a Kubernetes login
+func (v *fakeVault) expireLogins() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for token := range v.tokens {
+		if strings.HasPrefix(token, "k8s-") {
+			delete(v.tokens, token)
+		}
+	}
+}
+
+func (v *fakeVault) loginCount() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.logins
+}
+
+func (v *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if r.Method == http.MethodPost && r.URL.Path == "/v1/auth/kubernetes/login" {
+		var login struct {
+			Role string `json:"role"`
+			JWT  string `json:"jwt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Role != v.role || login.JWT != v.jwt {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		v.logins++
+		token := fmt.Sprintf("k8s-%d", v.logins)
+		v.tokens[token] = true
+		fmt.Fprintf(w, `{"auth":{"client_token":%q}}`, token)
+		return
+	}
+
+	if r.Method != http.MethodGet || !v.tokens[r.Header.Get("X-Vault-Token")] {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+	body, ok := v.secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+	if !ok {
+		http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+func TestVaultFetch(t *testing.T) {
+	vault := newFakeVault(t, "root")
+	vault.setKV1("secret/app", map[string]interface{}{"db_password": "kv1-secret"})
+	vault.setKV2("kv/data/app", map[string]interface{}{"db_password": "kv2-secret", "port": 5432})
+
+	tests := []struct {
+		name    string
+		token   string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "kv v1", token: "root", ref: "vault://secret/app#db_password", want: "kv1-secret"},
+		{name: "kv v2", token: "root", ref: "vault://kv/data/app#db_password", want: "kv2-secret"},
+		{name: "missing field", token: "root", ref: "vault://kv/data/app#api_key", wantErr: `field "api_key" not found`},
+		{name: "field not a string", token: "root", ref: "vault://kv/data/app#port", wantErr: `field "port" is not a string`},
+		{name: "no field", token: "root", ref: "vault://kv/data/app", wantErr: "must name a field"},
+		{name: "missing secret", token: "root", ref: "vault://kv/data/other#db_password", wantErr: "404"},
+		{name: "refused token", token: "stale", ref: "vault://secret/app#db_password", wantErr: "403"},
+		{name: "no token", ref: "vault://secret/app#db_password", wantErr: "VAULT_TOKEN or VAULT_K8S_ROLE is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &VaultProvider{Addr: vault.URL, Token: tt.token}
+			ref, _ := url.Parse(tt.ref)
+
+			got, err := p.Fetch(context.Background(), ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Fetch() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultKubernetesLogin(t *testing.T) {
+	vault := newFakeVault(t, "root")
+	vault.setKV2("kv/data/app", map[string]interface{}{"db_password": "kv2-secret"})
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte(vault.jwt+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ref, _ := url.Parse("vault://kv/data/app#db_password")
+	ctx := context.Background()
+
+	p := &VaultProvider{Addr: vault.URL, K8sRole: "app", K8sTokenPath: tokenPath}
+	for i := 0; i < 2; i++ {
+		if got, err := p.Fetch(ctx, ref); err != nil || got != "kv2-secret" {
+			t.Fatalf("Fetch() = %q, %v; want kv2-secret", got, err)
+		}
+	}
+	if n := vault.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1 while the token is accepted", n)
+	}
+
+	vault.expireLogins()
+	if got, err := p.Fetch(ctx, ref); err != nil || got != "kv2-secret" {
+		t.Fatalf("Fetch() after expiry = %q, %v; want kv2-secret", got, err)
+	}
+	if n := vault.loginCount(); n != 2 {
+		t.Errorf("logins = %d, want a second login after the token expired", n)
+	}
+
+	wrongRole := &VaultProvider{Addr: vault.URL, K8sRole: "other", K8sTokenPath: tokenPath}
+	if _, err := wrongRole.Fetch(ctx, ref); err == nil || !strings.Contains(err.Error(), "kubernetes login") {
+		t.Errorf("Fetch() with an unknown role error = %v, want a login failure", err)
+	}
+}
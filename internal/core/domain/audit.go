@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Audited entity types
+const (
+	AuditEntityUser = "user"
+)
+
+// Audit actions
+const (
+	AuditUserCreated     = "user.created"
+	AuditUserUpdated     = "user.updated"
+	AuditUserDeleted     = "user.deleted"
+	AuditPasswordChanged = "user.password_changed"
+	// AuditPasswordRehashed records a stored password upgraded to the current hash on login
+	AuditPasswordRehashed = "user.password_rehashed"
+	AuditRoleChanged      = "user.role_changed"
+)
+
+// AuditChange is one field's value before and after a mutation; nil means the field was unset
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEvent records a mutation of one entity and the fields it changed
+type AuditEvent struct {
+	ID         string
+	ActorID    *string // nil when no authenticated user made the change, e.g. signup
+	Action     string
+	EntityType string
+	EntityID   string
+	Diff       map[string]AuditChange
+	CreatedAt  time.Time
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// AnalyticsEvent is a single anonymized product analytics event
+type AnalyticsEvent struct {
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
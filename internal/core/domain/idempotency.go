@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key.
+// ResponseStatus is zero while the original request is still in progress.
+type IdempotencyRecord struct {
+	Key                 string
+	RequestHash         string
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+	ExpiresAt           time.Time
+	CreatedAt           time.Time
+}
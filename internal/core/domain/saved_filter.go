@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Resources a saved filter can apply to
+const (
+	SavedFilterUsers = "users"
+)
+
+// SavedFilter is a named set of list query parameters an admin can apply again later.
+// Only the owner may change it; a shared filter can be used by every admin.
+type SavedFilter struct {
+	ID       string
+	OwnerID  string
+	Name     string
+	Resource string
+	// Params are query parameter values by name, as they would appear in the listing's URL
+	Params    map[string]string
+	Shared    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
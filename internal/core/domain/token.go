@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// VerificationToken is a single-use token proving control of a user's email address.
+// Only the hash of the token is persisted.
+type VerificationToken struct {
+	TokenHash string
+	UserID    string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordResetToken is a single-use token authorizing one password change without the current password.
+// Only the hash of the token is persisted.
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RefreshToken lets a client obtain new access tokens without logging in again.
+// Tokens rotate on every use; all tokens descended from one login share a FamilyID.
+type RefreshToken struct {
+	TokenHash string
+	UserID    string
+	FamilyID  string
+	ExpiresAt time.Time
+	Revoked   bool
+	CreatedAt time.Time
+}
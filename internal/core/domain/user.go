@@ -1,11 +1,33 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Roles lists every role a user can be assigned
+var Roles = []string{RoleUser, RoleAdmin}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
 
 type User struct {
-    ID        string    `json:"id"`
-    Email     string    `json:"email"`
-    Password  string    `json:"-"`
-    CreatedAt time.Time `json:"created_at"`
-    UpdatedAt time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	Password        string     `json:"-"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	AvatarURL       *string    `json:"-"` // storage location of the avatar image, if one was uploaded
+	Version         int        `json:"-"` // incremented by every update, for optimistic concurrency
+	DeletedAt       *time.Time `json:"-"` // set when the account is deleted; the row is kept until purged
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
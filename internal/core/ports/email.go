@@ -0,0 +1,22 @@
+package ports
+
+import "context"
+
+// Email kinds, used to rate limit each type of message separately
+const (
+	EmailVerification  = "verification"
+	EmailPasswordReset = "password_reset"
+)
+
+// EmailMessage is a plain-text email addressed to a single recipient
+type EmailMessage struct {
+	Kind    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailSender delivers transactional email
+type EmailSender interface {
+	Send(ctx context.Context, msg EmailMessage) error
+}
@@ -0,0 +1,6 @@
+package ports
+
+// IDGenerator mints identifiers for new records
+type IDGenerator interface {
+	NewID() string
+}
@@ -3,17 +3,178 @@ package ports
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"example.com/monolithic/internal/core/domain"
 )
 
 var ErrNotFound = errors.New("not found")
-var ErrDuplicateEmail = errors.New("Duplicate email")
+var ErrConflict = errors.New("conflict")
+var ErrUnavailable = errors.New("storage unavailable")
+var ErrVersionConflict = errors.New("version conflict")
+
+// ConflictError reports a uniqueness violation on a logical field.
+// Field is empty when the violated constraint isn't declared by the repository.
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return "conflict"
+	}
+	return e.Field + " already exists"
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// BatchItemError identifies the entry that caused a batch operation to fail
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// UserSort orders a user listing by one of UserSortFields
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserSortFields are the field names users can be listed by
+var UserSortFields = []string{"created_at", "email", "updated_at"}
+
+// UserFilter selects and pages a user listing. Zero times leave that bound open.
+type UserFilter struct {
+	CreatedAfter  time.Time // inclusive
+	CreatedBefore time.Time // inclusive
+	Sort          UserSort
+	Limit         int
+	Offset        int
+}
 
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
+	CreateMany(ctx context.Context, users []*domain.User) error
+	// ImportMany inserts users in one transaction, skipping any whose email or ID already exists.
+	// inserted[i] reports whether users[i] was written.
+	ImportMany(ctx context.Context, users []*domain.User) (inserted []bool, err error)
 	GetByID(ctx context.Context, id string) (*domain.User, error)
+	ExistsByID(ctx context.Context, id string) (bool, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	// CountByRole counts users with role, excluding deleted ones
+	CountByRole(ctx context.Context, role string) (int, error)
+	// Update writes user only if its stored version still equals user.Version, then increments
+	// user.Version; a stale version returns ErrVersionConflict and a missing row ErrNotFound
 	Update(ctx context.Context, user *domain.User) error
+	// Delete soft-deletes a user; every read except GetDeletedByEmail skips deleted users
+	Delete(ctx context.Context, id string) error
+	// Restore undoes Delete, returning ErrNotFound unless the user is currently deleted
+	Restore(ctx context.Context, id string) error
+	GetDeletedByEmail(ctx context.Context, email string) (*domain.User, error)
+	// PurgeDeletedBefore permanently removes users deleted before cutoff
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	List(ctx context.Context, filter UserFilter) ([]domain.User, error)
+	// Count returns how many users match filter, ignoring its Sort, Limit and Offset
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	SearchByEmail(ctx context.Context, prefix string, limit int) ([]domain.User, error)
+	// ForEach streams users created after createdAfter (all users when zero) in creation order,
+	// stopping at the first error returned by fn
+	ForEach(ctx context.Context, createdAfter time.Time, fn func(*domain.User) error) error
+}
+
+// TokenRepository stores email verification tokens by hash
+type TokenRepository interface {
+	Create(ctx context.Context, token *domain.VerificationToken) error
+	GetByHash(ctx context.Context, hash string) (*domain.VerificationToken, error)
+	// MarkUsed consumes an unused token, returning ErrNotFound if it was already used
+	MarkUsed(ctx context.Context, hash string, at time.Time) error
+}
+
+// PasswordResetRepository stores password reset tokens by hash
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+	GetByHash(ctx context.Context, hash string) (*domain.PasswordResetToken, error)
+	// MarkUsed consumes an unused token, returning ErrNotFound if it was already used
+	MarkUsed(ctx context.Context, hash string, at time.Time) error
+}
+
+// RefreshTokenRepository stores refresh tokens by hash
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error)
+	// Rotate revokes the unrevoked token oldHash and stores next in one transaction,
+	// returning ErrNotFound if oldHash was already revoked
+	Rotate(ctx context.Context, oldHash string, next *domain.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+type EventRepository interface {
+	InsertBatch(ctx context.Context, events []domain.AnalyticsEvent) error
+	CreatePartition(ctx context.Context, day time.Time) error
+	DropPartitionsBefore(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// AuditRepository stores the audit trail of entity mutations
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+}
+
+// SavedFilterRepository stores admins' saved list filters
+type SavedFilterRepository interface {
+	// Create returns a ConflictError on "name" when the owner already has a filter by that name
+	Create(ctx context.Context, filter *domain.SavedFilter) error
+	GetByID(ctx context.Context, id string) (*domain.SavedFilter, error)
+	// ListVisible returns ownerID's filters and everyone's shared ones, ordered by name
+	ListVisible(ctx context.Context, ownerID string) ([]domain.SavedFilter, error)
+	// Update replaces a filter's name, resource, params and sharing, returning ErrNotFound
+	// for a missing filter and a ConflictError on "name" like Create
+	Update(ctx context.Context, filter *domain.SavedFilter) error
 	Delete(ctx context.Context, id string) error
 }
+
+// Transactor runs a unit of work atomically. Repository calls made with the ctx passed
+// to fn join the transaction, which commits when fn returns nil and rolls back otherwise.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// LockEntity blocks until no other transaction holds the lock for (entityType, id), then
+	// holds it until the transaction in ctx ends. It must be called with a ctx passed to fn.
+	LockEntity(ctx context.Context, entityType, id string) error
+}
+
+// IdempotencyRepository stores the responses replayed for retried requests
+type IdempotencyRepository interface {
+	// Reserve claims record.Key for a new request, returning ErrConflict while an unexpired record holds it
+	Reserve(ctx context.Context, record *domain.IdempotencyRecord) error
+	GetByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error)
+	// Complete stores the response for a reserved key
+	Complete(ctx context.Context, key string, status int, contentType string, body []byte) error
+	// Delete releases a key so the request can be retried from scratch
+	Delete(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ShadowStats reports mirroring counters
+type ShadowStats struct {
+	Mirrored    int64 // Operations sent to the shadow target
+	Failures    int64 // Shadow operations that returned an error
+	Divergences int64 // Shadow reads whose result differed from the primary
+}
+
+// ShadowControl reports and adjusts the share of a repository's traffic mirrored to a shadow target
+type ShadowControl interface {
+	Percent() int
+	SetPercent(percent int)
+	Stats() ShadowStats
+}
@@ -0,0 +1,52 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPasswordMismatch is returned by PasswordHasher.Compare when the password doesn't match the hash
+var ErrPasswordMismatch = errors.New("password mismatch")
+
+// ErrNotHashed is returned by PasswordHasher.Compare when the stored value isn't a
+// hash it recognises, which is how passwords saved before hashing was introduced look
+var ErrNotHashed = errors.New("stored password is not hashed")
+
+// PasswordHasher turns plaintext passwords into hashes suitable for storage
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+	// NeedsRehash reports whether hash should be replaced, because it isn't a hash
+	// or was made with different settings than Hash now uses
+	NeedsRehash(hash string) bool
+}
+
+// ErrInvalidToken is returned by AccessTokens.Verify for malformed, forged or expired tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// AccessClaims are the facts an access token asserts about its bearer
+type AccessClaims struct {
+	UserID    string
+	Role      string
+	ExpiresAt time.Time
+}
+
+// AccessTokens issues and verifies the bearer tokens clients send in the Authorization header
+type AccessTokens interface {
+	Issue(userID, role string) (token string, expiresAt time.Time, err error)
+	Verify(token string) (AccessClaims, error)
+}
+
+type claimsKey struct{}
+
+// ContextWithClaims returns ctx carrying the claims of the authenticated caller
+func ContextWithClaims(ctx context.Context, claims AccessClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by ContextWithClaims, if any
+func ClaimsFromContext(ctx context.Context) (AccessClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(AccessClaims)
+	return claims, ok
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"io"
+)
+
+// FileStorage keeps binary objects such as uploaded images.
+// Put returns a location that Open and Delete accept; Open returns ErrNotFound for missing objects.
+type FileStorage interface {
+	Put(ctx context.Context, key string, r io.Reader) (location string, err error)
+	Open(ctx context.Context, location string) (io.ReadCloser, error)
+	Delete(ctx context.Context, location string) error
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Refresh errors
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// AuthConfig controls session token lifetimes
+type AuthConfig struct {
+	RefreshTTL time.Duration
+}
+
+// TokenPair is what a client receives on login and on every refresh
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// AuthService issues access tokens and rotates the refresh tokens that renew them
+type AuthService struct {
+	users   *UserService
+	refresh ports.RefreshTokenRepository
+	access  ports.AccessTokens
+	cfg     AuthConfig
+}
+
+func NewAuthService(users *UserService, refresh ports.RefreshTokenRepository, access ports.AccessTokens, cfg AuthConfig) *AuthService {
+	return &AuthService{users: users, refresh: refresh, access: access, cfg: cfg}
+}
+
+// Login authenticates a user and starts a new refresh token family
+func (s *AuthService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	user, err := s.users.Authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := newFamilyID()
+	if err != nil {
+		return nil, err
+	}
+	token, record, err := s.newRefreshToken(user.ID, family)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh.Create(ctx, record); err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return s.pair(user, token, record)
+}
+
+// Refresh exchanges a refresh token for a new access token and a rotated refresh token.
+// Presenting a token that was already rotated revokes its whole family, since
+// either the client or an attacker holds a stolen copy.
+func (s *AuthService) Refresh(ctx context.Context, token string) (*TokenPair, error) {
+	if token == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := hashToken(token)
+
+	current, err := s.refresh.GetByHash(ctx, hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrInvalidRefreshToken
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+	if current.Revoked {
+		return nil, s.revokeFamily(ctx, current)
+	}
+	if !time.Now().Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	// Reload the user so the new access token carries their current role
+	user, err := s.users.GetUser(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	next, record, err := s.newRefreshToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh.Rotate(ctx, hash, record); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			// Another request rotated this token first
+			return nil, s.revokeFamily(ctx, current)
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return s.pair(user, next, record)
+}
+
+func (s *AuthService) revokeFamily(ctx context.Context, token *domain.RefreshToken) error {
+	log.Printf("auth: refresh token reuse detected for user %s, revoking family %s", token.UserID, token.FamilyID)
+	if err := s.refresh.RevokeFamily(ctx, token.FamilyID); err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *AuthService) newRefreshToken(userID, family string) (string, *domain.RefreshToken, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &domain.RefreshToken{
+		TokenHash: hash,
+		UserID:    userID,
+		FamilyID:  family,
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+	}, nil
+}
+
+func (s *AuthService) pair(user *domain.User, refreshToken string, record *domain.RefreshToken) (*TokenPair, error) {
+	access, expiresAt, err := s.access.Issue(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
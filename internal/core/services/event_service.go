@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Analytics event limits
+const (
+	MaxEventsPerBatch     = 100
+	MaxEventProperties    = 20
+	MaxPropertyKeyLength  = 64
+	MaxPropertyValueBytes = 256
+)
+
+// allowedEvents is the whitelist of event names accepted from clients
+var allowedEvents = map[string]bool{
+	"page_view":    true,
+	"feature_used": true,
+}
+
+// EventConfig controls buffering and partition retention for analytics events
+type EventConfig struct {
+	BufferSize    int
+	FlushSize     int
+	FlushInterval time.Duration
+	Retention     time.Duration
+	PrecreateDays int
+}
+
+type EventService struct {
+	repo    ports.EventRepository
+	cfg     EventConfig
+	buffer  chan domain.AnalyticsEvent
+	dropped atomic.Int64
+}
+
+func NewEventService(repo ports.EventRepository, cfg EventConfig) *EventService {
+	return &EventService{
+		repo:   repo,
+		cfg:    cfg,
+		buffer: make(chan domain.AnalyticsEvent, cfg.BufferSize),
+	}
+}
+
+// Track validates a batch and queues it for asynchronous persistence.
+// Events that don't fit in the buffer are dropped and counted instead of blocking the caller.
+func (s *EventService) Track(events []domain.AnalyticsEvent) (int, error) {
+	if err := validateEvents(events); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	accepted := 0
+	for _, event := range events {
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = now
+		}
+		event.OccurredAt = event.OccurredAt.UTC()
+		select {
+		case s.buffer <- event:
+			accepted++
+		default:
+			s.dropped.Add(1)
+		}
+	}
+
+	return accepted, nil
+}
+
+// Dropped returns the number of events discarded because the buffer was full or a write failed
+func (s *EventService) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Buffered returns the number of events waiting to be written, and how many the buffer holds
+func (s *EventService) Buffered() (queued, capacity int) {
+	return len(s.buffer), cap(s.buffer)
+}
+
+// Run drains the buffer into the repository until ctx is cancelled, then flushes what is left
+func (s *EventService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.AnalyticsEvent, 0, s.cfg.FlushSize)
+	for {
+		select {
+		case event := <-s.buffer:
+			batch = append(batch, event)
+			if len(batch) >= s.cfg.FlushSize {
+				batch = s.flush(context.Background(), batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(context.Background(), batch)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-s.buffer:
+					batch = append(batch, event)
+				default:
+					s.flush(context.Background(), batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *EventService) flush(ctx context.Context, batch []domain.AnalyticsEvent) []domain.AnalyticsEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := s.repo.InsertBatch(ctx, batch); err != nil {
+		log.Printf("Failed to write %d analytics events: %v", len(batch), err)
+		s.dropped.Add(int64(len(batch)))
+	}
+	return batch[:0]
+}
+
+// MaintainPartitions creates upcoming daily partitions and drops expired ones, once
+// immediately and then on every interval until ctx is cancelled
+func (s *EventService) MaintainPartitions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.maintainPartitions(ctx); err != nil {
+			log.Printf("Analytics partition maintenance failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// maintainPartitions creates each upcoming day's partition and drops expired ones. A day that
+// can't be created, e.g. because an old partition overlaps it, doesn't stop the later days or
+// the retention sweep; every failure is returned together.
+func (s *EventService) maintainPartitions(ctx context.Context) error {
+	var errs []error
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := 0; i <= s.cfg.PrecreateDays; i++ {
+		day := today.AddDate(0, 0, i)
+		if err := s.repo.CreatePartition(ctx, day); err != nil {
+			errs = append(errs, fmt.Errorf("create partition for %s: %w", day.Format(time.DateOnly), err))
+		}
+	}
+
+	if s.cfg.Retention > 0 {
+		dropped, err := s.repo.DropPartitionsBefore(ctx, today.Add(-s.cfg.Retention))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("drop expired partitions: %w", err))
+		}
+		if dropped > 0 {
+			log.Printf("Dropped %d expired analytics partitions", dropped)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateEvents returns a ValidationError keyed by each offending field, e.g. "events[2].name"
+func validateEvents(events []domain.AnalyticsEvent) error {
+	if len(events) == 0 || len(events) > MaxEventsPerBatch {
+		return newValidationError(map[string]string{
+			"events": fmt.Sprintf("must contain between 1 and %d events", MaxEventsPerBatch),
+		})
+	}
+
+	fields := make(map[string]string)
+	for i, event := range events {
+		field := fmt.Sprintf("events[%d]", i)
+		if !allowedEvents[event.Name] {
+			fields[field+".name"] = fmt.Sprintf("unknown event name %q", event.Name)
+		}
+		if len(event.Properties) > MaxEventProperties {
+			fields[field+".properties"] = fmt.Sprintf("must have at most %d properties", MaxEventProperties)
+			continue
+		}
+		for key, value := range event.Properties {
+			property := field + ".properties." + key
+			if key == "" || len(key) > MaxPropertyKeyLength {
+				fields[field+".properties"] = fmt.Sprintf("property names must be 1 to %d bytes", MaxPropertyKeyLength)
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				if len(v) > MaxPropertyValueBytes {
+					fields[property] = fmt.Sprintf("must be at most %d bytes", MaxPropertyValueBytes)
+				}
+			case json.Number, float64, bool, nil:
+			default:
+				fields[property] = "must be a string, number or boolean"
+			}
+		}
+	}
+
+	return newValidationError(fields)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestTrackValidation(t *testing.T) {
+	pageView := domain.AnalyticsEvent{Name: "page_view"}
+
+	tests := []struct {
+		name   string
+		events []domain.AnalyticsEvent
+		fields []string // invalid fields reported by the ValidationError, nil when the batch is valid
+	}{
+		{name: "valid", events: []domain.AnalyticsEvent{pageView, {Name: "feature_used", Properties: map[string]interface{}{"plan": "pro", "seats": 3.0}}}},
+		{name: "empty batch", events: nil, fields: []string{"events"}},
+		{name: "oversized batch", events: slices.Repeat([]domain.AnalyticsEvent{pageView}, MaxEventsPerBatch+1), fields: []string{"events"}},
+		{name: "unknown names", events: []domain.AnalyticsEvent{pageView, {Name: "nope"}, {Name: ""}}, fields: []string{"events[1].name", "events[2].name"}},
+		{name: "long property", events: []domain.AnalyticsEvent{{Name: "page_view", Properties: map[string]interface{}{"path": strings.Repeat("a", MaxPropertyValueBytes+1)}}}, fields: []string{"events[0].properties.path"}},
+		{name: "nested property", events: []domain.AnalyticsEvent{{Name: "page_view", Properties: map[string]interface{}{"tags": []interface{}{"a"}}}}, fields: []string{"events[0].properties.tags"}},
+		{name: "empty property name", events: []domain.AnalyticsEvent{{Name: "page_view", Properties: map[string]interface{}{"": "x"}}}, fields: []string{"events[0].properties"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewEventService(testutil.NewEventRepository(), EventConfig{BufferSize: 2 * MaxEventsPerBatch})
+
+			accepted, err := svc.Track(tt.events)
+			if tt.fields == nil {
+				if err != nil || accepted != len(tt.events) {
+					t.Fatalf("Track() = %d, %v; want %d, nil", accepted, err, len(tt.events))
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("Track() error = %v, want a ValidationError", err)
+			}
+			if got := slices.Sorted(maps.Keys(verr.Fields)); !slices.Equal(got, tt.fields) {
+				t.Errorf("fields = %v, want %v", got, tt.fields)
+			}
+			if accepted != 0 {
+				t.Errorf("accepted = %d from an invalid batch", accepted)
+			}
+		})
+	}
+}
+
+func TestTrackDropsWhenBufferIsFull(t *testing.T) {
+	svc := NewEventService(testutil.NewEventRepository(), EventConfig{BufferSize: 2})
+
+	accepted, err := svc.Track(slices.Repeat([]domain.AnalyticsEvent{{Name: "page_view"}}, 5))
+	if err != nil || accepted != 2 {
+		t.Fatalf("Track() = %d, %v; want 2, nil", accepted, err)
+	}
+	if svc.Dropped() != 3 {
+		t.Errorf("Dropped() = %d, want 3", svc.Dropped())
+	}
+	if queued, capacity := svc.Buffered(); queued != 2 || capacity != 2 {
+		t.Errorf("Buffered() = %d, %d; want 2, 2", queued, capacity)
+	}
+}
+
+func TestTrackStoresUTC(t *testing.T) {
+	repo := testutil.NewEventRepository()
+	svc := NewEventService(repo, EventConfig{BufferSize: 10, FlushSize: 10, FlushInterval: time.Hour})
+
+	// 23:30 in New York is already the next day in UTC, which is the partition the row must land in
+	occurred := time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	if _, err := svc.Track([]domain.AnalyticsEvent{{Name: "page_view", OccurredAt: occurred}, {Name: "page_view"}}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx) // flushes the buffer and returns
+
+	events := repo.Events()
+	if len(events) != 2 {
+		t.Fatalf("stored %d events, want 2", len(events))
+	}
+	for _, event := range events {
+		if event.OccurredAt.Location() != time.UTC {
+			t.Errorf("OccurredAt = %v, want UTC", event.OccurredAt)
+		}
+	}
+	if !events[0].OccurredAt.Equal(occurred) || events[0].OccurredAt.Day() != 2 {
+		t.Errorf("OccurredAt = %v, want %v on the 2nd", events[0].OccurredAt, occurred.UTC())
+	}
+}
+
+func TestFlushFailureCountsDropped(t *testing.T) {
+	repo := testutil.NewEventRepository()
+	repo.Err = errors.New("database is down")
+	svc := NewEventService(repo, EventConfig{BufferSize: 10, FlushSize: 10, FlushInterval: time.Hour})
+
+	if _, err := svc.Track(slices.Repeat([]domain.AnalyticsEvent{{Name: "page_view"}}, 3)); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx)
+
+	if svc.Dropped() != 3 {
+		t.Errorf("Dropped() = %d, want 3", svc.Dropped())
+	}
+}
+
+func TestMaintainPartitionsUsesUTCDays(t *testing.T) {
+	repo := testutil.NewEventRepository()
+	svc := NewEventService(repo, EventConfig{PrecreateDays: 2, Retention: 7 * 24 * time.Hour})
+
+	if err := svc.maintainPartitions(context.Background()); err != nil {
+		t.Fatalf("maintainPartitions() error = %v", err)
+	}
+
+	days := repo.Partitions()
+	if len(days) != 3 {
+		t.Fatalf("created %d partitions, want 3", len(days))
+	}
+	today := time.Now().UTC()
+	for i, day := range days {
+		want := time.Date(today.Year(), today.Month(), today.Day()+i, 0, 0, 0, 0, time.UTC)
+		if !day.Equal(want) || day.Location() != time.UTC {
+			t.Errorf("partition %d = %v, want %v", i, day, want)
+		}
+	}
+}
+
+// overlappingEventRepository refuses to create the partition for one day, as Postgres
+// does when a partition with older bounds overlaps it
+type overlappingEventRepository struct {
+	*testutil.EventRepository
+	day time.Time
+}
+
+func (r overlappingEventRepository) CreatePartition(ctx context.Context, day time.Time) error {
+	if day.Equal(r.day) {
+		return errors.New(`partition "analytics_events_x" would overlap partition "analytics_events_y"`)
+	}
+	return r.EventRepository.CreatePartition(ctx, day)
+}
+
+func TestMaintainPartitionsContinuesPastAFailedDay(t *testing.T) {
+	repo := testutil.NewEventRepository()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	svc := NewEventService(overlappingEventRepository{repo, today}, EventConfig{PrecreateDays: 2})
+
+	err := svc.maintainPartitions(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "would overlap") {
+		t.Fatalf("maintainPartitions() error = %v, want the overlap reported", err)
+	}
+	if days := repo.Partitions(); len(days) != 2 || !days[0].Equal(today.AddDate(0, 0, 1)) {
+		t.Errorf("created partitions for %v, want the two days after today", days)
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+var (
+	ErrSavedFilterNotFound = errors.New("saved filter not found")
+	ErrSavedFilterNotOwned = errors.New("saved filter belongs to another admin")
+	ErrDuplicateFilterName = errors.New("a saved filter with this name already exists")
+)
+
+// MaxFilterNameLength bounds a saved filter's name, in characters
+const MaxFilterNameLength = 100
+
+// StaleFilterError reports a saved filter that the listing it targets no longer accepts,
+// such as one holding a parameter that has since been removed
+type StaleFilterError struct {
+	Fields map[string]string
+}
+
+func (e *StaleFilterError) Error() string {
+	return "saved filter no longer matches the listing's parameters"
+}
+
+// paramCheck returns why value is unacceptable, or "" when it is fine
+type paramCheck func(value string) string
+
+// savedFilterParams are the query parameters a saved filter may hold, by resource. They
+// are checked when a filter is saved and again when it is used, so a filter saved before
+// a parameter was removed or narrowed fails instead of being silently misapplied.
+var savedFilterParams = map[string]map[string]paramCheck{
+	domain.SavedFilterUsers: {
+		"sort":           oneOf(ports.UserSortFields...),
+		"order":          oneOf("asc", "desc"),
+		"created_after":  timestamp,
+		"created_before": timestamp,
+		"email":          minLength(MinEmailPrefixLength),
+		"limit":          positiveInt,
+	},
+}
+
+func oneOf(values ...string) paramCheck {
+	return func(value string) string {
+		if !slices.Contains(values, value) {
+			return "must be one of " + strings.Join(values, ", ")
+		}
+		return ""
+	}
+}
+
+func timestamp(value string) string {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "must be an RFC 3339 timestamp"
+	}
+	return ""
+}
+
+func minLength(n int) paramCheck {
+	return func(value string) string {
+		if len(value) < n {
+			return "must be at least " + strconv.Itoa(n) + " characters"
+		}
+		return ""
+	}
+}
+
+func positiveInt(value string) string {
+	if n, err := strconv.Atoi(value); err != nil || n < 1 {
+		return "must be a positive integer"
+	}
+	return ""
+}
+
+// checkFilterParams adds a "params.<name>" entry to fields for every parameter of params
+// the resource doesn't accept
+func checkFilterParams(fields map[string]string, resource string, params map[string]string) {
+	accepted := savedFilterParams[resource]
+	for name, value := range params {
+		check, ok := accepted[name]
+		if !ok {
+			fields["params."+name] = "is not a filter of " + resource
+			continue
+		}
+		if msg := check(value); msg != "" {
+			fields["params."+name] = msg
+		}
+	}
+}
+
+type SavedFilterService struct {
+	repo ports.SavedFilterRepository
+	ids  ports.IDGenerator
+}
+
+func NewSavedFilterService(repo ports.SavedFilterRepository, ids ports.IDGenerator) *SavedFilterService {
+	return &SavedFilterService{repo: repo, ids: ids}
+}
+
+// CreateFilter validates and stores a new filter owned by filter.OwnerID
+func (s *SavedFilterService) CreateFilter(ctx context.Context, filter *domain.SavedFilter) error {
+	if filter.OwnerID == "" {
+		return ErrInvalidInput
+	}
+	if err := validateSavedFilter(filter); err != nil {
+		return err
+	}
+
+	filter.ID = s.ids.NewID()
+	if err := s.repo.Create(ctx, filter); err != nil {
+		return savedFilterError(err)
+	}
+	return nil
+}
+
+// GetFilter returns a filter requesterID owns or that is shared. Other admins' private
+// filters are reported as missing, so their existence isn't revealed.
+func (s *SavedFilterService) GetFilter(ctx context.Context, requesterID, id string) (*domain.SavedFilter, error) {
+	if requesterID == "" || id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	filter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, savedFilterError(err)
+	}
+	if filter.OwnerID != requesterID && !filter.Shared {
+		return nil, ErrSavedFilterNotFound
+	}
+	return filter, nil
+}
+
+// ListFilters returns requesterID's filters and every shared one, by name
+func (s *SavedFilterService) ListFilters(ctx context.Context, requesterID string) ([]domain.SavedFilter, error) {
+	if requesterID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	filters, err := s.repo.ListVisible(ctx, requesterID)
+	if err != nil {
+		return nil, savedFilterError(err)
+	}
+	return filters, nil
+}
+
+// UpdateFilter replaces the name, resource, params and sharing of a filter requesterID owns.
+// A shared filter owned by someone else returns ErrSavedFilterNotOwned.
+func (s *SavedFilterService) UpdateFilter(ctx context.Context, requesterID string, filter *domain.SavedFilter) error {
+	existing, err := s.GetFilter(ctx, requesterID, filter.ID)
+	if err != nil {
+		return err
+	}
+	if existing.OwnerID != requesterID {
+		return ErrSavedFilterNotOwned
+	}
+	if err := validateSavedFilter(filter); err != nil {
+		return err
+	}
+
+	filter.OwnerID = existing.OwnerID
+	filter.CreatedAt = existing.CreatedAt
+	if err := s.repo.Update(ctx, filter); err != nil {
+		return savedFilterError(err)
+	}
+	return nil
+}
+
+// DeleteFilter removes a filter requesterID owns, with the same checks as UpdateFilter
+func (s *SavedFilterService) DeleteFilter(ctx context.Context, requesterID, id string) error {
+	existing, err := s.GetFilter(ctx, requesterID, id)
+	if err != nil {
+		return err
+	}
+	if existing.OwnerID != requesterID {
+		return ErrSavedFilterNotOwned
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return savedFilterError(err)
+	}
+	return nil
+}
+
+// ResolveFilter returns the params of a filter requesterID may use, checked against what
+// the resource's listing accepts today. A filter for another resource, or holding
+// parameters the listing no longer accepts, returns a *StaleFilterError.
+func (s *SavedFilterService) ResolveFilter(ctx context.Context, requesterID, id, resource string) (map[string]string, error) {
+	filter, err := s.GetFilter(ctx, requesterID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	if filter.Resource != resource {
+		fields["resource"] = "filter is for " + filter.Resource + ", not " + resource
+	} else {
+		checkFilterParams(fields, resource, filter.Params)
+	}
+	if len(fields) > 0 {
+		return nil, &StaleFilterError{Fields: fields}
+	}
+	return filter.Params, nil
+}
+
+// validateSavedFilter trims the name of a filter about to be written, then checks the filter
+func validateSavedFilter(filter *domain.SavedFilter) error {
+	filter.Name = strings.TrimSpace(filter.Name)
+	fields := make(map[string]string)
+	switch {
+	case filter.Name == "":
+		fields["name"] = "is required"
+	case utf8.RuneCountInString(filter.Name) > MaxFilterNameLength:
+		fields["name"] = "must be at most " + strconv.Itoa(MaxFilterNameLength) + " characters"
+	}
+	if _, ok := savedFilterParams[filter.Resource]; !ok {
+		fields["resource"] = "must be one of " + strings.Join(sortedKeys(savedFilterParams), ", ")
+	} else {
+		checkFilterParams(fields, filter.Resource, filter.Params)
+	}
+	if filter.Params == nil {
+		filter.Params = map[string]string{}
+	}
+	return newValidationError(fields)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func savedFilterError(err error) error {
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		return ErrSavedFilterNotFound
+	case errors.Is(err, ports.ErrConflict):
+		return ErrDuplicateFilterName
+	case errors.Is(err, ports.ErrUnavailable):
+		return ErrUnavailable
+	}
+	return err
+}
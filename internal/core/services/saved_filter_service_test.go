@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestCreateFilterValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter domain.SavedFilter
+		fields []string
+	}{
+		{name: "valid", filter: domain.SavedFilter{Name: " Newest ", Resource: domain.SavedFilterUsers, Params: map[string]string{"sort": "created_at", "order": "desc", "limit": "50"}}},
+		{name: "no params", filter: domain.SavedFilter{Name: "All", Resource: domain.SavedFilterUsers}},
+		{name: "blank name", filter: domain.SavedFilter{Name: "  ", Resource: domain.SavedFilterUsers}, fields: []string{"name"}},
+		{name: "long name", filter: domain.SavedFilter{Name: strings.Repeat("n", MaxFilterNameLength+1), Resource: domain.SavedFilterUsers}, fields: []string{"name"}},
+		{name: "unknown resource", filter: domain.SavedFilter{Name: "Orders", Resource: "orders"}, fields: []string{"resource"}},
+		{name: "unknown param", filter: domain.SavedFilter{Name: "Admins", Resource: domain.SavedFilterUsers, Params: map[string]string{"role": "admin"}}, fields: []string{"params.role"}},
+		{name: "paging is not saved", filter: domain.SavedFilter{Name: "Page 2", Resource: domain.SavedFilterUsers, Params: map[string]string{"offset": "50"}}, fields: []string{"params.offset"}},
+		{
+			name:   "bad values",
+			filter: domain.SavedFilter{Name: "Bad", Resource: domain.SavedFilterUsers, Params: map[string]string{"sort": "password", "created_after": "yesterday", "email": "a", "limit": "0"}},
+			fields: []string{"params.sort", "params.created_after", "params.email", "params.limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewSavedFilterService(testutil.NewSavedFilterRepository(), &testutil.IDGenerator{})
+			filter := tt.filter
+			filter.OwnerID = "admin"
+			err := svc.CreateFilter(context.Background(), &filter)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("CreateFilter() error = %v", err)
+				}
+				if filter.ID == "" || filter.Name != strings.TrimSpace(tt.filter.Name) || filter.Params == nil {
+					t.Errorf("created %+v, want an ID, a trimmed name and params", filter)
+				}
+				return
+			}
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("CreateFilter() error = %v, want a ValidationError", err)
+			}
+			for _, field := range tt.fields {
+				if validation.Fields[field] == "" {
+					t.Errorf("fields = %v, want %s reported", validation.Fields, field)
+				}
+			}
+			if len(validation.Fields) != len(tt.fields) {
+				t.Errorf("fields = %v, want only %v", validation.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestResolveFilter(t *testing.T) {
+	repo := testutil.NewSavedFilterRepository(
+		factory.SavedFilter("admin").WithID("mine").WithParam("sort", "email").Build(),
+		factory.SavedFilter("admin").WithID("orders").WithResource("orders").WithParam("status", "paid").Build(),
+		factory.SavedFilter("admin").WithID("removed").WithParam("verified", "true").Build(),
+		factory.SavedFilter("other").WithID("private").Build(),
+		factory.SavedFilter("other").WithID("shared").Shared().Build(),
+	)
+	svc := NewSavedFilterService(repo, &testutil.IDGenerator{})
+
+	tests := []struct {
+		id         string
+		wantErr    error
+		wantFields []string
+	}{
+		{id: "mine"},
+		{id: "shared"},
+		{id: "orders", wantFields: []string{"resource"}},
+		{id: "removed", wantFields: []string{"params.verified"}},
+		{id: "private", wantErr: ErrSavedFilterNotFound},
+		{id: "ghost", wantErr: ErrSavedFilterNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			_, err := svc.ResolveFilter(context.Background(), "admin", tt.id, domain.SavedFilterUsers)
+			var stale *StaleFilterError
+			switch {
+			case tt.wantFields != nil:
+				if !errors.As(err, &stale) {
+					t.Fatalf("ResolveFilter() error = %v, want a StaleFilterError", err)
+				}
+				for _, field := range tt.wantFields {
+					if stale.Fields[field] == "" {
+						t.Errorf("fields = %v, want %s reported", stale.Fields, field)
+					}
+				}
+			case err != tt.wantErr:
+				t.Errorf("ResolveFilter() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
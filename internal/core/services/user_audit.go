@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"reflect"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// redacted replaces secret values in audit diffs, so a diff shows they changed but not to what
+const redacted = "[REDACTED]"
+
+// auditedUserFields are the user fields an audit diff compares, keyed as in auditFields
+var auditedUserFields = []string{"email", "password", "role", "email_verified_at", "avatar_url", "deleted_at"}
+
+// secretUserFields are the audited fields whose values are redacted
+var secretUserFields = map[string]bool{"password": true}
+
+// recordUserAudit writes an audit event for a mutation of a user from before to after,
+// either of which is nil when the user didn't exist on that side. The actor is the
+// authenticated caller in ctx. Call it inside the mutation's WithinTx so both commit together.
+func (s *UserService) recordUserAudit(ctx context.Context, action string, before, after *domain.User) error {
+	entity := after
+	if entity == nil {
+		entity = before
+	}
+
+	event := &domain.AuditEvent{
+		ID:         s.ids.NewID(),
+		Action:     action,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   entity.ID,
+		Diff:       userAuditDiff(before, after),
+	}
+	if claims, ok := ports.ClaimsFromContext(ctx); ok {
+		event.ActorID = &claims.UserID
+	}
+	return s.audits.Create(ctx, event)
+}
+
+// userAuditDiff lists the audited fields that differ between before and after
+func userAuditDiff(before, after *domain.User) map[string]domain.AuditChange {
+	old, cur := auditFields(before), auditFields(after)
+	diff := make(map[string]domain.AuditChange)
+	for _, field := range auditedUserFields {
+		change := domain.AuditChange{Before: old[field], After: cur[field]}
+		if reflect.DeepEqual(change.Before, change.After) {
+			continue
+		}
+		if secretUserFields[field] {
+			change.Before, change.After = redact(change.Before), redact(change.After)
+		}
+		diff[field] = change
+	}
+	return diff
+}
+
+// auditFields returns the audited fields of user that are set, or nil for no user
+func auditFields(user *domain.User) map[string]interface{} {
+	if user == nil {
+		return nil
+	}
+	fields := map[string]interface{}{
+		"email":    user.Email,
+		"password": user.Password,
+		"role":     user.Role,
+	}
+	if user.EmailVerifiedAt != nil {
+		fields["email_verified_at"] = *user.EmailVerifiedAt
+	}
+	if user.AvatarURL != nil {
+		fields["avatar_url"] = *user.AvatarURL
+	}
+	if user.DeletedAt != nil {
+		fields["deleted_at"] = *user.DeletedAt
+	}
+	return fields
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redacted
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/factory"
+)
+
+func TestUserAuditDiff(t *testing.T) {
+	verified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := *factory.User().WithID("u1").WithEmail("user@example.com").WithPasswordHash("hash-1").Build()
+	with := func(change func(u *domain.User)) *domain.User {
+		u := base
+		change(&u)
+		return &u
+	}
+
+	tests := []struct {
+		name   string
+		before *domain.User
+		after  *domain.User
+		want   map[string]domain.AuditChange
+	}{
+		{
+			name:  "created",
+			after: &base,
+			want: map[string]domain.AuditChange{
+				"email":    {After: "user@example.com"},
+				"password": {After: redacted},
+				"role":     {After: domain.RoleUser},
+			},
+		},
+		{
+			name:   "email changed and unverified",
+			before: with(func(u *domain.User) { u.EmailVerifiedAt = &verified }),
+			after:  with(func(u *domain.User) { u.Email = "new@example.com" }),
+			want: map[string]domain.AuditChange{
+				"email":             {Before: "user@example.com", After: "new@example.com"},
+				"email_verified_at": {Before: verified},
+			},
+		},
+		{
+			name:   "password changed",
+			before: &base,
+			after:  with(func(u *domain.User) { u.Password = "hash-2" }),
+			want:   map[string]domain.AuditChange{"password": {Before: redacted, After: redacted}},
+		},
+		{
+			name:   "nothing changed",
+			before: &base,
+			after:  with(func(u *domain.User) { u.Version = 9; u.UpdatedAt = verified }),
+			want:   map[string]domain.AuditChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := userAuditDiff(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userAuditDiff() = %#v, want %#v", got, tt.want)
+			}
+			for _, change := range got {
+				for _, value := range []interface{}{change.Before, change.After} {
+					if s, ok := value.(string); ok && strings.HasPrefix(s, "hash-") {
+						t.Errorf("diff leaks password hash %q", s)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRecordUserAuditActor(t *testing.T) {
+	f := newUserFixture(t, UserConfig{}, factory.User().WithID("u1").WithEmail("user@example.com").Build())
+	ctx := ports.ContextWithClaims(context.Background(), ports.AccessClaims{UserID: "admin", Role: domain.RoleAdmin})
+
+	if err := f.svc.UpdateUser(ctx, &domain.User{ID: "u1", Email: "changed@example.com"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if err := f.svc.CreateUser(context.Background(), &domain.User{Email: "new@example.com", Password: testPassword}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	events := f.audits.Events()
+	if len(events) != 2 {
+		t.Fatalf("audit events = %+v, want 2", events)
+	}
+	if events[0].ActorID == nil || *events[0].ActorID != "admin" {
+		t.Errorf("update actor = %v, want admin", events[0].ActorID)
+	}
+	if events[1].ActorID != nil {
+		t.Errorf("signup actor = %q, want none", *events[1].ActorID)
+	}
+}
+
+// A failed audit write rolls the mutation back with it
+func TestAuditFailureRollsBackMutation(t *testing.T) {
+	errAudit := errors.New("audit store down")
+
+	tests := []struct {
+		name   string
+		mutate func(*UserService) error
+		check  func(t *testing.T, f *userFixture)
+	}{
+		{
+			name: "create",
+			mutate: func(s *UserService) error {
+				return s.CreateUser(context.Background(), &domain.User{Email: "new@example.com", Password: testPassword})
+			},
+			check: func(t *testing.T, f *userFixture) {
+				if exists, _ := f.users.ExistsByEmail(context.Background(), "new@example.com"); exists {
+					t.Error("user created without its audit event")
+				}
+			},
+		},
+		{
+			name: "update",
+			mutate: func(s *UserService) error {
+				return s.UpdateUser(context.Background(), &domain.User{ID: "u1", Email: "changed@example.com"})
+			},
+			check: func(t *testing.T, f *userFixture) {
+				if u, _ := f.users.Stored("u1"); u.Email != "user@example.com" || u.Version != 1 {
+					t.Errorf("user updated without its audit event: %+v", u)
+				}
+			},
+		},
+		{
+			name: "change password",
+			mutate: func(s *UserService) error {
+				return s.ChangePassword(context.Background(), "u1", testPassword, "battery staple 2")
+			},
+			check: func(t *testing.T, f *userFixture) {
+				if u, _ := f.users.Stored("u1"); u.Password != "hashed:"+testPassword {
+					t.Errorf("password changed without its audit event")
+				}
+			},
+		},
+		{
+			name: "delete",
+			mutate: func(s *UserService) error {
+				return s.DeleteUser(context.Background(), "admin", "u1")
+			},
+			check: func(t *testing.T, f *userFixture) {
+				if u, _ := f.users.Stored("u1"); u.DeletedAt != nil {
+					t.Error("user deleted without its audit event")
+				}
+			},
+		},
+		{
+			name: "role change",
+			mutate: func(s *UserService) error {
+				_, err := s.SetUserRole(context.Background(), "u1", domain.RoleAdmin)
+				return err
+			},
+			check: func(t *testing.T, f *userFixture) {
+				if u, _ := f.users.Stored("u1"); u.Role != domain.RoleUser {
+					t.Error("role changed without its audit event")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, UserConfig{}, factory.User().WithID("u1").WithEmail("user@example.com").Build())
+			f.audits.Err = errAudit
+
+			if err := tt.mutate(f.svc); !errors.Is(err, errAudit) {
+				t.Fatalf("error = %v, want %v", err, errAudit)
+			}
+			tt.check(t, f)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/validation"
+)
+
+// Authentication errors
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrAccountDeleted     = errors.New("account has been deleted")
+)
+
+// Authenticate checks an email and password pair and returns the matching user.
+// Unknown emails and wrong passwords both yield ErrInvalidCredentials and take
+// about as long, so callers can't use login to discover which accounts exist.
+// ErrAccountDeleted and ErrEmailNotVerified are only returned once the password
+// has been checked, so they reveal nothing to someone who doesn't know it.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (*domain.User, error) {
+	email = validation.NormalizeEmail(email)
+	if email == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, s.authenticateDeleted(ctx, email, password)
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	if err := s.checkPassword(user.Password, password); err != nil {
+		if errors.Is(err, ports.ErrPasswordMismatch) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehash(ctx, user, password)
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	return user, nil
+}
+
+// authenticateDeleted tells a soft-deleted account with the right password apart
+// from an unknown email. Either way exactly one password comparison is made.
+func (s *UserService) authenticateDeleted(ctx context.Context, email, password string) error {
+	deleted, err := s.repo.GetDeletedByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			s.compareDummy(password)
+			return ErrInvalidCredentials
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+	if err := s.checkPassword(deleted.Password, password); err != nil {
+		if errors.Is(err, ports.ErrPasswordMismatch) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+	return ErrAccountDeleted
+}
+
+// checkPassword compares password with the stored value. Accounts created before
+// passwords were hashed still hold the plaintext; those are compared directly and
+// upgraded by rehash on the next successful login, so no bulk migration is needed.
+func (s *UserService) checkPassword(stored, password string) error {
+	err := s.hasher.Compare(stored, password)
+	if !errors.Is(err, ports.ErrNotHashed) {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+		return ports.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// rehash replaces a legacy or outdated hash after password has been checked. Like every
+// password write it happens under the user's lock and is audited in the same transaction.
+// A failure only means the upgrade is retried next login, so it doesn't fail the login.
+func (s *UserService) rehash(ctx context.Context, user *domain.User, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("auth: rehash password for user %s: %v", user.ID, err)
+		return
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockUser(ctx, user.ID); err != nil {
+			return err
+		}
+		stored, err := s.repo.GetByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if stored.Password != user.Password {
+			return nil // changed since it was checked; leave the newer password alone
+		}
+		before := *stored
+		stored.Password = hash
+		if err := s.repo.Update(ctx, stored); err != nil {
+			return err
+		}
+		*user = *stored
+		return s.recordUserAudit(ctx, domain.AuditPasswordRehashed, &before, stored)
+	})
+	if err != nil {
+		log.Printf("auth: store rehashed password for user %s: %v", user.ID, err)
+	}
+}
+
+// compareDummy spends the same work as a real password check
+func (s *UserService) compareDummy(password string) {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash("not-a-real-password")
+	})
+	if s.dummyHash != "" {
+		s.hasher.Compare(s.dummyHash, password)
+	}
+}
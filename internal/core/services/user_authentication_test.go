@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+// recordingHasher is the fake hasher, remembering every hash it was asked to compare against
+type recordingHasher struct {
+	testutil.PasswordHasher
+
+	mu       sync.Mutex
+	compared []string
+}
+
+func (h *recordingHasher) Compare(hash, password string) error {
+	h.mu.Lock()
+	h.compared = append(h.compared, hash)
+	h.mu.Unlock()
+	return h.PasswordHasher.Compare(hash, password)
+}
+
+func (h *recordingHasher) Compared() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.compared)
+}
+
+// newAuthFixture is newUserFixture with a hasher that records comparisons
+func newAuthFixture(t *testing.T, users ...*domain.User) (*userFixture, *recordingHasher) {
+	t.Helper()
+	f := newUserFixture(t, UserConfig{}, users...)
+	hasher := &recordingHasher{}
+	f.svc = NewUserService(f.users, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		f.audits, f.tx, hasher, &testutil.IDGenerator{}, f.mailer, f.files, UserConfig{})
+	return f, hasher
+}
+
+func TestAuthenticate(t *testing.T) {
+	active := factory.User().WithID("active").WithEmail("active@example.com").Verified().Build()
+	unverified := factory.User().WithID("unverified").WithEmail("unverified@example.com").Build()
+	deleted := factory.User().WithID("deleted").WithEmail("deleted@example.com").Verified().Deleted().Build()
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+		wantID   string
+		compared []string // hashes compared against, in order
+	}{
+		{name: "valid", email: "Active@Example.com ", password: testPassword, wantID: "active", compared: []string{active.Password}},
+		{name: "wrong password", email: "active@example.com", password: "guess", wantErr: ErrInvalidCredentials, compared: []string{active.Password}},
+		{name: "unknown email runs a dummy compare", email: "nobody@example.com", password: testPassword, wantErr: ErrInvalidCredentials, compared: []string{"hashed:not-a-real-password"}},
+		{name: "unverified", email: "unverified@example.com", password: testPassword, wantErr: ErrEmailNotVerified, compared: []string{unverified.Password}},
+		{name: "unverified with wrong password", email: "unverified@example.com", password: "guess", wantErr: ErrInvalidCredentials, compared: []string{unverified.Password}},
+		{name: "soft-deleted", email: "deleted@example.com", password: testPassword, wantErr: ErrAccountDeleted, compared: []string{deleted.Password}},
+		{name: "soft-deleted with wrong password", email: "deleted@example.com", password: "guess", wantErr: ErrInvalidCredentials, compared: []string{deleted.Password}},
+		{name: "empty password", email: "active@example.com", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, hasher := newAuthFixture(t, active, unverified, deleted)
+
+			user, err := f.svc.Authenticate(context.Background(), tt.email, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.ID != tt.wantID {
+				t.Errorf("Authenticate() = %s, want %s", user.ID, tt.wantID)
+			}
+			// One comparison whatever the outcome keeps the timing of every path alike
+			if got := hasher.Compared(); !slices.Equal(got, tt.compared) {
+				t.Errorf("compared against %v, want %v", got, tt.compared)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRehashesLegacyPassword(t *testing.T) {
+	legacy := factory.User().WithID("legacy").WithEmail("legacy@example.com").WithPasswordHash(testPassword).Verified().Build()
+	f, _ := newAuthFixture(t, legacy)
+
+	if _, err := f.svc.Authenticate(context.Background(), "legacy@example.com", testPassword); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	stored, _ := f.users.Stored("legacy")
+	if stored.Password != "hashed:"+testPassword {
+		t.Errorf("stored password = %q, want it rehashed", stored.Password)
+	}
+	if locks := f.tx.Locks(); len(locks) != 1 || locks[0] != "user:legacy" {
+		t.Errorf("locks = %v, want [user:legacy]", locks)
+	}
+	events := f.audits.Events()
+	if len(events) != 1 || events[0].Action != domain.AuditPasswordRehashed || events[0].ActorID != nil {
+		t.Fatalf("audit events = %+v, want one %s without an actor", events, domain.AuditPasswordRehashed)
+	}
+	if change := events[0].Diff["password"]; change.Before != redacted || change.After != redacted {
+		t.Errorf("password diff = %+v, want it redacted", change)
+	}
+
+	// The rehashed password still authenticates, without another upgrade
+	if _, err := f.svc.Authenticate(context.Background(), "legacy@example.com", testPassword); err != nil {
+		t.Fatalf("Authenticate() after rehash error = %v", err)
+	}
+	if n := len(f.audits.Events()); n != 1 {
+		t.Errorf("audit events after a second login = %d, want 1", n)
+	}
+}
+
+func TestAuthenticateKeepsLoginWhenRehashFails(t *testing.T) {
+	legacy := factory.User().WithID("legacy").WithEmail("legacy@example.com").WithPasswordHash(testPassword).Verified().Build()
+	f, _ := newAuthFixture(t, legacy)
+	f.audits.Err = errors.New("audit store is down")
+
+	if _, err := f.svc.Authenticate(context.Background(), "legacy@example.com", testPassword); err != nil {
+		t.Fatalf("Authenticate() error = %v, want the login to succeed", err)
+	}
+	// The audit failure rolled the upgrade back with it
+	if stored, _ := f.users.Stored("legacy"); stored.Password != testPassword {
+		t.Errorf("stored password = %q, want the unaudited rehash rolled back", stored.Password)
+	}
+}
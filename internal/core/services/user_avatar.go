@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"path"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Avatar errors
+var (
+	ErrUnsupportedImage = errors.New("avatar must be a PNG or JPEG image")
+	ErrAvatarNotFound   = errors.New("avatar not found")
+)
+
+// avatarExtensions maps the accepted avatar content types to the extension they are stored under
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// SetAvatar stores image as the user's avatar, replacing any previous one.
+// contentType must be the sniffed type of image, not one supplied by the client.
+func (s *UserService) SetAvatar(ctx context.Context, userID, contentType string, image io.Reader) (*domain.User, error) {
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs are client-chosen, so escape them to keep each avatar a single object
+	location, err := s.files.Put(ctx, "avatars/"+url.PathEscape(user.ID)+ext, image)
+	if err != nil {
+		return nil, err
+	}
+	previous := user.AvatarURL
+	user.AvatarURL = &location
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return nil, ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	// A new extension means a new object; the old one is no longer referenced
+	if previous != nil && *previous != location {
+		if err := s.files.Delete(ctx, *previous); err != nil {
+			log.Printf("avatar: failed to delete replaced object %s: %v", *previous, err)
+		}
+	}
+
+	return user, nil
+}
+
+// OpenAvatar returns the user's avatar image and its content type; the caller must close it
+func (s *UserService) OpenAvatar(ctx context.Context, userID string) (io.ReadCloser, string, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.AvatarURL == nil {
+		return nil, "", ErrAvatarNotFound
+	}
+
+	image, err := s.files.Open(ctx, *user.AvatarURL)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil, "", ErrAvatarNotFound
+		}
+		return nil, "", err
+	}
+
+	contentType := "application/octet-stream"
+	for candidate, ext := range avatarExtensions {
+		if path.Ext(*user.AvatarURL) == ext {
+			contentType = candidate
+		}
+	}
+	return image, contentType, nil
+}
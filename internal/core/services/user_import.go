@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Import limits
+const (
+	// MaxImportRows caps one import; every row costs a bcrypt hash
+	MaxImportRows = 1000
+	// ImportBatchSize is how many rows are written per transaction
+	ImportBatchSize = 100
+)
+
+// Import policies for rows whose email belongs to a deleted account
+const (
+	// ImportDeletedSkip leaves the deleted account alone and skips the row
+	ImportDeletedSkip = "skip"
+	// ImportDeletedRestore restores the deleted account with the row's password
+	ImportDeletedRestore = "restore"
+	// ImportDeletedError reports the row as an error
+	ImportDeletedError = "error"
+)
+
+// Import rules, one of which is reported for every row
+const (
+	ImportRuleCreated         = "created"
+	ImportRuleInvalid         = "invalid"
+	ImportRuleDuplicateInFile = "duplicate_in_file"
+	ImportRuleActiveAccount   = "active_account"
+	ImportRuleDeletedSkipped  = "deleted_account_skipped"
+	ImportRuleDeletedRestored = "deleted_account_restored"
+	ImportRuleDeletedRejected = "deleted_account_rejected"
+)
+
+// ImportRow is one data line of an import file
+type ImportRow struct {
+	Line     int
+	Email    string
+	Password string
+}
+
+// ImportRowError explains why a line was not imported
+type ImportRowError struct {
+	Line   int               `json:"line"`
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// ImportRowResult is the rule that decided what happened to a line
+type ImportRowResult struct {
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// ImportSummary reports the outcome of an import. Errors and Rows are in line order;
+// Rows covers every line processed before the import finished or stopped.
+type ImportSummary struct {
+	Created           int               `json:"created"`
+	Restored          int               `json:"restored"`
+	SkippedDuplicates int               `json:"skipped_duplicates"`
+	Errors            []ImportRowError  `json:"errors"`
+	Rows              []ImportRowResult `json:"rows"`
+}
+
+func (s *ImportSummary) record(line int, rule string) {
+	s.Rows = append(s.Rows, ImportRowResult{Line: line, Rule: rule})
+}
+
+func (s *ImportSummary) sortByLine() {
+	sort.SliceStable(s.Errors, func(i, j int) bool { return s.Errors[i].Line < s.Errors[j].Line })
+	sort.SliceStable(s.Rows, func(i, j int) bool { return s.Rows[i].Line < s.Rows[j].Line })
+}
+
+// importItem is a valid, hashed row waiting for its batch
+type importItem struct {
+	line int
+	user *domain.User
+}
+
+// ImportUsers creates a user for every valid row, writing in transactional batches.
+// Emails are normalized before comparing, so rows differing only in case or whitespace
+// collide. Invalid rows are reported and skipped; rows whose email belongs to an active
+// account or appears earlier in the file are counted as duplicates, and rows whose email
+// belongs to a deleted account follow cfg.ImportDeletedPolicy. Batches committed before
+// ctx is cancelled or a write fails stay committed, and the summary says so.
+func (s *UserService) ImportUsers(ctx context.Context, rows []ImportRow) (*ImportSummary, error) {
+	if len(rows) == 0 {
+		return nil, ErrInvalidInput
+	}
+	if len(rows) > MaxImportRows {
+		return nil, ErrBatchTooLarge
+	}
+
+	summary := &ImportSummary{Errors: []ImportRowError{}, Rows: make([]ImportRowResult, 0, len(rows))}
+	// Invalid rows are recorded before the batch holding earlier lines is written
+	defer summary.sortByLine()
+
+	seen := make(map[string]bool, len(rows))
+	batch := make([]importItem, 0, ImportBatchSize)
+	for _, row := range rows {
+		user := &domain.User{Email: row.Email, Password: row.Password}
+		if err := s.validateUser(user); err != nil {
+			summary.Errors = append(summary.Errors, ImportRowError{
+				Line:   row.Line,
+				Error:  err.Error(),
+				Fields: err.(*ValidationError).Fields,
+			})
+			summary.record(row.Line, ImportRuleInvalid)
+			continue
+		}
+		if seen[user.Email] {
+			summary.SkippedDuplicates++
+			summary.record(row.Line, ImportRuleDuplicateInFile)
+			continue
+		}
+		seen[user.Email] = true
+
+		user.ID = s.ids.NewID()
+		hash, err := s.hasher.Hash(user.Password)
+		if err != nil {
+			return summary, err
+		}
+		user.Password = hash
+
+		if batch = append(batch, importItem{line: row.Line, user: user}); len(batch) == ImportBatchSize {
+			if err := s.importBatch(ctx, batch, summary); err != nil {
+				return summary, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := s.importBatch(ctx, batch, summary); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+func (s *UserService) importBatch(ctx context.Context, batch []importItem, summary *ImportSummary) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	users := make([]*domain.User, len(batch))
+	for i, item := range batch {
+		users[i] = item.user
+	}
+
+	// Rules are only reported once the batch commits. Audit events for the inserted and
+	// restored rows commit with it, as CreateUser's do.
+	var rules []string
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		inserted, err := s.repo.ImportMany(ctx, users)
+		if err != nil {
+			return err
+		}
+		rules = make([]string, len(batch))
+		for i, ok := range inserted {
+			if ok {
+				rules[i] = ImportRuleCreated
+				if err := s.recordUserAudit(ctx, domain.AuditUserCreated, nil, users[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			if rules[i], err = s.importCollision(ctx, users[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	for i, rule := range rules {
+		switch rule {
+		case ImportRuleCreated:
+			summary.Created++
+		case ImportRuleDeletedRestored:
+			summary.Restored++
+		case ImportRuleDeletedRejected:
+			summary.Errors = append(summary.Errors, ImportRowError{
+				Line:  batch[i].line,
+				Error: "email belongs to a deleted account",
+			})
+		default:
+			summary.SkippedDuplicates++
+		}
+		summary.record(batch[i].line, rule)
+	}
+	return nil
+}
+
+// importCollision decides what happens to a row ImportMany skipped because its email
+// is taken. The unique index covers deleted accounts too, so the email may belong to
+// one; if so, cfg.ImportDeletedPolicy applies.
+func (s *UserService) importCollision(ctx context.Context, user *domain.User) (string, error) {
+	deleted, err := s.repo.GetDeletedByEmail(ctx, user.Email)
+	if errors.Is(err, ports.ErrNotFound) {
+		return ImportRuleActiveAccount, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch s.cfg.ImportDeletedPolicy {
+	case ImportDeletedRestore:
+		restored, err := s.restoreWithPassword(ctx, deleted.ID, user.Password)
+		if errors.Is(err, ErrDuplicateEmail) {
+			// Restored by someone else since the lookup
+			return ImportRuleActiveAccount, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := s.recordUserAudit(ctx, domain.AuditUserUpdated, deleted, restored); err != nil {
+			return "", err
+		}
+		*user = *restored
+		return ImportRuleDeletedRestored, nil
+	case ImportDeletedError:
+		return ImportRuleDeletedRejected, nil
+	}
+	return ImportRuleDeletedSkipped, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/factory"
+)
+
+func TestImportUsersAuditsInsertedRows(t *testing.T) {
+	f := newUserFixture(t, UserConfig{}, factory.User().WithID("existing").WithEmail("taken@example.com").Build())
+
+	rows := []ImportRow{
+		{Line: 2, Email: "one@example.com", Password: testPassword},
+		{Line: 3, Email: "taken@example.com", Password: testPassword},
+		{Line: 4, Email: "nope", Password: testPassword},
+		{Line: 5, Email: "two@example.com", Password: testPassword},
+	}
+	summary, err := f.svc.ImportUsers(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	if summary.Created != 2 || summary.SkippedDuplicates != 1 || len(summary.Errors) != 1 {
+		t.Fatalf("summary = %+v, want 2 created, 1 duplicate, 1 error", summary)
+	}
+
+	events := f.audits.Events()
+	if len(events) != summary.Created {
+		t.Fatalf("audit events = %+v, want one per inserted row", events)
+	}
+	for _, event := range events {
+		if event.Action != domain.AuditUserCreated || event.EntityID == "existing" {
+			t.Errorf("audit event %+v, want %s of an imported user", event, domain.AuditUserCreated)
+		}
+	}
+	if f.tx.Calls() != 1 {
+		t.Errorf("transactions = %d, want one per batch", f.tx.Calls())
+	}
+}
+
+func TestImportUsersOneTransactionPerBatch(t *testing.T) {
+	f := newUserFixture(t, UserConfig{})
+
+	rows := make([]ImportRow, ImportBatchSize+1)
+	for i := range rows {
+		rows[i] = ImportRow{Line: i + 2, Email: fmt.Sprintf("user%d@example.com", i), Password: testPassword}
+	}
+	summary, err := f.svc.ImportUsers(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	if summary.Created != len(rows) || len(f.audits.Events()) != len(rows) {
+		t.Errorf("created = %d, audit events = %d, want %d of each", summary.Created, len(f.audits.Events()), len(rows))
+	}
+	if f.tx.Calls() != 2 {
+		t.Errorf("transactions = %d, want 2", f.tx.Calls())
+	}
+}
+
+func TestImportUsersRollsBackBatchWhenAuditFails(t *testing.T) {
+	f := newUserFixture(t, UserConfig{})
+	f.audits.Err = errors.New("audit store is down")
+
+	rows := []ImportRow{{Line: 2, Email: "one@example.com", Password: testPassword}}
+	summary, err := f.svc.ImportUsers(context.Background(), rows)
+	if err == nil {
+		t.Fatal("ImportUsers() succeeded without its audit trail")
+	}
+	if summary.Created != 0 {
+		t.Errorf("created = %d, want 0", summary.Created)
+	}
+	if _, ok := f.users.Stored("id-1"); ok {
+		t.Error("imported user stored without an audit event")
+	}
+}
+
+func TestImportUsersCollisions(t *testing.T) {
+	rows := []ImportRow{
+		{Line: 2, Email: "Active@Example.com ", Password: testPassword},
+		{Line: 3, Email: " DELETED@example.com", Password: "new horse 2"},
+		{Line: 4, Email: "new@example.com", Password: testPassword},
+		{Line: 5, Email: "NEW@example.com", Password: testPassword},
+		{Line: 6, Email: "nope", Password: testPassword},
+	}
+
+	tests := []struct {
+		policy       string
+		deletedRule  string
+		wantRestored int
+		wantErrors   []int // lines
+	}{
+		{policy: "", deletedRule: ImportRuleDeletedSkipped, wantErrors: []int{6}},
+		{policy: ImportDeletedSkip, deletedRule: ImportRuleDeletedSkipped, wantErrors: []int{6}},
+		{policy: ImportDeletedRestore, deletedRule: ImportRuleDeletedRestored, wantRestored: 1, wantErrors: []int{6}},
+		{policy: ImportDeletedError, deletedRule: ImportRuleDeletedRejected, wantErrors: []int{3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			deleted := factory.User().WithID("deleted").WithEmail("deleted@example.com").Verified().Deleted().Build()
+			f := newUserFixture(t, UserConfig{ImportDeletedPolicy: tt.policy},
+				factory.User().WithID("active").WithEmail("active@example.com").Build(), deleted)
+
+			summary, err := f.svc.ImportUsers(context.Background(), rows)
+			if err != nil {
+				t.Fatalf("ImportUsers() error = %v", err)
+			}
+
+			want := []ImportRowResult{
+				{Line: 2, Rule: ImportRuleActiveAccount},
+				{Line: 3, Rule: tt.deletedRule},
+				{Line: 4, Rule: ImportRuleCreated},
+				{Line: 5, Rule: ImportRuleDuplicateInFile},
+				{Line: 6, Rule: ImportRuleInvalid},
+			}
+			if !slices.Equal(summary.Rows, want) {
+				t.Errorf("rows = %+v, want %+v", summary.Rows, want)
+			}
+			var errorLines []int
+			for _, rowErr := range summary.Errors {
+				errorLines = append(errorLines, rowErr.Line)
+			}
+			if !slices.Equal(errorLines, tt.wantErrors) {
+				t.Errorf("error lines = %v, want %v", errorLines, tt.wantErrors)
+			}
+			wantSkipped := 3 - tt.wantRestored - (len(tt.wantErrors) - 1)
+			if summary.Created != 1 || summary.Restored != tt.wantRestored || summary.SkippedDuplicates != wantSkipped {
+				t.Errorf("summary = %+v, want 1 created, %d restored, %d skipped", summary, tt.wantRestored, wantSkipped)
+			}
+
+			stored, _ := f.users.Stored("deleted")
+			if restored := stored.DeletedAt == nil; restored != (tt.wantRestored == 1) {
+				t.Fatalf("deleted account restored = %v under policy %q", restored, tt.policy)
+			}
+			if tt.wantRestored == 0 {
+				if stored.Password != "hashed:"+testPassword {
+					t.Error("password of a deleted account changed without restoring it")
+				}
+				return
+			}
+			if stored.Password != "hashed:new horse 2" || stored.EmailVerifiedAt != nil {
+				t.Errorf("restored account = %+v, want the row's password and an unverified email", stored)
+			}
+			var audited bool
+			for _, event := range f.audits.Events() {
+				if event.EntityID == "deleted" && event.Action == domain.AuditUserUpdated {
+					_, audited = event.Diff["deleted_at"]
+				}
+			}
+			if !audited {
+				t.Errorf("audit events = %+v, want the restore recorded", f.audits.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/validation"
+)
+
+// RequestPasswordReset emails a reset token to the account with the given address.
+// It succeeds without doing anything when no account matches, so callers can't probe for accounts.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = validation.NormalizeEmail(email)
+	if email == "" {
+		return ErrInvalidInput
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	record := &domain.PasswordResetToken{
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}
+	if err := s.resets.Create(ctx, record); err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	link := s.cfg.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, ports.EmailMessage{
+		Kind:    ports.EmailPasswordReset,
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for this account. To choose a new one, open:\n\n%s\n\n"+
+			"The link expires in %s. If you didn't ask for this, you can ignore this email.",
+			link, s.cfg.PasswordResetTTL),
+	})
+}
+
+// ConfirmPasswordReset redeems a reset token and sets the user's password to newPassword
+func (s *UserService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	fields := make(map[string]string)
+	if token == "" {
+		fields["token"] = "is required"
+	}
+	s.validatePassword(fields, newPassword)
+	if msg, ok := fields["password"]; ok {
+		delete(fields, "password")
+		fields["new_password"] = msg
+	}
+	// Validate before redeeming so a rejected password doesn't burn the token
+	if err := newValidationError(fields); err != nil {
+		return err
+	}
+	hash := hashToken(token)
+
+	record, err := s.resets.GetByHash(ctx, hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrTokenNotFound
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+	now := time.Now()
+	if record.UsedAt != nil || !now.Before(record.ExpiresAt) {
+		return ErrTokenGone
+	}
+
+	user, err := s.GetUser(ctx, record.UserID)
+	if err != nil {
+		return err
+	}
+	password, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.resets.MarkUsed(ctx, hash, now); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrTokenGone
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	user.Password = password
+	if err := s.repo.Update(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
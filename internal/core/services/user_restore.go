@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// RestoreUser undoes DeleteUser, returning ErrUserNotFound unless the user is currently deleted
+func (s *UserService) RestoreUser(ctx context.Context, id string) (*domain.User, error) {
+	if id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+// restoreOnSignup revives the deleted account holding user.Email with the password just hashed
+// into user. The address is treated as unverified because the new signup hasn't proven it.
+func (s *UserService) restoreOnSignup(ctx context.Context, user *domain.User) error {
+	deleted, err := s.repo.GetDeletedByEmail(ctx, user.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			// Taken by an active account after all
+			return ErrDuplicateEmail
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	restored, err := s.restoreWithPassword(ctx, deleted.ID, user.Password)
+	if err != nil {
+		return err
+	}
+	*user = *restored
+	return nil
+}
+
+// restoreWithPassword revives the deleted account id with an already hashed password,
+// leaving its address unverified. ErrDuplicateEmail means it was restored or purged
+// concurrently.
+func (s *UserService) restoreWithPassword(ctx context.Context, id, hash string) (*domain.User, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrDuplicateEmail
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	restored, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	restored.Password = hash
+	restored.EmailVerifiedAt = nil
+	if err := s.repo.Update(ctx, restored); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return nil, ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+	return restored, nil
+}
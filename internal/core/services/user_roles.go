@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// ErrLastAdmin is returned when a change would leave no admin able to manage roles
+var ErrLastAdmin = errors.New("cannot demote the last remaining admin")
+
+// adminsLock is held while admins are counted, so two concurrent demotions can't
+// each see the other admin still in place
+const adminsLock = "admin"
+
+// SetUserRole assigns one of domain.Roles to a user. Demoting the only admin is refused.
+func (s *UserService) SetUserRole(ctx context.Context, id, role string) (*domain.User, error) {
+	if id == "" {
+		return nil, ErrInvalidInput
+	}
+	if !domain.ValidRole(role) {
+		return nil, newValidationError(map[string]string{"role": "must be one of " + strings.Join(domain.Roles, ", ")})
+	}
+
+	var user *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockUser(ctx, id); err != nil {
+			return err
+		}
+		stored, err := s.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = stored
+		if stored.Role == role {
+			return nil
+		}
+
+		if stored.Role == domain.RoleAdmin {
+			if err := s.tx.LockEntity(ctx, "role", adminsLock); err != nil {
+				return err
+			}
+			admins, err := s.repo.CountByRole(ctx, domain.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		before := *stored
+		stored.Role = role
+		if err := s.repo.Update(ctx, stored); err != nil {
+			return err
+		}
+		return s.recordUserAudit(ctx, domain.AuditRoleChanged, &before, stored)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return nil, ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -3,9 +3,14 @@ package services
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"example.com/monolithic/internal/core/domain"
 	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/validation"
 )
 
 // Service errors
@@ -13,33 +18,276 @@ var (
 	ErrInvalidInput   = errors.New("invalid input")
 	ErrUserNotFound   = errors.New("user not found")
 	ErrDuplicateEmail = errors.New("email already exists")
+	ErrUnavailable    = errors.New("service temporarily unavailable")
+	ErrBatchTooLarge  = errors.New("batch too large")
+	// ErrVersionConflict means the user changed since the version the caller last read
+	ErrVersionConflict = errors.New("user was modified concurrently")
+
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrPasswordUnchanged = errors.New("new password must differ from the current one")
+	ErrSelfDelete        = errors.New("cannot delete your own account")
+)
+
+// ValidationError lists every invalid field of a request with a message for each.
+// It matches ErrInvalidInput under errors.Is.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed"
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func newValidationError(fields map[string]string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// ConflictError reports that a unique field other than email is already taken
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return "resource already exists"
+	}
+	return e.Field + " already exists"
+}
+
+// translateConflict maps a repository conflict to the error clients see
+func translateConflict(err error) (error, bool) {
+	var conflict *ports.ConflictError
+	if !errors.As(err, &conflict) {
+		return nil, false
+	}
+	if conflict.Field == "email" {
+		return ErrDuplicateEmail, true
+	}
+	return &ConflictError{Field: conflict.Field}, true
+}
+
+// MaxBulkCreate caps the number of users accepted by a single bulk create
+const MaxBulkCreate = 500
+
+// BulkItemResult reports the outcome of one entry in a bulk create
+type BulkItemResult struct {
+	Index  int               `json:"index"`
+	ID     string            `json:"id,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Pagination limits for listing users
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+
+	// MinEmailPrefixLength keeps email searches selective enough to avoid full scans
+	MinEmailPrefixLength = 3
 )
 
+// ListUsersOptions selects, sorts and pages a user listing
+type ListUsersOptions struct {
+	Limit  int
+	Offset int
+	// Sort is one of ports.UserSortFields and Order is "asc" or "desc"; see ListUsers for defaults
+	Sort  string
+	Order string
+	// CreatedAfter and CreatedBefore bound created_at inclusively; zero leaves a bound open
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// UserPage is one page of users along with the pagination that was applied
+type UserPage struct {
+	Users  []domain.User
+	Limit  int
+	Offset int
+	// Total is how many users match across all pages; nil when the query isn't counted
+	Total *int64
+}
+
+// UserConfig controls account lifecycle behaviour
+type UserConfig struct {
+	// VerificationTTL is how long an email verification token stays redeemable
+	VerificationTTL time.Duration
+	// VerifyURL is the public URL that verification links point at
+	VerifyURL string
+	// PasswordResetTTL is how long a password reset token stays redeemable
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the public URL that password reset links point at
+	PasswordResetURL string
+	// RestoreDeletedOnSignup makes signing up with the email of a deleted account restore
+	// that account with the new password; otherwise the signup fails with ErrDuplicateEmail
+	RestoreDeletedOnSignup bool
+	// ImportDeletedPolicy is what ImportUsers does with a row whose email belongs to a
+	// deleted account: ImportDeletedSkip (the default), ImportDeletedRestore or ImportDeletedError
+	ImportDeletedPolicy string
+	// AllowClientIDs accepts an ID supplied by the client on create; otherwise a supplied ID
+	// is a validation error and every ID comes from the IDGenerator
+	AllowClientIDs bool
+	// AllowSelfDelete lets a user delete their own account through DeleteUser
+	AllowSelfDelete bool
+	// PasswordPolicy is applied to every new password: signup, import, change and reset
+	PasswordPolicy validation.PasswordPolicy
+}
+
 type UserService struct {
-	repo ports.UserRepository
+	repo   ports.UserRepository
+	tokens ports.TokenRepository
+	resets ports.PasswordResetRepository
+	audits ports.AuditRepository
+	tx     ports.Transactor
+	hasher ports.PasswordHasher
+	ids    ports.IDGenerator
+	mailer ports.EmailSender
+	files  ports.FileStorage
+	cfg    UserConfig
+
+	// dummyHash is compared against when a login names an unknown email
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewUserService(repo ports.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo ports.UserRepository, tokens ports.TokenRepository, resets ports.PasswordResetRepository, audits ports.AuditRepository, tx ports.Transactor, hasher ports.PasswordHasher, ids ports.IDGenerator, mailer ports.EmailSender, files ports.FileStorage, cfg UserConfig) *UserService {
+	return &UserService{repo: repo, tokens: tokens, resets: resets, audits: audits, tx: tx, hasher: hasher, ids: ids, mailer: mailer, files: files, cfg: cfg}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, user *domain.User) error {
 	// Validate input
 	if err := s.validateUser(user); err != nil {
-		return ErrInvalidInput
+		return err
 	}
 
 	// Check for duplicate email
 	exists, err := s.repo.ExistsByEmail(ctx, user.Email)
 	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
 		return err
 	}
 	if exists {
 		return ErrDuplicateEmail
 	}
 
-	// Create user
-	return s.repo.Create(ctx, user)
+	if user.Password, err = s.hasher.Hash(user.Password); err != nil {
+		return err
+	}
+	if user.ID == "" {
+		user.ID = s.ids.NewID()
+	}
+
+	// Create user; a failed insert aborts the transaction, so a restore runs after it
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.recordUserAudit(ctx, domain.AuditUserCreated, nil, user)
+	})
+	if err != nil {
+		if conflict, ok := translateConflict(err); ok {
+			// ExistsByEmail skips deleted accounts, so their addresses surface here
+			if conflict == ErrDuplicateEmail && s.cfg.RestoreDeletedOnSignup {
+				return s.restoreOnSignup(ctx, user)
+			}
+			return conflict
+		}
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CreateUsers validates every entry and creates them all in one transaction.
+// On failure nothing is written and the results name the offending entries.
+func (s *UserService) CreateUsers(ctx context.Context, users []*domain.User) ([]BulkItemResult, error) {
+	if len(users) == 0 {
+		return nil, ErrInvalidInput
+	}
+	if len(users) > MaxBulkCreate {
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]BulkItemResult, len(users))
+	seen := make(map[string]int, len(users))
+	var failure error
+	for i, user := range users {
+		results[i].Index = i
+		if user == nil {
+			results[i].Error = "entry must be an object"
+			failure = ErrInvalidInput
+			continue
+		}
+		if err := s.validateUser(user); err != nil {
+			results[i].Error = err.Error()
+			results[i].Fields = err.(*ValidationError).Fields
+			failure = ErrInvalidInput
+			continue
+		}
+		if _, dup := seen[user.Email]; dup {
+			results[i].Error = ErrDuplicateEmail.Error()
+			failure = ErrInvalidInput
+			continue
+		}
+		seen[user.Email] = i
+	}
+	if failure != nil {
+		return results, failure
+	}
+
+	for _, user := range users {
+		hash, err := s.hasher.Hash(user.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hash
+		if user.ID == "" {
+			user.ID = s.ids.NewID()
+		}
+	}
+
+	// Each row's audit event commits with the batch, as CreateUser's does
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateMany(ctx, users); err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := s.recordUserAudit(ctx, domain.AuditUserCreated, nil, user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var itemErr *ports.BatchItemError
+		if errors.As(err, &itemErr) {
+			if conflict, ok := translateConflict(err); ok {
+				results[itemErr.Index].Error = conflict.Error()
+				return results, conflict
+			}
+		}
+		switch {
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	for i, user := range users {
+		results[i].ID = user.ID
+	}
+	return results, nil
 }
 
 func (s *UserService) GetUser(ctx context.Context, id string) (*domain.User, error) {
@@ -52,16 +300,324 @@ func (s *UserService) GetUser(ctx context.Context, id string) (*domain.User, err
 		if errors.Is(err, ports.ErrNotFound) {
 			return nil, ErrUserNotFound
 		}
+		if errors.Is(err, ports.ErrUnavailable) {
+			return nil, ErrUnavailable
+		}
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *UserService) validateUser(user *domain.User) error {
-	if user.Email == "" {
-		return errors.New("email is required")
+// UserExists reports whether a user with id exists without loading it
+func (s *UserService) UserExists(ctx context.Context, id string) (bool, error) {
+	if id == "" {
+		return false, ErrInvalidInput
+	}
+
+	exists, err := s.repo.ExistsByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return false, ErrUnavailable
+		}
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// UpdateUser replaces the editable fields of a user. A non-zero user.Version must match
+// the stored version, or ErrVersionConflict is returned and nothing is written.
+func (s *UserService) UpdateUser(ctx context.Context, user *domain.User) error {
+	if user.ID == "" {
+		return ErrInvalidInput
+	}
+	if err := s.validateUserUpdate(user); err != nil {
+		return err
+	}
+
+	// Reading under the user's lock keeps concurrent updates from interleaving between
+	// the version check and the write, so before is exactly what the row held
+	var current *domain.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockUser(ctx, user.ID); err != nil {
+			return err
+		}
+		stored, err := s.GetUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if user.Version != 0 && user.Version != stored.Version {
+			return ErrVersionConflict
+		}
+		before := *stored
+		if stored.Email != user.Email {
+			// Only a changed address can collide; the unique constraint still catches a
+			// concurrent update of another user that takes the same address before Update
+			exists, err := s.repo.ExistsByEmail(ctx, user.Email)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrDuplicateEmail
+			}
+			// A new address hasn't been proven yet
+			stored.EmailVerifiedAt = nil
+		}
+		stored.Email = user.Email
+
+		if err := s.repo.Update(ctx, stored); err != nil {
+			return err
+		}
+		current = stored
+		return s.recordUserAudit(ctx, domain.AuditUserUpdated, &before, stored)
+	})
+	if err != nil {
+		if conflict, ok := translateConflict(err); ok {
+			return conflict
+		}
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
 	}
-	// Add more validation as needed
+
+	*user = *current
 	return nil
 }
+
+// ChangePassword replaces a user's password after checking the current one
+func (s *UserService) ChangePassword(ctx context.Context, id, currentPassword, newPassword string) error {
+	if id == "" {
+		return ErrInvalidInput
+	}
+	fields := make(map[string]string)
+	if currentPassword == "" {
+		fields["current_password"] = "is required"
+	}
+	s.validatePassword(fields, newPassword)
+	if msg, ok := fields["password"]; ok {
+		delete(fields, "password")
+		fields["new_password"] = msg
+	}
+	if err := newValidationError(fields); err != nil {
+		return err
+	}
+
+	// The check and the write happen under the user's lock so a concurrent change
+	// can't slip in between verifying the current password and replacing it
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockUser(ctx, id); err != nil {
+			return err
+		}
+		user, err := s.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPassword(user.Password, currentPassword); err != nil {
+			if errors.Is(err, ports.ErrPasswordMismatch) {
+				return ErrIncorrectPassword
+			}
+			return err
+		}
+		if newPassword == currentPassword {
+			return ErrPasswordUnchanged
+		}
+
+		before := *user
+		if user.Password, err = s.hasher.Hash(newPassword); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.recordUserAudit(ctx, domain.AuditPasswordChanged, &before, user)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, ports.ErrVersionConflict):
+			return ErrVersionConflict
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser soft-deletes targetID on behalf of requesterID. Deleting your own
+// account is refused with ErrSelfDelete unless UserConfig.AllowSelfDelete is set.
+func (s *UserService) DeleteUser(ctx context.Context, requesterID, targetID string) error {
+	if requesterID == "" || targetID == "" {
+		return ErrInvalidInput
+	}
+	if requesterID == targetID && !s.cfg.AllowSelfDelete {
+		return ErrSelfDelete
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.lockUser(ctx, targetID); err != nil {
+			return err
+		}
+		before, err := s.repo.GetByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, targetID); err != nil {
+			return err
+		}
+		after := *before
+		deletedAt := time.Now()
+		after.DeletedAt = &deletedAt
+		return s.recordUserAudit(ctx, domain.AuditUserDeleted, before, &after)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, ports.ErrUnavailable):
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// lockUser serializes mutations of the user with id until the transaction in ctx ends
+func (s *UserService) lockUser(ctx context.Context, id string) error {
+	return s.tx.LockEntity(ctx, domain.AuditEntityUser, id)
+}
+
+// ExportUsers passes every user created after createdAfter to fn, oldest first, without loading them all at once
+func (s *UserService) ExportUsers(ctx context.Context, createdAfter time.Time, fn func(*domain.User) error) error {
+	if err := s.repo.ForEach(ctx, createdAfter, fn); err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+	return nil
+}
+
+// ListUsers returns a page of users sorted by one of ports.UserSortFields.
+// An empty sort lists newest first; an empty order is ascending otherwise.
+func (s *UserService) ListUsers(ctx context.Context, opts ListUsersOptions) (*UserPage, error) {
+	if opts.Limit < 1 || opts.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	limit := min(opts.Limit, MaxListLimit)
+	offset := opts.Offset
+
+	userSort, err := parseUserSort(opts.Sort, opts.Order)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.CreatedAfter.IsZero() && !opts.CreatedBefore.IsZero() && opts.CreatedAfter.After(opts.CreatedBefore) {
+		return nil, newValidationError(map[string]string{"created_after": "must not be later than created_before"})
+	}
+
+	filter := ports.UserFilter{
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		Sort:          userSort,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	users, err := s.repo.List(ctx, filter)
+	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return &UserPage{Users: users, Limit: limit, Offset: offset, Total: &total}, nil
+}
+
+func (s *UserService) SearchUsersByEmail(ctx context.Context, prefix string, limit int) (*UserPage, error) {
+	if len(prefix) < MinEmailPrefixLength || limit < 1 {
+		return nil, ErrInvalidInput
+	}
+	limit = min(limit, MaxListLimit)
+
+	users, err := s.repo.SearchByEmail(ctx, prefix, limit)
+	if err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return &UserPage{Users: users, Limit: limit}, nil
+}
+
+// parseUserSort checks sort and order against the fields users can be listed by
+func parseUserSort(sort, order string) (ports.UserSort, error) {
+	if sort == "" && order == "" {
+		return ports.UserSort{Field: "created_at", Desc: true}, nil
+	}
+	if sort == "" {
+		sort = "created_at"
+	}
+
+	fields := make(map[string]string)
+	if !slices.Contains(ports.UserSortFields, sort) {
+		fields["sort"] = "must be one of " + strings.Join(ports.UserSortFields, ", ")
+	}
+	if order != "" && order != "asc" && order != "desc" {
+		fields["order"] = "must be asc or desc"
+	}
+	if err := newValidationError(fields); err != nil {
+		return ports.UserSort{}, err
+	}
+	return ports.UserSort{Field: sort, Desc: order == "desc"}, nil
+}
+
+// validateUser normalizes the email of a user about to be created, then checks the user
+func (s *UserService) validateUser(user *domain.User) error {
+	user.Email = validation.NormalizeEmail(user.Email)
+	fields := make(map[string]string)
+	if user.ID != "" && !s.cfg.AllowClientIDs {
+		fields["id"] = "is assigned by the server"
+	}
+	validateEmail(fields, user.Email)
+	s.validatePassword(fields, user.Password)
+	return newValidationError(fields)
+}
+
+// validateUserUpdate normalizes and checks the fields a client may change on an existing user
+func (s *UserService) validateUserUpdate(user *domain.User) error {
+	user.Email = validation.NormalizeEmail(user.Email)
+	fields := make(map[string]string)
+	validateEmail(fields, user.Email)
+	return newValidationError(fields)
+}
+
+func validateEmail(fields map[string]string, email string) {
+	if err := validation.ValidateEmail(email); err != nil {
+		fields["email"] = err.Error()
+	}
+}
+
+// validatePassword reports every policy violation under fields["password"]
+func (s *UserService) validatePassword(fields map[string]string, password string) {
+	if violations := s.cfg.PasswordPolicy.Validate(password); len(violations) > 0 {
+		fields["password"] = strings.Join(violations, "; ")
+	}
+}
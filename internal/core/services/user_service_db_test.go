@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/platform/database"
+	"example.com/monolithic/internal/repositories"
+	"example.com/monolithic/internal/testutil"
+)
+
+// newDBUserService returns a UserService backed by the test database
+func newDBUserService(t *testing.T, db *database.DB) *services.UserService {
+	return services.NewUserService(repositories.NewUserRepository(db), repositories.NewTokenRepository(db),
+		repositories.NewPasswordResetRepository(db), repositories.NewAuditRepository(db), repositories.NewTransactor(db),
+		testutil.PasswordHasher{}, &testutil.IDGenerator{}, &testutil.EmailSender{}, testutil.NewFileStorage(), services.UserConfig{})
+}
+
+func TestConcurrentUpdatesAreSerialized(t *testing.T) {
+	db := testutil.OpenDB(t)
+	svc := newDBUserService(t, db)
+	ctx := context.Background()
+	user := factory.User().WithEmail("user@example.com").Create(t, repositories.NewUserRepository(db))
+
+	// Unconditional updates (version 0) would race between read and write without the user lock
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- svc.UpdateUser(ctx, &domain.User{ID: user.ID, Email: fmt.Sprintf("user%d@example.com", i)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("UpdateUser() error = %v", err)
+		}
+	}
+
+	stored, err := svc.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if stored.Version != writers+1 {
+		t.Errorf("version = %d, want %d", stored.Version, writers+1)
+	}
+
+	// Each audited update starts from what the previous one wrote
+	rows, err := db.QueryContext(ctx, `
+        SELECT diff->'email'->>'before', diff->'email'->>'after' FROM audit_events
+        WHERE entity_id = $1 AND action = $2 ORDER BY created_at`, user.ID, domain.AuditUserUpdated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	previous := "user@example.com"
+	for rows.Next() {
+		var before, after string
+		if err := rows.Scan(&before, &after); err != nil {
+			t.Fatal(err)
+		}
+		if before != previous {
+			t.Errorf("update from %q, want from %q", before, previous)
+		}
+		previous = after
+	}
+	if previous != stored.Email {
+		t.Errorf("last audited email = %q, stored %q", previous, stored.Email)
+	}
+}
+
+func TestConcurrentDemotionsKeepAnAdmin(t *testing.T) {
+	db := testutil.OpenDB(t)
+	svc := newDBUserService(t, db)
+	ctx := context.Background()
+	users := repositories.NewUserRepository(db)
+	ids := []string{
+		factory.User().WithRole(domain.RoleAdmin).Create(t, users).ID,
+		factory.User().WithRole(domain.RoleAdmin).Create(t, users).ID,
+	}
+
+	// Each admin demotes the other at the same time; only one may succeed
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ids))
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.SetUserRole(ctx, id, domain.RoleUser)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var demoted, refused int
+	for err := range errs {
+		switch {
+		case err == nil:
+			demoted++
+		case errors.Is(err, services.ErrLastAdmin):
+			refused++
+		default:
+			t.Errorf("SetUserRole() error = %v", err)
+		}
+	}
+	if demoted != 1 || refused != 1 {
+		t.Errorf("demoted %d and refused %d, want one of each", demoted, refused)
+	}
+
+	var audited int
+	err := db.QueryRowContext(ctx, `SELECT count(*) FROM audit_events WHERE action = $1 AND diff->'role'->>'after' = $2`,
+		domain.AuditRoleChanged, domain.RoleUser).Scan(&audited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audited != 1 {
+		t.Errorf("audited demotions = %d, want 1", audited)
+	}
+}
+
+func TestImportUsersCollisionPolicies(t *testing.T) {
+	tests := []struct {
+		policy      string
+		deletedRule string
+		wantDeleted bool // whether the deleted account is still deleted afterwards
+	}{
+		{policy: services.ImportDeletedSkip, deletedRule: services.ImportRuleDeletedSkipped, wantDeleted: true},
+		{policy: services.ImportDeletedRestore, deletedRule: services.ImportRuleDeletedRestored},
+		{policy: services.ImportDeletedError, deletedRule: services.ImportRuleDeletedRejected, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			db := testutil.OpenDB(t)
+			users := repositories.NewUserRepository(db)
+			svc := services.NewUserService(users, repositories.NewTokenRepository(db),
+				repositories.NewPasswordResetRepository(db), repositories.NewAuditRepository(db), repositories.NewTransactor(db),
+				testutil.PasswordHasher{}, &testutil.IDGenerator{}, &testutil.EmailSender{}, testutil.NewFileStorage(),
+				services.UserConfig{ImportDeletedPolicy: tt.policy})
+			ctx := context.Background()
+
+			factory.User().WithEmail("active@example.com").Create(t, users)
+			deletedID := factory.User().WithEmail("deleted@example.com").Deleted().Create(t, users).ID
+
+			summary, err := svc.ImportUsers(ctx, []services.ImportRow{
+				{Line: 2, Email: "ACTIVE@example.com", Password: "correct horse 1"},
+				{Line: 3, Email: " Deleted@Example.com ", Password: "correct horse 1"},
+				{Line: 4, Email: "new@example.com", Password: "correct horse 1"},
+				{Line: 5, Email: "New@Example.com", Password: "correct horse 1"},
+			})
+			if err != nil {
+				t.Fatalf("ImportUsers() error = %v", err)
+			}
+			want := []services.ImportRowResult{
+				{Line: 2, Rule: services.ImportRuleActiveAccount},
+				{Line: 3, Rule: tt.deletedRule},
+				{Line: 4, Rule: services.ImportRuleCreated},
+				{Line: 5, Rule: services.ImportRuleDuplicateInFile},
+			}
+			if !slices.Equal(summary.Rows, want) {
+				t.Errorf("rows = %+v, want %+v", summary.Rows, want)
+			}
+
+			var accounts int
+			var stillDeleted bool
+			err = db.QueryRowContext(ctx, `SELECT count(*), bool_or(id = $1 AND deleted_at IS NOT NULL) FROM users`, deletedID).
+				Scan(&accounts, &stillDeleted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accounts != 3 {
+				t.Errorf("accounts = %d, want the two seeded and one imported", accounts)
+			}
+			if stillDeleted != tt.wantDeleted {
+				t.Errorf("deleted account still deleted = %v, want %v", stillDeleted, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+const testPassword = "correct horse 1"
+
+// userFixture is a UserService wired to in-memory fakes
+type userFixture struct {
+	svc    *UserService
+	users  *testutil.UserRepository
+	audits *testutil.AuditRepository
+	tx     *testutil.Transactor
+	mailer *testutil.EmailSender
+	files  *testutil.FileStorage
+}
+
+func newUserFixture(t *testing.T, cfg UserConfig, users ...*domain.User) *userFixture {
+	t.Helper()
+	f := &userFixture{
+		users:  testutil.NewUserRepository(users...),
+		audits: testutil.NewAuditRepository(),
+		mailer: &testutil.EmailSender{},
+		files:  testutil.NewFileStorage(),
+	}
+	f.tx = testutil.NewTransactor(f.users, f.audits)
+	f.svc = NewUserService(f.users, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		f.audits, f.tx, testutil.PasswordHasher{}, &testutil.IDGenerator{}, f.mailer, f.files, cfg)
+	return f
+}
+
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     UserConfig
+		user    domain.User
+		wantErr error
+		fields  []string // invalid fields reported by a ValidationError
+	}{
+		{name: "valid", user: domain.User{Email: "New@Example.com", Password: testPassword}},
+		{name: "invalid email", user: domain.User{Email: "nope", Password: testPassword}, wantErr: ErrInvalidInput, fields: []string{"email"}},
+		{name: "short password", user: domain.User{Email: "new@example.com", Password: "x1"}, wantErr: ErrInvalidInput, fields: []string{"password"}},
+		{name: "client id refused", user: domain.User{ID: "mine", Email: "new@example.com", Password: testPassword}, wantErr: ErrInvalidInput, fields: []string{"id"}},
+		{name: "client id allowed", cfg: UserConfig{AllowClientIDs: true}, user: domain.User{ID: "mine", Email: "new@example.com", Password: testPassword}},
+		{name: "duplicate email in another case", user: domain.User{Email: "TAKEN@example.com", Password: testPassword}, wantErr: ErrDuplicateEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, tt.cfg, factory.User().WithID("existing").WithEmail("taken@example.com").Build())
+			user := tt.user
+
+			err := f.svc.CreateUser(context.Background(), &user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				for _, field := range tt.fields {
+					if _, ok := verr.Fields[field]; !ok {
+						t.Errorf("fields = %v, missing %q", verr.Fields, field)
+					}
+				}
+			}
+			if err != nil {
+				if n := len(f.audits.Events()); n != 0 {
+					t.Errorf("failed create recorded %d audit events", n)
+				}
+				return
+			}
+
+			stored, ok := f.users.Stored(user.ID)
+			if !ok {
+				t.Fatalf("user %q not stored", user.ID)
+			}
+			if stored.Email != "new@example.com" {
+				t.Errorf("stored email = %q, want it normalized", stored.Email)
+			}
+			if stored.Password != "hashed:"+testPassword {
+				t.Errorf("stored password = %q, want it hashed", stored.Password)
+			}
+			events := f.audits.Events()
+			if len(events) != 1 || events[0].Action != domain.AuditUserCreated || events[0].EntityID != user.ID {
+				t.Errorf("audit events = %+v, want one %s for %s", events, domain.AuditUserCreated, user.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    domain.User
+		wantErr error
+	}{
+		{name: "new email", user: domain.User{ID: "u1", Email: "changed@example.com"}},
+		{name: "current version", user: domain.User{ID: "u1", Email: "changed@example.com", Version: 1}},
+		{name: "stale version", user: domain.User{ID: "u1", Email: "changed@example.com", Version: 7}, wantErr: ErrVersionConflict},
+		{name: "email taken", user: domain.User{ID: "u1", Email: "other@example.com"}, wantErr: ErrDuplicateEmail},
+		{name: "invalid email", user: domain.User{ID: "u1", Email: "bad"}, wantErr: ErrInvalidInput},
+		{name: "missing user", user: domain.User{ID: "ghost", Email: "changed@example.com"}, wantErr: ErrUserNotFound},
+		{name: "missing id", user: domain.User{Email: "changed@example.com"}, wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, UserConfig{},
+				factory.User().WithID("u1").WithEmail("user@example.com").Build(),
+				factory.User().WithID("u2").WithEmail("other@example.com").Build())
+			user := tt.user
+
+			err := f.svc.UpdateUser(context.Background(), &user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := f.users.Stored("u1")
+			if err != nil {
+				if stored.Email != "user@example.com" || stored.Version != 1 {
+					t.Errorf("failed update changed the user: %+v", stored)
+				}
+				return
+			}
+			if stored.Email != "changed@example.com" || stored.Version != 2 || user.Version != 2 {
+				t.Errorf("stored = %+v, returned version %d", stored, user.Version)
+			}
+			events := f.audits.Events()
+			if len(events) != 1 || events[0].Diff["email"].After != "changed@example.com" {
+				t.Errorf("audit events = %+v, want the email change", events)
+			}
+			if locks := f.tx.Locks(); len(locks) != 1 || locks[0] != "user:u1" {
+				t.Errorf("locks = %v, want [user:u1]", locks)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       UserConfig
+		requester string
+		target    string
+		wantErr   error
+	}{
+		{name: "other user", requester: "admin", target: "u1"},
+		{name: "self refused", requester: "admin", target: "admin", wantErr: ErrSelfDelete},
+		{name: "self allowed", cfg: UserConfig{AllowSelfDelete: true}, requester: "admin", target: "admin"},
+		{name: "missing user", requester: "admin", target: "ghost", wantErr: ErrUserNotFound},
+		{name: "missing requester", target: "u1", wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, tt.cfg,
+				factory.User().WithID("admin").WithEmail("admin@example.com").WithRole(domain.RoleAdmin).Build(),
+				factory.User().WithID("u1").WithEmail("user@example.com").Build())
+
+			err := f.svc.DeleteUser(context.Background(), tt.requester, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := f.svc.GetUser(context.Background(), tt.target); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetUser() after delete error = %v, want %v", err, ErrUserNotFound)
+			}
+			events := f.audits.Events()
+			if len(events) != 1 || events[0].Action != domain.AuditUserDeleted {
+				t.Errorf("audit events = %+v, want one %s", events, domain.AuditUserDeleted)
+			}
+			if locks := f.tx.Locks(); len(locks) != 1 || locks[0] != "user:"+tt.target {
+				t.Errorf("locks = %v, want [user:%s]", locks, tt.target)
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		next     string
+		wantErr  error
+		wantHash string
+	}{
+		{name: "changed", current: testPassword, next: "battery staple 2", wantHash: "hashed:battery staple 2"},
+		{name: "wrong current", current: "guess", next: "battery staple 2", wantErr: ErrIncorrectPassword},
+		{name: "unchanged", current: testPassword, next: testPassword, wantErr: ErrPasswordUnchanged},
+		{name: "weak new password", current: testPassword, next: "short", wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, UserConfig{}, factory.User().WithID("u1").WithEmail("user@example.com").Build())
+
+			err := f.svc.ChangePassword(context.Background(), "u1", tt.current, tt.next)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangePassword() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := f.users.Stored("u1")
+			want := tt.wantHash
+			if want == "" {
+				want = "hashed:" + testPassword
+			}
+			if stored.Password != want {
+				t.Errorf("stored password = %q, want %q", stored.Password, want)
+			}
+			if tt.wantErr == ErrInvalidInput {
+				return // refused before reading the user
+			}
+			if locks := f.tx.Locks(); len(locks) != 1 || locks[0] != "user:u1" {
+				t.Errorf("locks = %v, want [user:u1]", locks)
+			}
+			wantEvents := 0
+			if tt.wantErr == nil {
+				wantEvents = 1
+			}
+			if events := f.audits.Events(); len(events) != wantEvents {
+				t.Errorf("audit events = %+v, want %d", events, wantEvents)
+			}
+		})
+	}
+}
+
+// racingUserRepository fails every Update as if another writer bumped the version first
+type racingUserRepository struct {
+	*testutil.UserRepository
+}
+
+func (r racingUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return ports.ErrVersionConflict
+}
+
+func TestChangePasswordVersionConflict(t *testing.T) {
+	f := newUserFixture(t, UserConfig{}, factory.User().WithID("u1").WithEmail("user@example.com").Build())
+	svc := NewUserService(racingUserRepository{f.users}, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		f.audits, f.tx, testutil.PasswordHasher{}, &testutil.IDGenerator{}, f.mailer, f.files, UserConfig{})
+
+	err := svc.ChangePassword(context.Background(), "u1", testPassword, "battery staple 2")
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("ChangePassword() error = %v, want %v", err, ErrVersionConflict)
+	}
+	if events := f.audits.Events(); len(events) != 0 {
+		t.Errorf("conflicting change recorded audit events %+v", events)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		admins   int
+		id       string
+		role     string
+		wantErr  error
+		wantRole string
+	}{
+		{name: "promote", admins: 1, id: "u1", role: domain.RoleAdmin, wantRole: domain.RoleAdmin},
+		{name: "demote one of two admins", admins: 2, id: "admin-1", role: domain.RoleUser, wantRole: domain.RoleUser},
+		{name: "demote last admin", admins: 1, id: "admin-1", role: domain.RoleUser, wantErr: ErrLastAdmin},
+		{name: "unknown role", admins: 1, id: "u1", role: "owner", wantErr: ErrInvalidInput},
+		{name: "missing user", admins: 1, id: "ghost", role: domain.RoleAdmin, wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := []*domain.User{factory.User().WithID("u1").WithEmail("user@example.com").Build()}
+			for i := 1; i <= tt.admins; i++ {
+				id := "admin-" + string(rune('0'+i))
+				users = append(users, factory.User().WithID(id).WithEmail(id+"@example.com").WithRole(domain.RoleAdmin).Build())
+			}
+			f := newUserFixture(t, UserConfig{}, users...)
+
+			user, err := f.svc.SetUserRole(context.Background(), tt.id, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetUserRole() error = %v, want %v", err, tt.wantErr)
+			}
+			events := f.audits.Events()
+			if err != nil {
+				if len(events) != 0 {
+					t.Errorf("refused role change recorded %+v", events)
+				}
+				return
+			}
+			if user.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", user.Role, tt.wantRole)
+			}
+			if len(events) != 1 || events[0].Action != domain.AuditRoleChanged || events[0].Diff["role"].After != tt.wantRole {
+				t.Errorf("audit events = %+v, want one %s to %s", events, domain.AuditRoleChanged, tt.wantRole)
+			}
+			wantLocks := []string{"user:" + tt.id}
+			if tt.role == domain.RoleUser {
+				wantLocks = append(wantLocks, "role:admin")
+			}
+			if locks := f.tx.Locks(); !slices.Equal(locks, wantLocks) {
+				t.Errorf("locks = %v, want %v", locks, wantLocks)
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	f := newUserFixture(t, UserConfig{},
+		factory.User().WithID("a").WithEmail("a@example.com").Build(),
+		factory.User().WithID("b").WithEmail("b@example.com").Build(),
+		factory.User().WithID("c").WithEmail("c@example.com").Build())
+
+	tests := []struct {
+		name      string
+		opts      ListUsersOptions
+		wantErr   error
+		wantEmail []string
+	}{
+		{name: "by email", opts: ListUsersOptions{Limit: 2, Sort: "email"}, wantEmail: []string{"a@example.com", "b@example.com"}},
+		{name: "by email desc with offset", opts: ListUsersOptions{Limit: 2, Offset: 1, Sort: "email", Order: "desc"}, wantEmail: []string{"b@example.com", "a@example.com"}},
+		{name: "unknown sort", opts: ListUsersOptions{Limit: 2, Sort: "password"}, wantErr: ErrInvalidInput},
+		{name: "injected sort", opts: ListUsersOptions{Limit: 2, Sort: "email; DROP TABLE users"}, wantErr: ErrInvalidInput},
+		{name: "injected order", opts: ListUsersOptions{Limit: 2, Sort: "email", Order: "asc; DROP TABLE users"}, wantErr: ErrInvalidInput},
+		{name: "zero limit", opts: ListUsersOptions{}, wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed := len(f.users.Filters())
+			page, err := f.svc.ListUsers(context.Background(), tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ListUsers() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				// A rejected sort is never handed to the repository, so it can't reach SQL
+				if filters := f.users.Filters(); len(filters) != listed {
+					t.Errorf("repository listed with %+v after a rejected request", filters[listed:])
+				}
+				return
+			}
+			if *page.Total != 3 {
+				t.Errorf("total = %d, want 3", *page.Total)
+			}
+			var emails []string
+			for _, user := range page.Users {
+				emails = append(emails, user.Email)
+			}
+			if len(emails) != len(tt.wantEmail) {
+				t.Fatalf("emails = %v, want %v", emails, tt.wantEmail)
+			}
+			for i := range emails {
+				if emails[i] != tt.wantEmail[i] {
+					t.Errorf("emails = %v, want %v", emails, tt.wantEmail)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCreateUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		emails    []string
+		wantErr   error
+		failIndex int // entry whose result carries the error, when wantErr is set
+	}{
+		{name: "all created", emails: []string{"one@example.com", "two@example.com"}},
+		{name: "taken email rolls back the batch", emails: []string{"one@example.com", "taken@example.com"}, wantErr: ErrDuplicateEmail, failIndex: 1},
+		{name: "duplicate within the batch", emails: []string{"one@example.com", "one@example.com"}, wantErr: ErrInvalidInput, failIndex: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUserFixture(t, UserConfig{}, factory.User().WithID("existing").WithEmail("taken@example.com").Build())
+			users := make([]*domain.User, len(tt.emails))
+			for i, email := range tt.emails {
+				users[i] = &domain.User{Email: email, Password: testPassword}
+			}
+
+			results, err := f.svc.CreateUsers(context.Background(), users)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateUsers() error = %v, want %v", err, tt.wantErr)
+			}
+			events := f.audits.Events()
+			if err != nil {
+				if results[tt.failIndex].Error == "" {
+					t.Errorf("results = %+v, want entry %d to carry the error", results, tt.failIndex)
+				}
+				if len(events) != 0 {
+					t.Errorf("failed batch recorded audit events %+v", events)
+				}
+				if _, ok := f.users.Stored(users[0].ID); ok && users[0].ID != "" {
+					t.Errorf("failed batch stored %s", users[0].ID)
+				}
+				return
+			}
+
+			if len(events) != len(users) {
+				t.Fatalf("audit events = %d, want one per created user", len(events))
+			}
+			for i, event := range events {
+				if event.Action != domain.AuditUserCreated || event.EntityID != results[i].ID {
+					t.Errorf("audit event %d = %+v, want %s of %s", i, event, domain.AuditUserCreated, results[i].ID)
+				}
+			}
+			if f.tx.Calls() != 1 {
+				t.Errorf("transactions = %d, want the batch and its audit in one", f.tx.Calls())
+			}
+		})
+	}
+}
+
+func TestCreateUsersRollsBackWhenAuditFails(t *testing.T) {
+	f := newUserFixture(t, UserConfig{})
+	f.audits.Err = errors.New("audit store is down")
+
+	users := []*domain.User{{Email: "one@example.com", Password: testPassword}}
+	if _, err := f.svc.CreateUsers(context.Background(), users); err == nil {
+		t.Fatal("CreateUsers() succeeded without its audit trail")
+	}
+	if _, ok := f.users.Stored(users[0].ID); ok {
+		t.Errorf("user %s stored without an audit event", users[0].ID)
+	}
+}
+
+func TestUnavailableStorage(t *testing.T) {
+	f := newUserFixture(t, UserConfig{}, factory.User().WithID("u1").WithEmail("user@example.com").Build())
+	f.users.Err = ports.ErrUnavailable
+
+	if _, err := f.svc.GetUser(context.Background(), "u1"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("GetUser() error = %v, want %v", err, ErrUnavailable)
+	}
+	if err := f.svc.CreateUser(context.Background(), &domain.User{Email: "new@example.com", Password: testPassword}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("CreateUser() error = %v, want %v", err, ErrUnavailable)
+	}
+	if err := f.svc.DeleteUser(context.Background(), "admin", "u1"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("DeleteUser() error = %v, want %v", err, ErrUnavailable)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Email verification errors
+var (
+	ErrAlreadyVerified = errors.New("email already verified")
+	ErrTokenNotFound   = errors.New("token not found")
+	ErrTokenGone       = errors.New("token expired or already used")
+)
+
+// IssueVerification creates a fresh verification token for the user and emails it to them
+func (s *UserService) IssueVerification(ctx context.Context, userID string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	record := &domain.VerificationToken{
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.cfg.VerificationTTL),
+	}
+	if err := s.tokens.Create(ctx, record); err != nil {
+		if errors.Is(err, ports.ErrUnavailable) {
+			return ErrUnavailable
+		}
+		return err
+	}
+
+	link := s.cfg.VerifyURL + "?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, ports.EmailMessage{
+		Kind:    ports.EmailVerification,
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Confirm your email address by opening this link:\n\n%s\n\nThe link expires in %s.",
+			link, s.cfg.VerificationTTL),
+	})
+}
+
+// VerifyEmail redeems a verification token and marks its user's email as verified
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	if token == "" {
+		return nil, ErrInvalidInput
+	}
+	hash := hashToken(token)
+
+	record, err := s.tokens.GetByHash(ctx, hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrTokenNotFound
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if record.UsedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, ErrTokenGone
+	}
+
+	// MarkUsed is the single-use guard: only one concurrent redemption gets past it
+	if err := s.tokens.MarkUsed(ctx, hash, now); err != nil {
+		switch {
+		case errors.Is(err, ports.ErrNotFound):
+			return nil, ErrTokenGone
+		case errors.Is(err, ports.ErrUnavailable):
+			return nil, ErrUnavailable
+		}
+		return nil, err
+	}
+
+	user, err := s.GetUser(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+		if err := s.repo.Update(ctx, user); err != nil {
+			switch {
+			case errors.Is(err, ports.ErrNotFound):
+				return nil, ErrUserNotFound
+			case errors.Is(err, ports.ErrVersionConflict):
+				return nil, ErrVersionConflict
+			case errors.Is(err, ports.ErrUnavailable):
+				return nil, ErrUnavailable
+			}
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// newToken returns a random URL-safe token and the hash under which it is stored
+func newToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+// Package validation holds input checks shared by the core services
+package validation
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Email limits from RFC 5321; the whole address is limited by the 256-octet path less its angle brackets
+const (
+	MaxEmailLength     = 254
+	MaxEmailLocalPart  = 64
+	maxEmailLabelBytes = 63
+)
+
+// Email validation errors. Their messages complete a sentence that starts with
+// the field name, so services can report them per field as they are.
+var (
+	ErrEmailRequired = errors.New("is required")
+	ErrEmailTooLong  = errors.New("must be at most 254 characters")
+	ErrEmailInvalid  = errors.New("must be a valid address")
+)
+
+// NormalizeEmail returns the form emails are stored and looked up in: without
+// surrounding whitespace and lower-cased, so addresses differing only in case match
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks that email is a bare address such as user@example.com.
+// It must parse with net/mail and contain nothing else: no display name, angle
+// brackets or surrounding whitespace, and exactly one @. The domain, compared in
+// lower case, needs at least two dot-separated labels of letters, digits and
+// inner hyphens, without a trailing dot; internationalised labels are accepted.
+func ValidateEmail(email string) error {
+	if email == "" {
+		return ErrEmailRequired
+	}
+	if len(email) > MaxEmailLength {
+		return ErrEmailTooLong
+	}
+	if strings.Count(email, "@") != 1 {
+		return ErrEmailInvalid
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return ErrEmailInvalid
+	}
+
+	local, domain, _ := strings.Cut(email, "@")
+	if len(local) > MaxEmailLocalPart || !validDomain(strings.ToLower(domain)) {
+		return ErrEmailInvalid
+	}
+	return nil
+}
+
+func validDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > maxEmailLabelBytes || !utf8.ValidString(label) {
+			return false
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if c != '-' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
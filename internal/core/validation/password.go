@@ -0,0 +1,69 @@
+package validation
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultPasswordMinLength is used when a PasswordPolicy sets no MinLength
+const DefaultPasswordMinLength = 8
+
+// MaxPasswordBytes is the most bcrypt will hash; longer passwords are rejected
+// rather than silently truncated
+const MaxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// CommonPasswords returns the embedded list of frequently used passwords, lower-cased
+func CommonPasswords() []string {
+	return strings.Fields(strings.ToLower(commonPasswordsFile))
+}
+
+// PasswordPolicy is the set of rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength     int // in characters; DefaultPasswordMinLength when zero
+	RequireDigit  bool
+	RequireSymbol bool // any character that is not a letter, digit or space
+	// DenyList holds lower-cased passwords that are refused whatever their case
+	DenyList []string
+}
+
+// Validate returns every rule password breaks, each phrased to follow the field
+// name, or nil when it satisfies the policy
+func (p PasswordPolicy) Validate(password string) []string {
+	if password == "" {
+		return []string{"is required"}
+	}
+
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+
+	var violations []string
+	if utf8.RuneCountInString(password) < minLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", minLength))
+	}
+	if len(password) > MaxPasswordBytes {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes", MaxPasswordBytes))
+	}
+	if p.RequireDigit && !strings.ContainsFunc(password, unicode.IsDigit) {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !strings.ContainsFunc(password, isSymbol) {
+		violations = append(violations, "must contain a symbol")
+	}
+	if slices.Contains(p.DenyList, strings.ToLower(password)) {
+		violations = append(violations, "is too common")
+	}
+	return violations
+}
+
+func isSymbol(c rune) bool {
+	return !unicode.IsLetter(c) && !unicode.IsDigit(c) && !unicode.IsSpace(c)
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  error
+	}{
+		{"user@example.com", nil},
+		{"first.last+tag@sub.example.co", nil},
+		{"user@bücher.de", nil},
+		{"", ErrEmailRequired},
+		{strings.Repeat("a", 250) + "@x.io", ErrEmailTooLong},
+		{"user", ErrEmailInvalid},
+		{"a@b@example.com", ErrEmailInvalid},
+		{"User <user@example.com>", ErrEmailInvalid},
+		{" user@example.com", ErrEmailInvalid},
+		{"user@localhost", ErrEmailInvalid},
+		{"user@example.com.", ErrEmailInvalid},
+		{"user@-example.com", ErrEmailInvalid},
+		{strings.Repeat("a", 65) + "@example.com", ErrEmailInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if err := ValidateEmail(tt.email); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateEmail(%q) = %v, want %v", tt.email, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("  User@Example.COM "); got != "user@example.com" {
+		t.Errorf("NormalizeEmail() = %q", got)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		want     int // number of violations
+	}{
+		{name: "default length met", password: "abcdefgh"},
+		{name: "default length short", password: "abcdefg", want: 1},
+		{name: "empty", password: "", want: 1},
+		{name: "custom length", policy: PasswordPolicy{MinLength: 12}, password: "abcdefghijk", want: 1},
+		{name: "length counts characters", policy: PasswordPolicy{MinLength: 4}, password: "éééé"},
+		{name: "too long for bcrypt", password: strings.Repeat("a", MaxPasswordBytes+1), want: 1},
+		{name: "digit required", policy: PasswordPolicy{RequireDigit: true}, password: "abcdefgh", want: 1},
+		{name: "symbol required", policy: PasswordPolicy{RequireSymbol: true}, password: "abcd efgh", want: 1},
+		{name: "symbol present", policy: PasswordPolicy{RequireSymbol: true}, password: "abcd!efgh"},
+		{name: "denied in any case", policy: PasswordPolicy{DenyList: []string{"password1"}}, password: "PassWord1", want: 1},
+		{name: "every violation", policy: PasswordPolicy{MinLength: 10, RequireDigit: true, RequireSymbol: true}, password: "abc", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Validate(tt.password); len(got) != tt.want {
+				t.Errorf("Validate(%q) = %v, want %d violations", tt.password, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package factory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestUserCreate(t *testing.T) {
+	tests := []struct {
+		name         string
+		builder      *UserBuilder
+		wantVerified bool
+		wantDeleted  bool
+	}{
+		{name: "default", builder: User()},
+		{name: "verified admin", builder: User().WithRole(domain.RoleAdmin).Verified(), wantVerified: true},
+		{name: "deleted", builder: User().Verified().Deleted(), wantVerified: true, wantDeleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testutil.NewUserRepository()
+			user := tt.builder.Create(t, repo)
+
+			stored, ok := repo.Stored(user.ID)
+			if !ok {
+				t.Fatalf("user %s was not stored", user.ID)
+			}
+			if stored.Email != user.Email || stored.Role != user.Role || stored.Password != "hashed:"+Password {
+				t.Errorf("stored = %+v, want %+v", stored, user)
+			}
+			if got := stored.EmailVerifiedAt != nil; got != tt.wantVerified {
+				t.Errorf("verified = %v, want %v", got, tt.wantVerified)
+			}
+			_, err := repo.GetByID(context.Background(), user.ID)
+			if got := err != nil; got != tt.wantDeleted {
+				t.Errorf("GetByID() error = %v, want deleted %v", err, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestUserBuildCopiesPointers(t *testing.T) {
+	builder := User().Verified()
+	first, second := builder.Build(), builder.Build()
+	*first.EmailVerifiedAt = first.EmailVerifiedAt.AddDate(1, 0, 0)
+	if !second.EmailVerifiedAt.Equal(Epoch) {
+		t.Errorf("second EmailVerifiedAt = %v, want %v", second.EmailVerifiedAt, Epoch)
+	}
+}
+
+func TestEmailsAreUnique(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				email := User().Build().Email
+				mu.Lock()
+				if seen[email] {
+					t.Errorf("email %s handed out twice", email)
+				}
+				seen[email] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
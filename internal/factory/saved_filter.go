@@ -0,0 +1,74 @@
+package factory
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// SavedFilterBuilder builds a domain.SavedFilter. Its zero configuration is a private
+// users filter with a unique ID and name and no parameters.
+type SavedFilterBuilder struct {
+	filter domain.SavedFilter
+}
+
+// SavedFilter starts building a saved filter owned by ownerID
+func SavedFilter(ownerID string) *SavedFilterBuilder {
+	id := IDs{Prefix: "filter"}.NewID()
+	return &SavedFilterBuilder{filter: domain.SavedFilter{
+		ID:        id,
+		OwnerID:   ownerID,
+		Name:      "Filter " + id,
+		Resource:  domain.SavedFilterUsers,
+		Params:    map[string]string{},
+		CreatedAt: Epoch,
+		UpdatedAt: Epoch,
+	}}
+}
+
+func (b *SavedFilterBuilder) WithID(id string) *SavedFilterBuilder {
+	b.filter.ID = id
+	return b
+}
+
+func (b *SavedFilterBuilder) WithName(name string) *SavedFilterBuilder {
+	b.filter.Name = name
+	return b
+}
+
+func (b *SavedFilterBuilder) WithResource(resource string) *SavedFilterBuilder {
+	b.filter.Resource = resource
+	return b
+}
+
+// WithParam adds a query parameter to the filter
+func (b *SavedFilterBuilder) WithParam(name, value string) *SavedFilterBuilder {
+	b.filter.Params[name] = value
+	return b
+}
+
+func (b *SavedFilterBuilder) Shared() *SavedFilterBuilder {
+	b.filter.Shared = true
+	return b
+}
+
+// Build returns a new saved filter with its own copy of the parameters
+func (b *SavedFilterBuilder) Build() *domain.SavedFilter {
+	filter := b.filter
+	filter.Params = maps.Clone(b.filter.Params)
+	return &filter
+}
+
+// Create builds the filter and stores it through repo, failing t if it can't.
+// The owner must already exist.
+func (b *SavedFilterBuilder) Create(t testing.TB, repo ports.SavedFilterRepository) *domain.SavedFilter {
+	t.Helper()
+	filter := b.Build()
+	if err := repo.Create(context.Background(), filter); err != nil {
+		t.Fatalf("factory: create saved filter %q: %v", filter.Name, err)
+	}
+	return filter
+}
@@ -0,0 +1,144 @@
+package factory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/testutil"
+)
+
+// UserBuilder builds a domain.User. Its zero configuration is an active, unverified
+// user with a unique ID and email, the password Password, and the user role.
+type UserBuilder struct {
+	user     domain.User
+	password string
+	hash     *string
+}
+
+// User starts building a user
+func User() *UserBuilder {
+	return &UserBuilder{
+		user: domain.User{
+			ID:        IDs{Prefix: "user"}.NewID(),
+			Email:     Email(),
+			Role:      domain.RoleUser,
+			Version:   1,
+			CreatedAt: Epoch,
+			UpdatedAt: Epoch,
+		},
+		password: Password,
+	}
+}
+
+func (b *UserBuilder) WithID(id string) *UserBuilder {
+	b.user.ID = id
+	return b
+}
+
+func (b *UserBuilder) WithEmail(email string) *UserBuilder {
+	b.user.Email = email
+	return b
+}
+
+func (b *UserBuilder) WithRole(role string) *UserBuilder {
+	b.user.Role = role
+	return b
+}
+
+// WithPassword sets the plaintext password, stored hashed as testutil.PasswordHasher hashes it
+func (b *UserBuilder) WithPassword(password string) *UserBuilder {
+	b.password, b.hash = password, nil
+	return b
+}
+
+// WithPasswordHash stores hash as the password exactly, such as a bcrypt hash or a legacy plaintext one
+func (b *UserBuilder) WithPasswordHash(hash string) *UserBuilder {
+	b.hash = &hash
+	return b
+}
+
+// Verified marks the email verified at Epoch
+func (b *UserBuilder) Verified() *UserBuilder {
+	return b.VerifiedAt(Epoch)
+}
+
+func (b *UserBuilder) VerifiedAt(at time.Time) *UserBuilder {
+	b.user.EmailVerifiedAt = &at
+	return b
+}
+
+func (b *UserBuilder) WithAvatar(location string) *UserBuilder {
+	b.user.AvatarURL = &location
+	return b
+}
+
+// Deleted soft-deletes the user at Epoch
+func (b *UserBuilder) Deleted() *UserBuilder {
+	at := Epoch
+	b.user.DeletedAt = &at
+	return b
+}
+
+func (b *UserBuilder) CreatedAt(at time.Time) *UserBuilder {
+	b.user.CreatedAt, b.user.UpdatedAt = at, at
+	return b
+}
+
+func (b *UserBuilder) WithVersion(version int) *UserBuilder {
+	b.user.Version = version
+	return b
+}
+
+// Build returns a new user; a builder can build several, which share every field
+func (b *UserBuilder) Build() *domain.User {
+	user := b.user
+	if b.hash != nil {
+		user.Password = *b.hash
+	} else {
+		user.Password, _ = testutil.PasswordHasher{}.Hash(b.password)
+	}
+	if user.EmailVerifiedAt != nil {
+		at := *user.EmailVerifiedAt
+		user.EmailVerifiedAt = &at
+	}
+	if user.AvatarURL != nil {
+		location := *user.AvatarURL
+		user.AvatarURL = &location
+	}
+	if user.DeletedAt != nil {
+		at := *user.DeletedAt
+		user.DeletedAt = &at
+	}
+	return &user
+}
+
+// Create builds the user and stores it through repo, a real repository or a fake,
+// failing t if it can't. Create only writes the account itself, so verification and
+// the avatar are written with Update, and deletion with Delete. Timestamps and the
+// version are whatever repo sets.
+func (b *UserBuilder) Create(t testing.TB, repo ports.UserRepository) *domain.User {
+	t.Helper()
+	ctx := context.Background()
+	user := b.Build()
+	deletedAt := user.DeletedAt
+	user.DeletedAt = nil
+
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("factory: create user %s: %v", user.Email, err)
+	}
+	if user.EmailVerifiedAt != nil || user.AvatarURL != nil {
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("factory: update user %s: %v", user.Email, err)
+		}
+	}
+	if deletedAt != nil {
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("factory: delete user %s: %v", user.Email, err)
+		}
+		user.DeletedAt = deletedAt
+	}
+	return user
+}
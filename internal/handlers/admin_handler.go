@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
+	"example.com/monolithic/internal/platform/database"
+)
+
+// AdminConfig holds the runtime controls the admin endpoints expose
+type AdminConfig struct {
+	// Routes is the application router, which runtime settings naming routes are checked against
+	Routes     chi.Routes
+	LogSampler *middleware.LogSampler
+	// DB is the database the explain and db-stats routes inspect; they are served only when it is set
+	DB *database.DB
+	// ClientVersions is the app version gate; its routes are served only when it is set
+	ClientVersions *middleware.ClientVersionGate
+	// Mirror is the request mirror in front of the user routes; its routes are served only when it is set
+	Mirror *middleware.Mirror
+	// ShadowUsers controls shadowing of the user repository; its routes are served only when it is set
+	ShadowUsers ports.ShadowControl
+	// Events is the analytics event pipeline; its route is served only when it is set
+	Events *services.EventService
+	// SavedFilters serves saved list filters and the user listing that applies them; its
+	// routes are served only when it is set
+	SavedFilters *SavedFilterHandler
+}
+
+// AdminHandler serves operational endpoints for inspecting and tuning a running server
+type AdminHandler struct {
+	cfg AdminConfig
+}
+
+func NewAdminHandler(cfg AdminConfig) *AdminHandler {
+	return &AdminHandler{cfg: cfg}
+}
+
+// Routes sets up the admin routes; every one requires the admin role
+func (h *AdminHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(middleware.RequireRole(domain.RoleAdmin))
+	r.Get("/log-sampling", h.getLogSampling) // GET /api/admin/log-sampling
+	r.Put("/log-sampling", h.setLogSampling) // PUT /api/admin/log-sampling
+	if h.cfg.DB != nil {
+		r.Get("/db-stats", h.getDatabaseStats) // GET /api/admin/db-stats
+		r.Get("/explain", h.getExplain)        // GET /api/admin/explain
+		r.Put("/explain", h.setExplain)        // PUT /api/admin/explain
+		r.Delete("/explain", h.disableExplain) // DELETE /api/admin/explain
+	}
+	if h.cfg.ClientVersions != nil {
+		r.Get("/client-versions", h.getClientVersions) // GET /api/admin/client-versions
+		r.Put("/client-versions", h.setClientVersions) // PUT /api/admin/client-versions
+	}
+	if h.cfg.Mirror != nil {
+		r.Get("/mirror", h.getMirror) // GET /api/admin/mirror
+		r.Put("/mirror", h.setMirror) // PUT /api/admin/mirror
+	}
+	if h.cfg.ShadowUsers != nil {
+		r.Get("/shadow/users", h.getShadowUsers) // GET /api/admin/shadow/users
+		r.Put("/shadow/users", h.setShadowUsers) // PUT /api/admin/shadow/users
+	}
+	if h.cfg.Events != nil {
+		r.Get("/events", h.getEvents) // GET /api/admin/events
+	}
+	if h.cfg.SavedFilters != nil {
+		r.Mount("/saved-filters", h.cfg.SavedFilters.Routes()) // /api/admin/saved-filters
+		r.Get("/users", h.cfg.SavedFilters.listUsers)          // GET /api/admin/users?filter_id=...
+	}
+	return r
+}
+
+// GetLogSampling handles reporting the sampling rates and how many requests each route logged
+func (h *AdminHandler) getLogSampling(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, h.logSampling())
+}
+
+// SetLogSampling handles replacing the sampling rates; every pattern must name a registered route
+func (h *AdminHandler) setLogSampling(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req LogSamplingRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	if err := middleware.CheckRates(h.cfg.Routes, req.Rates); err != nil {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, err.Error())
+		return
+	}
+
+	h.cfg.LogSampler.SetRates(req.Rates)
+	respond.JSON(w, r, http.StatusOK, h.logSampling())
+}
+
+func (h *AdminHandler) logSampling() LogSamplingResponse {
+	seen, logged := h.cfg.LogSampler.Counts()
+	return LogSamplingResponse{Rates: h.cfg.LogSampler.Rates(), Seen: seen, Logged: logged}
+}
+
+// GetClientVersions handles reporting the minimum supported app builds and the versions seen
+func (h *AdminHandler) getClientVersions(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, h.clientVersions())
+}
+
+// SetClientVersions handles replacing the minimum supported app builds
+func (h *AdminHandler) setClientVersions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req ClientVersionsRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	if err := h.cfg.ClientVersions.SetMinimums(req.Minimums); err != nil {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, err.Error())
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, h.clientVersions())
+}
+
+func (h *AdminHandler) clientVersions() ClientVersionsResponse {
+	return ClientVersionsResponse{Minimums: h.cfg.ClientVersions.Minimums(), Histogram: h.cfg.ClientVersions.Histogram()}
+}
+
+// GetDatabaseStats handles reporting connection pool backpressure and the breaker's state
+func (h *AdminHandler) getDatabaseStats(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newDatabaseStatsResponse(h.cfg.DB.Stats()))
+}
+
+// GetExplain handles reporting the query plan sampling settings
+func (h *AdminHandler) getExplain(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newExplainResponse(h.cfg.DB.ExplainConfig()))
+}
+
+// SetExplain handles enabling query plan sampling, replacing any earlier settings
+func (h *AdminHandler) setExplain(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req ExplainRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	cfg := database.ExplainConfig{
+		SampleRate:      req.SampleRate,
+		SlowThreshold:   time.Duration(req.SlowThresholdMS) * time.Millisecond,
+		Analyze:         req.Analyze,
+		BudgetPerMinute: req.BudgetPerMinute,
+	}
+	if err := cfg.Validate(); err != nil {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, err.Error())
+		return
+	}
+
+	h.cfg.DB.SetExplainConfig(&cfg)
+	respond.JSON(w, r, http.StatusOK, newExplainResponse(&cfg))
+}
+
+// DisableExplain handles turning query plan sampling off
+func (h *AdminHandler) disableExplain(w http.ResponseWriter, r *http.Request) {
+	h.cfg.DB.SetExplainConfig(nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMirror handles reporting the request mirroring share and counters
+func (h *AdminHandler) getMirror(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newMirrorResponse(h.cfg.Mirror))
+}
+
+// SetMirror handles changing the share of requests mirrored
+func (h *AdminHandler) setMirror(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req PercentRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	if !validPercent(w, r, req.Percent) {
+		return
+	}
+
+	h.cfg.Mirror.SetPercent(*req.Percent)
+	respond.JSON(w, r, http.StatusOK, newMirrorResponse(h.cfg.Mirror))
+}
+
+// GetShadowUsers handles reporting the user repository shadowing share and counters
+func (h *AdminHandler) getShadowUsers(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newShadowResponse(h.cfg.ShadowUsers))
+}
+
+// SetShadowUsers handles changing the share of user repository calls shadowed
+func (h *AdminHandler) setShadowUsers(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req PercentRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	if !validPercent(w, r, req.Percent) {
+		return
+	}
+
+	h.cfg.ShadowUsers.SetPercent(*req.Percent)
+	respond.JSON(w, r, http.StatusOK, newShadowResponse(h.cfg.ShadowUsers))
+}
+
+// GetEvents handles reporting the analytics event buffer and how many events were dropped
+func (h *AdminHandler) getEvents(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newEventStatsResponse(h.cfg.Events))
+}
+
+// validPercent writes 400 and reports false unless percent is set and within 0–100
+func validPercent(w http.ResponseWriter, r *http.Request, percent *int) bool {
+	if percent == nil || *percent < 0 || *percent > 100 {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "percent must be between 0 and 100")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
+	"example.com/monolithic/internal/platform/database"
+	"example.com/monolithic/internal/repositories"
+	"example.com/monolithic/internal/testutil"
+)
+
+// newAdminServer mounts AdminHandler.Routes under /api/admin next to a mounted events router
+func newAdminServer(t *testing.T, cfg AdminConfig) http.Handler {
+	t.Helper()
+	r := chi.NewRouter()
+	cfg.Routes = r
+	r.Route("/api", func(r chi.Router) {
+		events := chi.NewRouter()
+		events.Post("/", func(w http.ResponseWriter, r *http.Request) {})
+		r.Mount("/events", events)
+		r.Mount("/admin", NewAdminHandler(cfg).Routes())
+	})
+	return r
+}
+
+func adminRequest(t *testing.T, router http.Handler, claims *ports.AccessClaims, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if claims != nil {
+		req = req.WithContext(middleware.WithClaims(req.Context(), *claims))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLogSamplingRoutes(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    *ports.AccessClaims
+		method    string
+		body      string
+		status    int
+		wantCode  string
+		wantRates map[string]int
+	}{
+		{name: "get", claims: asAdmin, method: http.MethodGet, status: http.StatusOK, wantRates: map[string]int{"/api/events": 100}},
+		{name: "set", claims: asAdmin, method: http.MethodPut, body: `{"rates":{"/api/events":10}}`, status: http.StatusOK, wantRates: map[string]int{"/api/events": 10}},
+		{name: "set unknown route", claims: asAdmin, method: http.MethodPut, body: `{"rates":{"/api/events/":10}}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantRates: map[string]int{"/api/events": 100}},
+		{name: "set rate below one", claims: asAdmin, method: http.MethodPut, body: `{"rates":{"/api/events":0}}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantRates: map[string]int{"/api/events": 100}},
+		{name: "as user", claims: asAlice, method: http.MethodPut, body: `{"rates":{"/api/events":1}}`, status: http.StatusForbidden, wantCode: respond.CodeForbidden, wantRates: map[string]int{"/api/events": 100}},
+		{name: "unauthenticated", method: http.MethodGet, status: http.StatusUnauthorized, wantCode: respond.CodeUnauthorized, wantRates: map[string]int{"/api/events": 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := middleware.NewLogSampler(0, map[string]int{"/api/events": 100})
+			router := newAdminServer(t, AdminConfig{LogSampler: sampler})
+
+			rec := adminRequest(t, router, tt.claims, tt.method, "/api/admin/log-sampling", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s = %d, want %d; body: %s", tt.method, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			} else {
+				var body struct {
+					Data LogSamplingResponse `json:"data"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if !maps.Equal(body.Data.Rates, tt.wantRates) {
+					t.Errorf("response rates = %v, want %v", body.Data.Rates, tt.wantRates)
+				}
+			}
+			if rates := sampler.Rates(); !maps.Equal(rates, tt.wantRates) {
+				t.Errorf("sampler rates = %v, want %v", rates, tt.wantRates)
+			}
+		})
+	}
+}
+
+func TestClientVersionsRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *ports.AccessClaims
+		method     string
+		body       string
+		status     int
+		wantCode   string
+		wantIOSMin string
+	}{
+		{name: "get", claims: asAdmin, method: http.MethodGet, status: http.StatusOK, wantIOSMin: "2.0.0"},
+		{name: "set", claims: asAdmin, method: http.MethodPut, body: `{"minimums":{"ios":{"version":"2.1.0","upgrade_url":"https://example.com/ios"}}}`, status: http.StatusOK, wantIOSMin: "2.1.0"},
+		{name: "set invalid version", claims: asAdmin, method: http.MethodPut, body: `{"minimums":{"ios":{"version":"two"}}}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantIOSMin: "2.0.0"},
+		{name: "as user", claims: asAlice, method: http.MethodGet, status: http.StatusForbidden, wantCode: respond.CodeForbidden, wantIOSMin: "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate, err := middleware.NewClientVersionGate(middleware.ClientVersionConfig{Minimums: map[string]middleware.ClientMinimum{
+				"ios": {Version: "2.0.0", UpgradeURL: "https://example.com/ios"},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), ClientVersions: gate})
+
+			rec := adminRequest(t, router, tt.claims, tt.method, "/api/admin/client-versions", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s = %d, want %d; body: %s", tt.method, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			} else {
+				var body struct {
+					Data ClientVersionsResponse `json:"data"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if body.Data.Minimums["ios"].Version != tt.wantIOSMin || body.Data.Histogram == nil {
+					t.Errorf("body = %s", rec.Body)
+				}
+			}
+			if min := gate.Minimums()["ios"].Version; min != tt.wantIOSMin {
+				t.Errorf("ios minimum = %q, want %q", min, tt.wantIOSMin)
+			}
+		})
+	}
+}
+
+func TestExplainRoutes(t *testing.T) {
+	enabled := &database.ExplainConfig{SampleRate: 0.5, BudgetPerMinute: 5}
+
+	tests := []struct {
+		name     string
+		claims   *ports.AccessClaims
+		method   string
+		body     string
+		status   int
+		wantCode string
+		want     *database.ExplainConfig
+	}{
+		{name: "get", claims: asAdmin, method: http.MethodGet, status: http.StatusOK, want: enabled},
+		{name: "set", claims: asAdmin, method: http.MethodPut, body: `{"slow_threshold_ms":250,"analyze":true,"budget_per_minute":2}`, status: http.StatusOK,
+			want: &database.ExplainConfig{SlowThreshold: 250 * time.Millisecond, Analyze: true, BudgetPerMinute: 2}},
+		{name: "set without budget", claims: asAdmin, method: http.MethodPut, body: `{"sample_rate":0.1}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, want: enabled},
+		{name: "set sample rate above one", claims: asAdmin, method: http.MethodPut, body: `{"sample_rate":5,"budget_per_minute":2}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, want: enabled},
+		{name: "disable", claims: asAdmin, method: http.MethodDelete, status: http.StatusNoContent, want: nil},
+		{name: "disable as user", claims: asAlice, method: http.MethodDelete, status: http.StatusForbidden, wantCode: respond.CodeForbidden, want: enabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &database.DB{}
+			db.SetExplainConfig(enabled)
+			router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), DB: db})
+
+			rec := adminRequest(t, router, tt.claims, tt.method, "/api/admin/explain", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s = %d, want %d; body: %s", tt.method, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if got := db.ExplainConfig(); (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("explain config = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		claims      *ports.AccessClaims
+		method      string
+		body        string
+		status      int
+		wantCode    string
+		wantPercent int
+	}{
+		{name: "get", claims: asAdmin, method: http.MethodGet, status: http.StatusOK, wantPercent: 5},
+		{name: "set", claims: asAdmin, method: http.MethodPut, body: `{"percent":50}`, status: http.StatusOK, wantPercent: 50},
+		{name: "turn off", claims: asAdmin, method: http.MethodPut, body: `{"percent":0}`, status: http.StatusOK, wantPercent: 0},
+		{name: "set without percent", claims: asAdmin, method: http.MethodPut, body: `{}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantPercent: 5},
+		{name: "set above 100", claims: asAdmin, method: http.MethodPut, body: `{"percent":101}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantPercent: 5},
+		{name: "as user", claims: asAlice, method: http.MethodPut, body: `{"percent":50}`, status: http.StatusForbidden, wantCode: respond.CodeForbidden, wantPercent: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirror := middleware.NewMirror(middleware.MirrorConfig{Handler: http.NotFoundHandler(), Percent: 5})
+			router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), Mirror: mirror})
+
+			rec := adminRequest(t, router, tt.claims, tt.method, "/api/admin/mirror", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s = %d, want %d; body: %s", tt.method, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if mirror.Percent() != tt.wantPercent {
+				t.Errorf("percent = %d, want %d", mirror.Percent(), tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestShadowUsersRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		claims      *ports.AccessClaims
+		method      string
+		body        string
+		status      int
+		wantCode    string
+		wantPercent int
+	}{
+		{name: "get", claims: asAdmin, method: http.MethodGet, status: http.StatusOK, wantPercent: 10},
+		{name: "set", claims: asAdmin, method: http.MethodPut, body: `{"percent":100}`, status: http.StatusOK, wantPercent: 100},
+		{name: "set negative", claims: asAdmin, method: http.MethodPut, body: `{"percent":-1}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantPercent: 10},
+		{name: "as user", claims: asBob, method: http.MethodGet, status: http.StatusForbidden, wantCode: respond.CodeForbidden, wantPercent: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shadow := repositories.NewShadow("users", 10)
+			router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), ShadowUsers: shadow})
+
+			rec := adminRequest(t, router, tt.claims, tt.method, "/api/admin/shadow/users", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s = %d, want %d; body: %s", tt.method, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if shadow.Percent() != tt.wantPercent {
+				t.Errorf("percent = %d, want %d", shadow.Percent(), tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestDatabaseStatsRoute(t *testing.T) {
+	router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), DB: &database.DB{}})
+
+	rec := adminRequest(t, router, asAdmin, http.MethodGet, "/api/admin/db-stats", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var body struct {
+		Data map[string]int64 `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	for _, field := range []string{"waiting", "rejected", "circuit_trips", "retry_after_ms"} {
+		if _, ok := body.Data[field]; !ok {
+			t.Errorf("body has no %q: %s", field, rec.Body)
+		}
+	}
+
+	if rec := adminRequest(t, router, asAlice, http.MethodGet, "/api/admin/db-stats", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GET as user = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestEventsRoute(t *testing.T) {
+	events := services.NewEventService(testutil.NewEventRepository(), services.EventConfig{BufferSize: 1})
+	if _, err := events.Track([]domain.AnalyticsEvent{{Name: "page_view"}, {Name: "page_view"}}); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	router := newAdminServer(t, AdminConfig{LogSampler: middleware.NewLogSampler(0, nil), Events: events})
+
+	if rec := adminRequest(t, router, asBob, http.MethodGet, "/api/admin/events", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GET as user = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec := adminRequest(t, router, asAdmin, http.MethodGet, "/api/admin/events", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var body struct {
+		Data EventStatsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if want := (EventStatsResponse{Buffered: 1, Capacity: 1, Dropped: 1}); body.Data != want {
+		t.Errorf("events = %+v, want %+v", body.Data, want)
+	}
+}
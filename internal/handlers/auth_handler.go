@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type AuthHandler struct {
+	service    *services.AuthService
+	retryAfter RetryAfter
+}
+
+func NewAuthHandler(service *services.AuthService, retryAfter RetryAfter) *AuthHandler {
+	return &AuthHandler{
+		service:    service,
+		retryAfter: retryAfter,
+	}
+}
+
+// Routes sets up the authentication routes
+func (h *AuthHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/login", h.login)     // POST /api/auth/login
+	r.Post("/refresh", h.refresh) // POST /api/auth/refresh
+	return r
+}
+
+// Login handles exchanging an email and password for a token pair
+func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req LoginRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	pair, err := h.service.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidCredentials:
+			respond.Error(w, r, http.StatusUnauthorized, errorCode(err), err.Error())
+		case services.ErrEmailNotVerified, services.ErrAccountDeleted:
+			respond.Error(w, r, http.StatusForbidden, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	writeTokenPair(w, r, pair)
+}
+
+// Refresh handles exchanging a refresh token for a new token pair
+func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req RefreshRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	pair, err := h.service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRefreshToken, services.ErrRefreshTokenReused:
+			// A reused token is reported like any invalid one, so callers learn nothing about the family
+			respond.Error(w, r, http.StatusUnauthorized, errorCode(err), services.ErrInvalidRefreshToken.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	writeTokenPair(w, r, pair)
+}
+
+func writeTokenPair(w http.ResponseWriter, r *http.Request, pair *services.TokenPair) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, TokenResponse{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(time.Until(pair.AccessExpiresAt).Seconds()),
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+// authServer serves AuthHandler.Routes under /api/auth, backed by in-memory fakes
+type authServer struct {
+	router  http.Handler
+	refresh *testutil.RefreshTokenRepository
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+	users := testutil.NewUserRepository(
+		factory.User().WithID("alice").WithEmail("alice@example.com").WithPassword("alice password").Verified().Build(),
+		factory.User().WithID("bob").WithEmail("bob@example.com").WithPassword("bob password").Build(),
+	)
+	audits := testutil.NewAuditRepository()
+	userService := services.NewUserService(users, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		audits, testutil.NewTransactor(users, audits), testutil.PasswordHasher{}, &testutil.IDGenerator{},
+		&testutil.EmailSender{}, testutil.NewFileStorage(), services.UserConfig{})
+
+	s := &authServer{refresh: testutil.NewRefreshTokenRepository()}
+	service := services.NewAuthService(userService, s.refresh, &testutil.AccessTokens{}, services.AuthConfig{RefreshTTL: time.Hour})
+	r := chi.NewRouter()
+	r.Mount("/api/auth", NewAuthHandler(service, nil).Routes())
+	s.router = r
+	return s
+}
+
+func (s *authServer) post(t *testing.T, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// refreshWith posts token to the refresh endpoint
+func (s *authServer) refreshWith(t *testing.T, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	return s.post(t, "/api/auth/refresh", `{"refresh_token":"`+token+`"}`)
+}
+
+// tokens decodes the token pair from a successful login or refresh
+func tokens(t *testing.T, rec *httptest.ResponseRecorder) TokenResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var pair TokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil {
+		t.Fatalf("decode tokens: %v", err)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("token pair = %+v, want both tokens", pair)
+	}
+	return pair
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		wantCode string
+	}{
+		{name: "valid", body: `{"email":"Alice@Example.com","password":"alice password"}`, status: http.StatusOK},
+		{name: "wrong password", body: `{"email":"alice@example.com","password":"guess"}`, status: http.StatusUnauthorized, wantCode: CodeInvalidCredentials},
+		{name: "unknown email", body: `{"email":"carol@example.com","password":"guess"}`, status: http.StatusUnauthorized, wantCode: CodeInvalidCredentials},
+		{name: "unverified", body: `{"email":"bob@example.com","password":"bob password"}`, status: http.StatusForbidden, wantCode: CodeEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAuthServer(t)
+			rec := s.post(t, "/api/auth/login", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			if rec.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	s := newAuthServer(t)
+	first := tokens(t, s.post(t, "/api/auth/login", `{"email":"alice@example.com","password":"alice password"}`))
+
+	second := tokens(t, s.refreshWith(t, first.RefreshToken))
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatalf("refresh returned the same tokens: %+v", second)
+	}
+	third := tokens(t, s.refreshWith(t, second.RefreshToken))
+
+	// The rotated tokens are spent
+	for _, spent := range []string{first.RefreshToken, second.RefreshToken} {
+		if rec := s.refreshWith(t, spent); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("refresh with a rotated token = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	}
+	// and presenting them revoked the whole family, the newest token included
+	if rec := s.refreshWith(t, third.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with the newest token after reuse = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefreshReuseLooksLikeAnyInvalidToken(t *testing.T) {
+	s := newAuthServer(t)
+	first := tokens(t, s.post(t, "/api/auth/login", `{"email":"alice@example.com","password":"alice password"}`))
+	next := tokens(t, s.refreshWith(t, first.RefreshToken))
+
+	reused := s.refreshWith(t, first.RefreshToken)
+	unknown := s.refreshWith(t, "not-a-token")
+	for name, rec := range map[string]*httptest.ResponseRecorder{"reused": reused, "unknown": unknown} {
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s token status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+		if code := errorCodeOf(t, rec); code != CodeInvalidRefreshToken {
+			t.Errorf("%s token error code = %q, want %q", name, code, CodeInvalidRefreshToken)
+		}
+	}
+	if reused.Body.String() != unknown.Body.String() {
+		t.Errorf("reused token body %s differs from an unknown token's %s", reused.Body, unknown.Body)
+	}
+
+	// Every token the login issued is now revoked
+	if active := s.refresh.Active(); len(active) != 0 {
+		t.Errorf("active tokens after reuse = %+v, want none", active)
+	}
+	rec := s.refreshWith(t, next.RefreshToken)
+	if rec.Body.String() != unknown.Body.String() {
+		t.Errorf("newest token after reuse = %d %s, want the invalid token response", rec.Code, rec.Body)
+	}
+}
+
+func TestRefreshRequiresToken(t *testing.T) {
+	s := newAuthServer(t)
+	rec := s.post(t, "/api/auth/refresh", `{}`)
+	if rec.Code != http.StatusUnauthorized || errorCodeOf(t, rec) != CodeInvalidRefreshToken {
+		t.Errorf("refresh without a token = %d %s, want 401 %s", rec.Code, rec.Body, CodeInvalidRefreshToken)
+	}
+}
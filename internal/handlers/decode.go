@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// BodyPolicy declares whether an endpoint expects a request body
+type BodyPolicy int
+
+const (
+	BodyRequired BodyPolicy = iota
+	BodyOptional
+	BodyForbidden
+)
+
+// Request body error codes
+const (
+	CodeEmptyBody            = "EMPTY_BODY"
+	CodeBodyNotAllowed       = "BODY_NOT_ALLOWED"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodeBodyTooLarge         = "BODY_TOO_LARGE"
+	CodeInvalidBody          = "INVALID_BODY"
+)
+
+// decodeJSON applies the endpoint's body policy and decodes a JSON body into dst.
+// It writes the error response itself and returns false when the handler should stop.
+// Numbers are decoded as json.Number when dst holds interface{} values.
+func decodeJSON(w http.ResponseWriter, r *http.Request, policy BodyPolicy, dst interface{}) bool {
+	body := bufio.NewReader(r.Body)
+	_, err := body.Peek(1)
+	empty := errors.Is(err, io.EOF)
+
+	switch {
+	case empty && policy == BodyRequired:
+		writeBodyError(w, r, http.StatusBadRequest, CodeEmptyBody, "Request body is required")
+		return false
+	case empty:
+		return true
+	case policy == BodyForbidden:
+		writeBodyError(w, r, http.StatusBadRequest, CodeBodyNotAllowed, "Request body is not allowed")
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeBodyError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	if err := decoder.Decode(dst); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeBodyError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+			return false
+		}
+		writeBodyError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return false
+	}
+
+	return true
+}
+
+func writeBodyError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	respond.Error(w, r, status, code, message)
+}
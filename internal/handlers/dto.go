@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"net/url"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
+	"example.com/monolithic/internal/platform/database"
+	"example.com/monolithic/internal/platform/lifecycle"
+)
+
+// CreateUserRequest is the body accepted when creating a user
+type CreateUserRequest struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (req *CreateUserRequest) toDomain() *domain.User {
+	return &domain.User{
+		ID:       req.ID,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+}
+
+// UpdateUserRequest is the body accepted when updating a user
+type UpdateUserRequest struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// ChangePasswordRequest is the body accepted when changing a user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// SetRoleRequest is the body accepted when assigning a user's role
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// PasswordResetRequest is the body accepted when asking for a password reset email
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// ConfirmPasswordResetRequest is the body accepted when redeeming a password reset token
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// LoginRequest is the body accepted when logging in
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the body accepted when refreshing an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse carries the tokens issued to a client
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// UserResponse is the public representation of a user; it never carries the password
+type UserResponse struct {
+	ID              string     `json:"id" xml:"id"`
+	Email           string     `json:"email" xml:"email"`
+	Role            string     `json:"role" xml:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" xml:"email_verified_at,omitempty"`
+	AvatarURL       string     `json:"avatar_url,omitempty" xml:"avatar_url,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" xml:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" xml:"updated_at"`
+}
+
+func newUserResponse(user *domain.User) UserResponse {
+	response := UserResponse{
+		ID:              user.ID,
+		Email:           user.Email,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	if user.AvatarURL != nil {
+		// Clients fetch the image through the API, never from the storage location
+		response.AvatarURL = "/api/users/" + url.PathEscape(user.ID) + "/avatar"
+	}
+	return response
+}
+
+func newUserResponses(users []domain.User) []UserResponse {
+	responses := make([]UserResponse, len(users))
+	for i := range users {
+		responses[i] = newUserResponse(&users[i])
+	}
+	return responses
+}
+
+// UserListResponse is a page of users
+type UserListResponse struct {
+	Users  []UserResponse `json:"users" xml:"users>user"`
+	Limit  int            `json:"limit" xml:"limit"`
+	Offset int            `json:"offset" xml:"offset"`
+	Total  *int64         `json:"total,omitempty" xml:"total,omitempty"` // absent for email searches
+}
+
+// BulkItemResponse reports the outcome of one user in a bulk create
+type BulkItemResponse struct {
+	Index  int         `json:"index" xml:"index"`
+	ID     string      `json:"id,omitempty" xml:"id,omitempty"`
+	Error  string      `json:"error,omitempty" xml:"error,omitempty"`
+	Fields respond.Map `json:"fields,omitempty" xml:"fields,omitempty"`
+}
+
+// BulkCreateResponse is the body returned by a bulk create
+type BulkCreateResponse struct {
+	Results []BulkItemResponse `json:"results" xml:"results>result"`
+}
+
+func newBulkItemResponses(results []services.BulkItemResult) []BulkItemResponse {
+	responses := make([]BulkItemResponse, len(results))
+	for i, result := range results {
+		responses[i] = BulkItemResponse{
+			Index:  result.Index,
+			ID:     result.ID,
+			Error:  result.Error,
+			Fields: fieldsMap(result.Fields),
+		}
+	}
+	return responses
+}
+
+// ImportRowErrorResponse reports a rejected row of an import file
+type ImportRowErrorResponse struct {
+	Line   int         `json:"line" xml:"line"`
+	Error  string      `json:"error" xml:"error"`
+	Fields respond.Map `json:"fields,omitempty" xml:"fields,omitempty"`
+}
+
+// ImportRowResultResponse names the rule that decided what happened to a line
+type ImportRowResultResponse struct {
+	Line int    `json:"line" xml:"line"`
+	Rule string `json:"rule" xml:"rule"`
+}
+
+// ImportSummaryResponse reports the outcome of an import
+type ImportSummaryResponse struct {
+	Created           int                       `json:"created" xml:"created"`
+	Restored          int                       `json:"restored" xml:"restored"`
+	SkippedDuplicates int                       `json:"skipped_duplicates" xml:"skipped_duplicates"`
+	Errors            []ImportRowErrorResponse  `json:"errors" xml:"errors>row"`
+	Rows              []ImportRowResultResponse `json:"rows" xml:"rows>row"`
+}
+
+func newImportSummaryResponse(summary *services.ImportSummary) *ImportSummaryResponse {
+	if summary == nil {
+		return nil
+	}
+	response := &ImportSummaryResponse{
+		Created:           summary.Created,
+		Restored:          summary.Restored,
+		SkippedDuplicates: summary.SkippedDuplicates,
+		Errors:            make([]ImportRowErrorResponse, len(summary.Errors)),
+		Rows:              make([]ImportRowResultResponse, len(summary.Rows)),
+	}
+	for i, rowErr := range summary.Errors {
+		response.Errors[i] = ImportRowErrorResponse{Line: rowErr.Line, Error: rowErr.Error, Fields: fieldsMap(rowErr.Fields)}
+	}
+	for i, row := range summary.Rows {
+		response.Rows[i] = ImportRowResultResponse{Line: row.Line, Rule: row.Rule}
+	}
+	return response
+}
+
+func fieldsMap(fields map[string]string) respond.Map {
+	if len(fields) == 0 {
+		return nil
+	}
+	m := make(respond.Map, len(fields))
+	for field, message := range fields {
+		m[field] = message
+	}
+	return m
+}
+
+// SavedFilterRequest is the body accepted when saving or replacing a list filter
+type SavedFilterRequest struct {
+	Name     string            `json:"name"`
+	Resource string            `json:"resource"`
+	Params   map[string]string `json:"params"`
+	Shared   bool              `json:"shared"`
+}
+
+func (req *SavedFilterRequest) toDomain() *domain.SavedFilter {
+	return &domain.SavedFilter{
+		Name:     req.Name,
+		Resource: req.Resource,
+		Params:   req.Params,
+		Shared:   req.Shared,
+	}
+}
+
+// SavedFilterResponse is a saved list filter
+type SavedFilterResponse struct {
+	ID        string      `json:"id" xml:"id"`
+	OwnerID   string      `json:"owner_id" xml:"owner_id"`
+	Name      string      `json:"name" xml:"name"`
+	Resource  string      `json:"resource" xml:"resource"`
+	Params    respond.Map `json:"params" xml:"params"`
+	Shared    bool        `json:"shared" xml:"shared"`
+	CreatedAt time.Time   `json:"created_at" xml:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" xml:"updated_at"`
+}
+
+func newSavedFilterResponse(filter *domain.SavedFilter) SavedFilterResponse {
+	params := make(respond.Map, len(filter.Params))
+	for name, value := range filter.Params {
+		params[name] = value
+	}
+	return SavedFilterResponse{
+		ID:        filter.ID,
+		OwnerID:   filter.OwnerID,
+		Name:      filter.Name,
+		Resource:  filter.Resource,
+		Params:    params,
+		Shared:    filter.Shared,
+		CreatedAt: filter.CreatedAt,
+		UpdatedAt: filter.UpdatedAt,
+	}
+}
+
+// SavedFilterListResponse lists the saved filters visible to the caller
+type SavedFilterListResponse struct {
+	Filters []SavedFilterResponse `json:"filters" xml:"filters>filter"`
+}
+
+// LogSamplingRequest is the body accepted when replacing the log sampling rates
+type LogSamplingRequest struct {
+	Rates map[string]int `json:"rates"`
+}
+
+// LogSamplingResponse reports the log sampling rates and per-route request counts
+type LogSamplingResponse struct {
+	Rates  map[string]int   `json:"rates"`
+	Seen   map[string]int64 `json:"seen"`
+	Logged map[string]int64 `json:"logged"`
+}
+
+// ClientVersionsRequest is the body accepted when replacing the minimum supported app builds
+type ClientVersionsRequest struct {
+	Minimums map[string]middleware.ClientMinimum `json:"minimums"`
+}
+
+// ClientVersionsResponse reports the minimum supported app builds and the versions seen
+type ClientVersionsResponse struct {
+	Minimums  map[string]middleware.ClientMinimum `json:"minimums"`
+	Histogram map[string]int64                    `json:"histogram"`
+}
+
+// DatabaseStatsResponse reports connection pool backpressure; durations are in milliseconds
+type DatabaseStatsResponse struct {
+	TotalConns     int32 `json:"total_conns"`
+	AcquiredConns  int32 `json:"acquired_conns"`
+	Waiting        int64 `json:"waiting"`
+	Rejected       int64 `json:"rejected"`
+	CircuitTrips   int64 `json:"circuit_trips"`
+	CircuitOpenMS  int64 `json:"circuit_open_ms"`
+	AvgHoldMS      int64 `json:"avg_hold_ms"`
+	RetryAfterMS   int64 `json:"retry_after_ms"`
+	LockWaits      int64 `json:"lock_waits"`
+	LockWaitTimeMS int64 `json:"lock_wait_time_ms"`
+}
+
+func newDatabaseStatsResponse(stats database.PoolStats) DatabaseStatsResponse {
+	return DatabaseStatsResponse{
+		TotalConns:     stats.Total,
+		AcquiredConns:  stats.Acquired,
+		Waiting:        stats.Waiting,
+		Rejected:       stats.Rejected,
+		CircuitTrips:   stats.CircuitTrips,
+		CircuitOpenMS:  stats.CircuitOpen.Milliseconds(),
+		AvgHoldMS:      stats.AvgHold.Milliseconds(),
+		RetryAfterMS:   stats.RetryAfter.Milliseconds(),
+		LockWaits:      stats.LockWaits,
+		LockWaitTimeMS: stats.LockWaitTime.Milliseconds(),
+	}
+}
+
+// ExplainRequest is the body accepted when enabling query plan sampling
+type ExplainRequest struct {
+	SampleRate      float64 `json:"sample_rate"`
+	SlowThresholdMS int64   `json:"slow_threshold_ms"`
+	Analyze         bool    `json:"analyze"`
+	BudgetPerMinute int     `json:"budget_per_minute"`
+}
+
+// ExplainResponse reports the query plan sampling settings
+type ExplainResponse struct {
+	Enabled         bool    `json:"enabled"`
+	SampleRate      float64 `json:"sample_rate"`
+	SlowThresholdMS int64   `json:"slow_threshold_ms"`
+	Analyze         bool    `json:"analyze"`
+	BudgetPerMinute int     `json:"budget_per_minute"`
+}
+
+func newExplainResponse(cfg *database.ExplainConfig) ExplainResponse {
+	if cfg == nil {
+		return ExplainResponse{}
+	}
+	return ExplainResponse{
+		Enabled:         true,
+		SampleRate:      cfg.SampleRate,
+		SlowThresholdMS: cfg.SlowThreshold.Milliseconds(),
+		Analyze:         cfg.Analyze,
+		BudgetPerMinute: cfg.BudgetPerMinute,
+	}
+}
+
+// PercentRequest is the body accepted when changing the share of traffic mirrored or shadowed
+type PercentRequest struct {
+	Percent *int `json:"percent"`
+}
+
+// MirrorResponse reports the request mirroring share and counters
+type MirrorResponse struct {
+	Percent             int   `json:"percent"`
+	Mirrored            int64 `json:"mirrored"`
+	Succeeded           int64 `json:"succeeded"`
+	Failed              int64 `json:"failed"`
+	TotalLatencyDeltaMS int64 `json:"total_latency_delta_ms"`
+}
+
+func newMirrorResponse(m *middleware.Mirror) MirrorResponse {
+	stats := m.Stats()
+	return MirrorResponse{
+		Percent:             m.Percent(),
+		Mirrored:            stats.Mirrored,
+		Succeeded:           stats.Succeeded,
+		Failed:              stats.Failed,
+		TotalLatencyDeltaMS: stats.TotalLatencyDelta.Milliseconds(),
+	}
+}
+
+// ShadowResponse reports a repository shadow's share and counters
+type ShadowResponse struct {
+	Percent     int   `json:"percent"`
+	Mirrored    int64 `json:"mirrored"`
+	Failures    int64 `json:"failures"`
+	Divergences int64 `json:"divergences"`
+}
+
+func newShadowResponse(s ports.ShadowControl) ShadowResponse {
+	stats := s.Stats()
+	return ShadowResponse{
+		Percent:     s.Percent(),
+		Mirrored:    stats.Mirrored,
+		Failures:    stats.Failures,
+		Divergences: stats.Divergences,
+	}
+}
+
+// EventStatsResponse reports the analytics event buffer and how many events were dropped
+type EventStatsResponse struct {
+	Buffered int   `json:"buffered"`
+	Capacity int   `json:"capacity"`
+	Dropped  int64 `json:"dropped"`
+}
+
+func newEventStatsResponse(s *services.EventService) EventStatsResponse {
+	buffered, capacity := s.Buffered()
+	return EventStatsResponse{
+		Buffered: buffered,
+		Capacity: capacity,
+		Dropped:  s.Dropped(),
+	}
+}
+
+// ReadinessResponse reports whether the server is ready and the state of each lifecycle hook
+type ReadinessResponse struct {
+	Ready bool                  `json:"ready"`
+	Hooks []lifecycle.HookState `json:"hooks"`
+}
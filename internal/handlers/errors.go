@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// Error codes for failures specific to user endpoints
+const (
+	CodeIncorrectPassword = "INCORRECT_PASSWORD"
+	CodePasswordUnchanged = "PASSWORD_UNCHANGED"
+	CodeVersionConflict   = "VERSION_CONFLICT"
+	CodeAlreadyVerified   = "ALREADY_VERIFIED"
+	CodeInvalidToken      = "INVALID_TOKEN"
+	CodeTokenExpired      = "TOKEN_EXPIRED"
+	CodeUnsupportedImage  = "UNSUPPORTED_IMAGE"
+	CodeLastAdmin         = "LAST_ADMIN"
+	CodeSelfDelete        = "SELF_DELETE"
+
+	CodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	CodeEmailNotVerified    = "EMAIL_NOT_VERIFIED"
+	CodeAccountDeleted      = "ACCOUNT_DELETED"
+	CodeInvalidRefreshToken = "INVALID_REFRESH_TOKEN"
+)
+
+// RetryAfter estimates how long a client should wait before retrying a request
+// refused for database backpressure, such as DB.RetryAfter
+type RetryAfter func() time.Duration
+
+// maxRetryAfter caps the estimate sent to clients
+const maxRetryAfter = time.Minute
+
+// set writes the estimate as Retry-After in whole seconds, at least one; nil always suggests one second
+func (ra RetryAfter) set(w http.ResponseWriter) {
+	wait := time.Second
+	if ra != nil {
+		wait = min(max(ra(), time.Second), maxRetryAfter)
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+// errorCode returns the machine-readable code for a service error
+func errorCode(err error) string {
+	switch err {
+	case services.ErrInvalidInput:
+		return respond.CodeInvalidInput
+	case services.ErrUserNotFound, services.ErrAvatarNotFound:
+		return respond.CodeNotFound
+	case services.ErrDuplicateEmail:
+		return respond.CodeDuplicateEmail
+	case services.ErrBatchTooLarge:
+		return respond.CodeTooLarge
+	case services.ErrIncorrectPassword:
+		return CodeIncorrectPassword
+	case services.ErrPasswordUnchanged:
+		return CodePasswordUnchanged
+	case services.ErrVersionConflict:
+		return CodeVersionConflict
+	case services.ErrAlreadyVerified:
+		return CodeAlreadyVerified
+	case services.ErrTokenNotFound:
+		return CodeInvalidToken
+	case services.ErrTokenGone:
+		return CodeTokenExpired
+	case services.ErrUnsupportedImage:
+		return CodeUnsupportedImage
+	case services.ErrLastAdmin:
+		return CodeLastAdmin
+	case services.ErrSelfDelete:
+		return CodeSelfDelete
+	case services.ErrInvalidCredentials:
+		return CodeInvalidCredentials
+	case services.ErrEmailNotVerified:
+		return CodeEmailNotVerified
+	case services.ErrAccountDeleted:
+		return CodeAccountDeleted
+	case services.ErrInvalidRefreshToken, services.ErrRefreshTokenReused:
+		return CodeInvalidRefreshToken
+	case services.ErrUnavailable:
+		return respond.CodeUnavailable
+	}
+	return respond.CodeInternal
+}
+
+// writeConflictError writes the uniform 409 naming the field that is already taken.
+// It reports false, writing nothing, when err is not a uniqueness conflict.
+func writeConflictError(w http.ResponseWriter, r *http.Request, err error) bool {
+	code, message, details, ok := conflictDetails(err)
+	if ok {
+		respond.ErrorDetails(w, r, http.StatusConflict, code, message, details)
+	}
+	return ok
+}
+
+// conflictDetails describes a uniqueness conflict; callers may add to details before writing it
+func conflictDetails(err error) (code, message string, details map[string]interface{}, ok bool) {
+	if errors.Is(err, services.ErrDuplicateEmail) {
+		return respond.CodeDuplicateEmail, err.Error(), map[string]interface{}{"field": "email", "reason": "already exists"}, true
+	}
+
+	var conflict *services.ConflictError
+	if !errors.As(err, &conflict) {
+		return "", "", nil, false
+	}
+	details = map[string]interface{}{"reason": "already exists"}
+	if conflict.Field != "" {
+		details["field"] = conflict.Field
+	}
+	return respond.CodeConflict, "Resource already exists", details, true
+}
+
+// writeValidationError writes the 400 listing each invalid field.
+// It reports false, writing nothing, when err is not a validation error.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var validation *services.ValidationError
+	if !errors.As(err, &validation) {
+		return false
+	}
+	respond.ErrorDetails(w, r, http.StatusBadRequest, respond.CodeValidationFailed, err.Error(),
+		map[string]interface{}{"fields": validation.Fields})
+	return true
+}
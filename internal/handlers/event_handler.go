@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// maxEventsBodyBytes caps the size of a single events batch request
+const maxEventsBodyBytes = 64 << 10
+
+type EventHandler struct {
+	service *services.EventService
+}
+
+func NewEventHandler(service *services.EventService) *EventHandler {
+	return &EventHandler{
+		service: service,
+	}
+}
+
+// Routes sets up the analytics event routes
+func (h *EventHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.trackEvents) // POST /api/events
+	return r
+}
+
+type trackEventsRequest struct {
+	Events []domain.AnalyticsEvent `json:"events"`
+}
+
+// trackEvents accepts a batch of analytics events for asynchronous storage
+func (h *EventHandler) trackEvents(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxEventsBodyBytes)
+	defer r.Body.Close()
+
+	// decodeJSON keeps numeric properties as json.Number so integers beyond 2^53 survive to storage
+	var req trackEventsRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	accepted, err := h.service.Track(req.Events)
+	if err != nil {
+		if !writeValidationError(w, r, err) {
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, map[string]int{"accepted": accepted})
+}
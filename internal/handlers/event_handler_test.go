@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestTrackEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		wantFields []string
+	}{
+		{name: "accepted", body: `{"events":[{"name":"page_view","properties":{"path":"/"}}]}`, status: http.StatusAccepted},
+		{name: "empty batch", body: `{"events":[]}`, status: http.StatusBadRequest, wantFields: []string{"events"}},
+		{name: "invalid events", body: `{"events":[{"name":"page_view"},{"name":"nope","properties":{"tags":["a"]}}]}`, status: http.StatusBadRequest, wantFields: []string{"events[1].name", "events[1].properties.tags"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := services.NewEventService(testutil.NewEventRepository(), services.EventConfig{BufferSize: 10})
+			r := chi.NewRouter()
+			r.Mount("/api/events", NewEventHandler(service).Routes())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("POST = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantFields == nil {
+				return
+			}
+
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Details struct {
+						Fields map[string]string `json:"fields"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error.Code != respond.CodeValidationFailed {
+				t.Errorf("error code = %q, want %q", body.Error.Code, respond.CodeValidationFailed)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := body.Error.Details.Fields[field]; !ok {
+					t.Errorf("fields = %v, missing %q", body.Error.Details.Fields, field)
+				}
+			}
+		})
+	}
+}
+
+func TestTrackEventsKeepsLargeIntegers(t *testing.T) {
+	repo := testutil.NewEventRepository()
+	service := services.NewEventService(repo, services.EventConfig{BufferSize: 10, FlushSize: 10, FlushInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		service.Run(ctx)
+	}()
+
+	r := chi.NewRouter()
+	r.Mount("/api/events", NewEventHandler(service).Routes())
+
+	// Each literal is one float64 would round: 2^53+1, the largest int64, past int64, and a
+	// decimal whose shortest float64 form differs from what was sent
+	properties := `{"above_2_53":9007199254740993,"max_int64":9223372036854775807,"past_int64":12345678901234567890123,"decimal":0.10000000000000000001}`
+	req := httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(`{"events":[{"name":"feature_used","properties":`+properties+`}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST = %d, want %d; body: %s", rec.Code, http.StatusAccepted, rec.Body)
+	}
+
+	cancel()
+	<-stopped
+	events := repo.Events()
+	if len(events) != 1 {
+		t.Fatalf("stored %d events, want 1", len(events))
+	}
+
+	// The repository encodes properties with encoding/json for the jsonb column, so
+	// rendering them must give back the literals exactly as the client sent them
+	rendered, err := json.Marshal(events[0].Properties)
+	if err != nil {
+		t.Fatalf("encoding stored properties: %v", err)
+	}
+	var want, got map[string]json.RawMessage
+	json.Unmarshal([]byte(properties), &want)
+	json.Unmarshal(rendered, &got)
+	for key, literal := range want {
+		if string(got[key]) != string(literal) {
+			t.Errorf("%s stored as %s, want %s", key, got[key], literal)
+		}
+	}
+}
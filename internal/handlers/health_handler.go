@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/platform/lifecycle"
+	"github.com/go-chi/chi/v5"
+)
+
+type HealthHandler struct {
+	app *lifecycle.Registry
+}
+
+func NewHealthHandler(app *lifecycle.Registry) *HealthHandler {
+	return &HealthHandler{
+		app: app,
+	}
+}
+
+// Routes sets up the health probe routes; they need no authentication
+func (h *HealthHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/ready", h.ready) // GET /health/ready
+	return r
+}
+
+// Ready handles the readiness probe: 200 once every lifecycle hook is running,
+// 503 while any is starting, stopping or failed, with each hook's state either way
+func (h *HealthHandler) ready(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	ready := h.app.Ready()
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	respond.JSON(w, r, status, ReadinessResponse{Ready: ready, Hooks: h.app.States()})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/platform/lifecycle"
+)
+
+func TestReadiness(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	tests := []struct {
+		name       string
+		start      bool
+		failing    bool
+		status     int
+		wantStates map[string]string
+	}{
+		{
+			name:       "before start",
+			status:     http.StatusServiceUnavailable,
+			wantStates: map[string]string{"events": lifecycle.StatePending, "http": lifecycle.StatePending},
+		},
+		{
+			name:       "running",
+			start:      true,
+			status:     http.StatusOK,
+			wantStates: map[string]string{"events": lifecycle.StateRunning, "http": lifecycle.StateRunning},
+		},
+		{
+			name:       "failed start",
+			start:      true,
+			failing:    true,
+			status:     http.StatusServiceUnavailable,
+			wantStates: map[string]string{"events": lifecycle.StateStopped, "http": lifecycle.StateFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := lifecycle.NewRegistry(log.New(io.Discard, "", 0))
+			httpStart := noop
+			if tt.failing {
+				httpStart = func(context.Context) error { return errors.New("address already in use") }
+			}
+			app.Register(lifecycle.Hook{Name: "events", Start: noop})
+			app.Register(lifecycle.Hook{Name: "http", DependsOn: []string{"events"}, Start: httpStart})
+			if tt.start {
+				app.Start(context.Background())
+			}
+
+			r := chi.NewRouter()
+			r.Mount("/health", NewHealthHandler(app).Routes())
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			var body struct {
+				Data ReadinessResponse `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body, err)
+			}
+			if body.Data.Ready != (tt.status == http.StatusOK) {
+				t.Errorf("ready = %v with status %d", body.Data.Ready, rec.Code)
+			}
+			got := make(map[string]string)
+			for _, hook := range body.Data.Hooks {
+				got[hook.Name] = hook.State
+			}
+			for name, want := range tt.wantStates {
+				if got[name] != want {
+					t.Errorf("hook %s state = %q, want %q", name, got[name], want)
+				}
+			}
+			if tt.failing && body.Data.Hooks[1].Error == "" {
+				t.Error("failed hook reported no error")
+			}
+		})
+	}
+}
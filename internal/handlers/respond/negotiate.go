@@ -0,0 +1,114 @@
+package respond
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types the envelope can be written as
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+)
+
+// Offered lists the media types a client can ask for, in order of preference
+var Offered = []string{ContentTypeJSON, ContentTypeXML}
+
+// acceptRange is one media range of an Accept header, e.g. application/*;q=0.5
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q-value the client gives mediaType, taken from the most
+// specific range that matches it, and whether the type was named exactly
+// rather than through a wildcard.
+func quality(ranges []acceptRange, mediaType string) (q float64, exact bool) {
+	major, _, _ := strings.Cut(mediaType, "/")
+	specificity := 0
+	for _, ar := range ranges {
+		var s int
+		switch {
+		case ar.mediaType == mediaType:
+			s = 3
+		case ar.mediaType == major+"/*":
+			s = 2
+		case ar.mediaType == "*/*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity || (s == specificity && ar.q > q) {
+			specificity, q = s, ar.q
+		}
+	}
+	return q, specificity == 3
+}
+
+// negotiated is the outcome of reading a request's Accept header
+type negotiated struct {
+	format  string // ContentTypeJSON, ContentTypeXML, or "" when neither is acceptable
+	problem bool   // errors should be written as problem documents
+}
+
+func negotiate(r *http.Request) negotiated {
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return negotiated{format: ContentTypeJSON}
+	}
+	ranges := parseAccept(header)
+	if len(ranges) == 0 {
+		// Nothing parseable; answer as if no preference was given
+		return negotiated{format: ContentTypeJSON}
+	}
+
+	plain, _ := quality(ranges, ContentTypeJSON)
+	xmlQ, _ := quality(ranges, ContentTypeXML)
+	if textQ, _ := quality(ranges, "text/xml"); textQ > xmlQ {
+		xmlQ = textQ
+	}
+	// Problem documents are only sent to clients that name the type, never through */*
+	problem, exact := quality(ranges, ProblemContentType)
+
+	var n negotiated
+	switch {
+	case xmlQ > plain:
+		n.format = ContentTypeXML
+	case plain > 0:
+		n.format = ContentTypeJSON
+	}
+	n.problem = exact && problem > 0 && problem >= plain && problem >= xmlQ
+	return n
+}
+
+// Acceptable reports whether the request's Accept header allows any media type
+// the envelope can be written as. A client that accepts only problem documents
+// is served JSON on success.
+func Acceptable(r *http.Request) bool {
+	n := negotiate(r)
+	return n.format != "" || n.problem
+}
@@ -0,0 +1,62 @@
+package respond
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		wantFormat  string
+		wantProblem bool
+	}{
+		{name: "no header", accept: "", wantFormat: ContentTypeJSON},
+		{name: "unparseable", accept: ";;;", wantFormat: ContentTypeJSON},
+		{name: "json", accept: "application/json", wantFormat: ContentTypeJSON},
+		{name: "xml", accept: "application/xml", wantFormat: ContentTypeXML},
+		{name: "text xml", accept: "text/xml", wantFormat: ContentTypeXML},
+
+		// q-values order the alternatives, whatever order they are listed in
+		{name: "xml preferred by q", accept: "application/json;q=0.5, application/xml", wantFormat: ContentTypeXML},
+		{name: "json preferred by q", accept: "application/xml;q=0.4, application/json;q=0.9", wantFormat: ContentTypeJSON},
+		{name: "equal q keeps server preference", accept: "application/xml, application/json", wantFormat: ContentTypeJSON},
+		{name: "q=0 excludes a type", accept: "application/json;q=0, application/xml;q=0.1", wantFormat: ContentTypeXML},
+		{name: "out of range q ignored", accept: "application/xml;q=2, application/json", wantFormat: ContentTypeJSON},
+
+		// Wildcards match, but a more specific range takes precedence over them
+		{name: "any type", accept: "*/*", wantFormat: ContentTypeJSON},
+		{name: "application wildcard", accept: "application/*", wantFormat: ContentTypeJSON},
+		{name: "specific beats wildcard", accept: "application/*;q=0.9, application/json;q=0.1", wantFormat: ContentTypeXML},
+		{name: "wildcard excluded but xml named", accept: "*/*;q=0, application/xml", wantFormat: ContentTypeXML},
+		{name: "text wildcard reaches text/xml", accept: "text/*", wantFormat: ContentTypeXML},
+
+		// Nothing the envelope can be written as
+		{name: "html only", accept: "text/html", wantFormat: ""},
+		{name: "everything excluded", accept: "*/*;q=0", wantFormat: ""},
+
+		// Problem documents need the type named, not reached through */*
+		{name: "problem json", accept: "application/problem+json", wantProblem: true},
+		{name: "problem preferred", accept: "application/problem+json, application/json;q=0.5", wantFormat: ContentTypeJSON, wantProblem: true},
+		{name: "json preferred over problem", accept: "application/problem+json;q=0.5, application/json", wantFormat: ContentTypeJSON},
+		{name: "wildcard is not a problem request", accept: "application/*", wantFormat: ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got := negotiate(r)
+			if got.format != tt.wantFormat || got.problem != tt.wantProblem {
+				t.Errorf("negotiate(%q) = %+v, want format %q, problem %v", tt.accept, got, tt.wantFormat, tt.wantProblem)
+			}
+			if acceptable := tt.wantFormat != "" || tt.wantProblem; Acceptable(r) != acceptable {
+				t.Errorf("Acceptable(%q) = %v, want %v", tt.accept, !acceptable, acceptable)
+			}
+		})
+	}
+}
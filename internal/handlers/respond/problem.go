@@ -0,0 +1,64 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem documents
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes every problem type URI. The rest of the URI is derived
+// from the error code, e.g. NOT_FOUND becomes ProblemTypeBase + "not-found",
+// so the URIs stay stable as long as the codes do.
+const ProblemTypeBase = "https://example.com/problems/"
+
+// Problem is an RFC 7807 problem document. Code and Details are extension
+// members carrying the same values as the envelope's error.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// ProblemType returns the type URI for an error code
+func ProblemType(code string) string {
+	return ProblemTypeBase + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+func newProblem(r *http.Request, status int, code, message string, details interface{}) Problem {
+	return Problem{
+		Type:     ProblemType(code),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   message,
+		Instance: r.URL.Path,
+		Code:     code,
+		Details:  details,
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	buf := buffers.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(problem); err != nil {
+		log.Printf("respond: encoding problem: %v", err)
+		problem = Problem{Type: ProblemType(CodeInternal), Title: http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError, Code: CodeInternal}
+		buf.Reset()
+		json.NewEncoder(buf).Encode(problem)
+	}
+
+	w.Header()["Content-Type"] = contentTypeProblem
+	w.WriteHeader(problem.Status)
+	w.Write(buf.Bytes())
+}
+
+var contentTypeProblem = []string{ProblemContentType}
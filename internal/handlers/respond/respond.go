@@ -0,0 +1,145 @@
+// Package respond writes responses in the API's standard envelope:
+// {"data": ..., "error": null} on success and
+// {"data": null, "error": {"code": "...", "message": "..."}} on failure.
+// The envelope is JSON unless the Accept header prefers application/xml, in which
+// case it is written as <response><data>...</data></response> with encoding/xml.
+// Clients that prefer application/problem+json get errors as RFC 7807 problem documents instead.
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Error codes shared across handlers; endpoint-specific codes are declared next to their handler
+const (
+	CodeInvalidInput         = "INVALID_INPUT"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeUnauthorized         = "UNAUTHORIZED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeNotFound             = "NOT_FOUND"
+	CodeConflict             = "CONFLICT"
+	CodeDuplicateEmail       = "DUPLICATE_EMAIL"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodePreconditionRequired = "PRECONDITION_REQUIRED"
+	CodeNotAcceptable        = "NOT_ACCEPTABLE"
+	CodeTooLarge             = "TOO_LARGE"
+	CodeTooManyRequests      = "TOO_MANY_REQUESTS"
+	CodeUpgradeRequired      = "UPGRADE_REQUIRED"
+	CodeUnavailable          = "UNAVAILABLE"
+	CodeInternal             = "INTERNAL_ERROR"
+)
+
+// ErrorBody is the error member of the envelope. Details carries structured
+// context such as the invalid fields, and is omitted when there is none.
+type ErrorBody struct {
+	Code    string      `json:"code" xml:"code"`
+	Message string      `json:"message" xml:"message"`
+	Details interface{} `json:"details,omitempty" xml:"details,omitempty"`
+}
+
+type envelope struct {
+	XMLName xml.Name    `json:"-" xml:"response"`
+	Data    interface{} `json:"data" xml:"data,omitempty"`
+	Error   *ErrorBody  `json:"error" xml:"error,omitempty"`
+}
+
+// JSON writes data as a successful response with status, encoded as JSON or,
+// when the client asks for it, XML. Data written as XML needs xml struct tags;
+// maps are only supported inside error details.
+func JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	write(w, negotiate(r).format, status, envelope{Data: data})
+}
+
+// Created writes data as a 201 response
+func Created(w http.ResponseWriter, r *http.Request, data interface{}) {
+	JSON(w, r, http.StatusCreated, data)
+}
+
+// Error writes a failed response with a machine-readable code and a message for people
+func Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	ErrorDetails(w, r, status, code, message, nil)
+}
+
+// ErrorDetails is Error with structured details, such as invalid fields or a partial result
+func ErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	n := negotiate(r)
+	if n.problem {
+		writeProblem(w, newProblem(r, status, code, message, details))
+		return
+	}
+	write(w, n.format, status, envelope{Error: &ErrorBody{Code: code, Message: message, Details: details}})
+}
+
+// write encodes body as format, falling back to JSON when the client accepts neither
+func write(w http.ResponseWriter, format string, status int, body envelope) {
+	addVary(w.Header())
+	if format == ContentTypeXML {
+		writeXML(w, status, body)
+		return
+	}
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		log.Printf("respond: encoding response: %v", err)
+		buf.Reset()
+		buf.WriteString(`{"data":null,"error":{"code":"` + CodeInternal + `","message":"Internal server error"}}` + "\n")
+		status = http.StatusInternalServerError
+	}
+
+	w.Header()["Content-Type"] = contentTypeJSON
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Header values every response shares, assigned rather than Set so they aren't allocated per response
+var (
+	contentTypeJSON = []string{ContentTypeJSON}
+	varyAccept      = []string{"Accept"}
+)
+
+// addVary adds Accept to the Vary header
+func addVary(h http.Header) {
+	if vary := h["Vary"]; len(vary) > 0 {
+		h["Vary"] = append(vary, "Accept")
+		return
+	}
+	h["Vary"] = varyAccept
+}
+
+// buffers hold JSON bodies while they are encoded
+var buffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// putBuffer returns buf to the pool unless an unusually large body grew it
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+func writeXML(w http.ResponseWriter, status int, body envelope) {
+	if body.Error != nil {
+		errBody := *body.Error
+		errBody.Details = xmlValue(errBody.Details)
+		body.Error = &errBody
+	}
+
+	buf, err := xml.Marshal(body)
+	if err != nil {
+		log.Printf("respond: encoding XML response: %v", err)
+		buf = []byte(`<response><error><code>` + CodeInternal + `</code><message>Internal server error</message></error></response>`)
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", ContentTypeXML+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(append(buf, '\n'))
+}
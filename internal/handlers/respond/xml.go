@@ -0,0 +1,44 @@
+package respond
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// Map is a JSON object that also encodes as XML, one child element per key in
+// key order. encoding/xml has no mapping for maps, so response types use Map
+// for free-form members such as per-field errors.
+type Map map[string]interface{}
+
+func (m Map) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := e.EncodeElement(xmlValue(m[key]), xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// xmlValue converts the maps details are usually built from into Maps
+func xmlValue(v interface{}) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return Map(m)
+	case map[string]string:
+		converted := make(Map, len(m))
+		for key, value := range m {
+			converted[key] = value
+		}
+		return converted
+	}
+	return v
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
+)
+
+// CodeStaleFilter is returned for a saved filter the listing no longer accepts
+const CodeStaleFilter = "STALE_FILTER"
+
+// SavedFilterHandler serves admins' saved list filters and the admin listings that apply them.
+// AdminHandler mounts it, behind its admin role check.
+type SavedFilterHandler struct {
+	service    *services.SavedFilterService
+	users      *UserHandler
+	retryAfter RetryAfter
+}
+
+func NewSavedFilterHandler(service *services.SavedFilterService, users *UserHandler, retryAfter RetryAfter) *SavedFilterHandler {
+	return &SavedFilterHandler{service: service, users: users, retryAfter: retryAfter}
+}
+
+// Routes sets up the saved filter routes
+func (h *SavedFilterHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.listFilters)               // GET /api/admin/saved-filters
+	r.Post("/", h.createFilter)             // POST /api/admin/saved-filters
+	r.Get("/{filterID}", h.getFilter)       // GET /api/admin/saved-filters/{filterID}
+	r.Put("/{filterID}", h.updateFilter)    // PUT /api/admin/saved-filters/{filterID}
+	r.Delete("/{filterID}", h.deleteFilter) // DELETE /api/admin/saved-filters/{filterID}
+	return r
+}
+
+// ListFilters handles listing the caller's saved filters and every shared one
+func (h *SavedFilterHandler) listFilters(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	requesterID, _ := middleware.UserID(r.Context())
+	filters, err := h.service.ListFilters(r.Context(), requesterID)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	responses := make([]SavedFilterResponse, len(filters))
+	for i := range filters {
+		responses[i] = newSavedFilterResponse(&filters[i])
+	}
+	respond.JSON(w, r, http.StatusOK, SavedFilterListResponse{Filters: responses})
+}
+
+// CreateFilter handles saving a filter owned by the caller
+func (h *SavedFilterHandler) createFilter(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req SavedFilterRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	filter := req.toDomain()
+	filter.OwnerID, _ = middleware.UserID(r.Context())
+
+	if err := h.service.CreateFilter(r.Context(), filter); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	respond.Created(w, r, newSavedFilterResponse(filter))
+}
+
+// GetFilter handles fetching a filter the caller owns or that is shared
+func (h *SavedFilterHandler) getFilter(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	requesterID, _ := middleware.UserID(r.Context())
+	filter, err := h.service.GetFilter(r.Context(), requesterID, chi.URLParam(r, "filterID"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newSavedFilterResponse(filter))
+}
+
+// UpdateFilter handles replacing a filter the caller owns
+func (h *SavedFilterHandler) updateFilter(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req SavedFilterRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	filter := req.toDomain()
+	filter.ID = chi.URLParam(r, "filterID")
+
+	requesterID, _ := middleware.UserID(r.Context())
+	if err := h.service.UpdateFilter(r.Context(), requesterID, filter); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, newSavedFilterResponse(filter))
+}
+
+// DeleteFilter handles removing a filter the caller owns
+func (h *SavedFilterHandler) deleteFilter(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	requesterID, _ := middleware.UserID(r.Context())
+	if err := h.service.DeleteFilter(r.Context(), requesterID, chi.URLParam(r, "filterID")); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUsers handles the user listing, as GET /api/users serves it, with ?filter_id= applying
+// a saved users filter. Parameters given explicitly override the filter's.
+func (h *SavedFilterHandler) listUsers(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	query := r.URL.Query()
+	filterID := query.Get("filter_id")
+	if filterID == "" {
+		h.users.writeUserList(w, r, query)
+		return
+	}
+
+	requesterID, _ := middleware.UserID(r.Context())
+	params, err := h.service.ResolveFilter(r.Context(), requesterID, filterID, domain.SavedFilterUsers)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	for name, value := range params {
+		if !query.Has(name) {
+			query.Set(name, value)
+		}
+	}
+	query.Del("filter_id")
+	h.users.writeUserList(w, r, query)
+}
+
+func (h *SavedFilterHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeValidationError(w, r, err) {
+		return
+	}
+	var stale *services.StaleFilterError
+	if errors.As(err, &stale) {
+		respond.ErrorDetails(w, r, http.StatusUnprocessableEntity, CodeStaleFilter, err.Error(),
+			map[string]interface{}{"fields": stale.Fields})
+		return
+	}
+
+	switch err {
+	case services.ErrInvalidInput:
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, err.Error())
+	case services.ErrSavedFilterNotFound:
+		respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Saved filter not found")
+	case services.ErrSavedFilterNotOwned:
+		respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, err.Error())
+	case services.ErrDuplicateFilterName:
+		respond.ErrorDetails(w, r, http.StatusConflict, respond.CodeConflict, err.Error(),
+			map[string]interface{}{"field": "name", "reason": "already exists"})
+	case services.ErrUnavailable:
+		h.retryAfter.set(w)
+		respond.Error(w, r, http.StatusServiceUnavailable, respond.CodeUnavailable, err.Error())
+	default:
+		respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/testutil"
+)
+
+var asOtherAdmin = &ports.AccessClaims{UserID: "admin2", Role: domain.RoleAdmin}
+
+// newSavedFilterServer serves the admin routes with saved filters over newUserServer's users.
+// admin owns "mine" and "stale"; admin2 owns the private "theirs" and the shared "team".
+func newSavedFilterServer(t *testing.T) http.Handler {
+	t.Helper()
+	users := newUserServer(t)
+	filters := testutil.NewSavedFilterRepository(
+		factory.SavedFilter("admin").WithID("mine").WithName("By email").WithParam("sort", "email").WithParam("order", "asc").Build(),
+		factory.SavedFilter("admin").WithID("stale").WithName("Admins").WithParam("role", "admin").WithParam("sort", "last_login").Build(),
+		factory.SavedFilter("admin2").WithID("theirs").WithName("Private").WithParam("sort", "email").Build(),
+		factory.SavedFilter("admin2").WithID("team").WithName("Team").WithParam("sort", "email").WithParam("order", "desc").Shared().Build(),
+	)
+	service := services.NewSavedFilterService(filters, &testutil.IDGenerator{})
+	userHandler := NewUserHandler(users.service, 1<<20, nil, nil)
+	return newAdminServer(t, AdminConfig{SavedFilters: NewSavedFilterHandler(service, userHandler, nil)})
+}
+
+func TestAdminListUsersWithSavedFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *ports.AccessClaims
+		query      string
+		status     int
+		wantCode   string
+		wantEmails []string
+		wantFields []string // stale fields reported with a 422
+	}{
+		{name: "no filter", claims: asAdmin, query: "?sort=email", status: http.StatusOK,
+			wantEmails: []string{"admin@example.com", "alice@example.com", "bob@example.com"}},
+		{name: "own filter", claims: asAdmin, query: "?filter_id=mine", status: http.StatusOK,
+			wantEmails: []string{"admin@example.com", "alice@example.com", "bob@example.com"}},
+		{name: "explicit params override", claims: asAdmin, query: "?filter_id=mine&order=desc&limit=2", status: http.StatusOK,
+			wantEmails: []string{"bob@example.com", "alice@example.com"}},
+		{name: "shared filter", claims: asAdmin, query: "?filter_id=team", status: http.StatusOK,
+			wantEmails: []string{"bob@example.com", "alice@example.com", "admin@example.com"}},
+		{name: "another admin's private filter", claims: asAdmin, query: "?filter_id=theirs", status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+		{name: "owner uses private filter", claims: asOtherAdmin, query: "?filter_id=theirs", status: http.StatusOK,
+			wantEmails: []string{"admin@example.com", "alice@example.com", "bob@example.com"}},
+		{name: "missing filter", claims: asAdmin, query: "?filter_id=ghost", status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+		{name: "stale filter", claims: asAdmin, query: "?filter_id=stale", status: http.StatusUnprocessableEntity, wantCode: CodeStaleFilter,
+			wantFields: []string{"params.role", "params.sort"}},
+		{name: "explicit params are still checked", claims: asAdmin, query: "?filter_id=mine&sort=password", status: http.StatusBadRequest,
+			wantCode: respond.CodeValidationFailed},
+		{name: "as user", claims: asAlice, query: "?filter_id=team", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newSavedFilterServer(t)
+			rec := adminRequest(t, router, tt.claims, http.MethodGet, "/api/admin/users"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Fatalf("GET = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if tt.wantFields != nil {
+				var body struct {
+					Error struct {
+						Details struct {
+							Fields map[string]string `json:"fields"`
+						} `json:"details"`
+					} `json:"error"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				for _, field := range tt.wantFields {
+					if body.Error.Details.Fields[field] == "" {
+						t.Errorf("fields = %v, want %s reported", body.Error.Details.Fields, field)
+					}
+				}
+			}
+			if tt.wantEmails == nil {
+				return
+			}
+
+			var body struct {
+				Data UserListResponse `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			var emails []string
+			for _, user := range body.Data.Users {
+				emails = append(emails, user.Email)
+			}
+			if !slices.Equal(emails, tt.wantEmails) {
+				t.Errorf("emails = %v, want %v", emails, tt.wantEmails)
+			}
+		})
+	}
+}
+
+func TestSavedFilterRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *ports.AccessClaims
+		method   string
+		path     string
+		body     string
+		status   int
+		wantCode string
+	}{
+		{name: "create", claims: asAdmin, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"Recent","resource":"users","params":{"created_after":"2026-01-01T00:00:00Z"}}`, status: http.StatusCreated},
+		{name: "create with unknown param", claims: asAdmin, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"Recent","resource":"users","params":{"role":"admin"}}`, status: http.StatusBadRequest, wantCode: respond.CodeValidationFailed},
+		{name: "create for unknown resource", claims: asAdmin, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"Recent","resource":"orders"}`, status: http.StatusBadRequest, wantCode: respond.CodeValidationFailed},
+		{name: "create with taken name", claims: asAdmin, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"By email","resource":"users"}`, status: http.StatusConflict, wantCode: respond.CodeConflict},
+		{name: "another admin may reuse a name", claims: asOtherAdmin, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"By email","resource":"users"}`, status: http.StatusCreated},
+		{name: "create as user", claims: asAlice, method: http.MethodPost, path: "/api/admin/saved-filters",
+			body: `{"name":"Recent","resource":"users"}`, status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+
+		{name: "get own", claims: asAdmin, method: http.MethodGet, path: "/api/admin/saved-filters/mine", status: http.StatusOK},
+		{name: "get shared", claims: asAdmin, method: http.MethodGet, path: "/api/admin/saved-filters/team", status: http.StatusOK},
+		{name: "get another admin's private", claims: asAdmin, method: http.MethodGet, path: "/api/admin/saved-filters/theirs", status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+
+		{name: "update own", claims: asAdmin, method: http.MethodPut, path: "/api/admin/saved-filters/mine",
+			body: `{"name":"By email, newest","resource":"users","params":{"sort":"email","order":"desc"},"shared":true}`, status: http.StatusOK},
+		{name: "update shared", claims: asAdmin, method: http.MethodPut, path: "/api/admin/saved-filters/team",
+			body: `{"name":"Mine now","resource":"users"}`, status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "update another admin's private", claims: asAdmin, method: http.MethodPut, path: "/api/admin/saved-filters/theirs",
+			body: `{"name":"Mine now","resource":"users"}`, status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+		{name: "update to taken name", claims: asAdmin, method: http.MethodPut, path: "/api/admin/saved-filters/mine",
+			body: `{"name":"Admins","resource":"users"}`, status: http.StatusConflict, wantCode: respond.CodeConflict},
+
+		{name: "delete own", claims: asAdmin, method: http.MethodDelete, path: "/api/admin/saved-filters/mine", status: http.StatusNoContent},
+		{name: "delete shared", claims: asAdmin, method: http.MethodDelete, path: "/api/admin/saved-filters/team", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "delete missing", claims: asAdmin, method: http.MethodDelete, path: "/api/admin/saved-filters/ghost", status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newSavedFilterServer(t)
+			rec := adminRequest(t, router, tt.claims, tt.method, tt.path, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("%s %s = %d, want %d; body: %s", tt.method, tt.path, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestListSavedFilters(t *testing.T) {
+	router := newSavedFilterServer(t)
+
+	tests := []struct {
+		claims *ports.AccessClaims
+		want   []string
+	}{
+		{claims: asAdmin, want: []string{"stale", "mine", "team"}},
+		{claims: asOtherAdmin, want: []string{"theirs", "team"}},
+	}
+	for _, tt := range tests {
+		rec := adminRequest(t, router, tt.claims, http.MethodGet, "/api/admin/saved-filters", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET as %s = %d; body: %s", tt.claims.UserID, rec.Code, rec.Body)
+		}
+		var body struct {
+			Data SavedFilterListResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		var ids []string
+		for _, filter := range body.Data.Filters {
+			ids = append(ids, filter.ID)
+		}
+		// Ordered by name: Admins, By email, Private, Team
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("filters visible to %s = %v, want %v", tt.claims.UserID, ids, tt.want)
+		}
+	}
+}
@@ -1,69 +1,216 @@
 package handlers
 
 import (
-	"encoding/json"
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
 	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
 )
 
 type UserHandler struct {
-	service *services.UserService
+	service        *services.UserService
+	avatarMaxBytes int64
+	idempotent     func(http.Handler) http.Handler // wraps POST routes that honour Idempotency-Key
+	retryAfter     RetryAfter
 }
 
-func NewUserHandler(service *services.UserService) *UserHandler {
+func NewUserHandler(service *services.UserService, avatarMaxBytes int64, idempotent func(http.Handler) http.Handler, retryAfter RetryAfter) *UserHandler {
 	return &UserHandler{
-		service: service,
+		service:        service,
+		avatarMaxBytes: avatarMaxBytes,
+		idempotent:     idempotent,
+		retryAfter:     retryAfter,
 	}
 }
 
 // Routes sets up the user routes
 func (h *UserHandler) Routes() chi.Router {
 	r := chi.NewRouter()
-	r.Post("/", h.createUser)     // POST /api/users
-	r.Get("/{userID}", h.getUser) // GET /api/users/{userID}
+
+	// Endpoints that answer in JSON or XML; other Accept values get 406
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Negotiate)
+		r.With(h.idempotent).Post("/", h.createUser)              // POST /api/users
+		r.Get("/verify", h.verifyEmail)                           // GET /api/users/verify?token=...
+		r.Post("/password-reset", h.requestPasswordReset)         // POST /api/users/password-reset
+		r.Post("/password-reset/confirm", h.confirmPasswordReset) // POST /api/users/password-reset/confirm
+		r.Get("/me", h.getCurrentUser)                            // GET /api/users/me
+		r.Get("/{userID}", h.getUser)                             // GET /api/users/{userID}
+		r.Head("/{userID}", h.headUser)                           // HEAD /api/users/{userID}
+		r.Put("/{userID}", h.updateUser)                          // PUT /api/users/{userID}
+		r.Post("/{userID}/password", h.changePassword)            // POST /api/users/{userID}/password
+		r.Post("/{userID}/verification", h.issueVerification)     // POST /api/users/{userID}/verification
+		r.Put("/{userID}/avatar", h.uploadAvatar)                 // PUT /api/users/{userID}/avatar
+
+		// Admin-only endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireRole(domain.RoleAdmin))
+			r.Get("/", h.listUsers)                    // GET /api/users
+			r.Post("/bulk", h.createUsers)             // POST /api/users/bulk
+			r.Post("/import", h.importUsers)           // POST /api/users/import
+			r.Delete("/{userID}", h.deleteUser)        // DELETE /api/users/{userID}
+			r.Post("/{userID}/restore", h.restoreUser) // POST /api/users/{userID}/restore
+			r.Put("/{userID}/role", h.setRole)         // PUT /api/users/{userID}/role
+		})
+	})
+
+	// Endpoints that serve files rather than the envelope
+	r.Get("/{userID}/avatar", h.getAvatar)                                         // GET /api/users/{userID}/avatar
+	r.With(middleware.RequireRole(domain.RoleAdmin)).Get("/export", h.exportUsers) // GET /api/users/export
 	return r
 }
 
 // CreateUser handles user creation
 func (h *UserHandler) createUser(w http.ResponseWriter, r *http.Request) {
-	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "Invalid request body"})
+	defer r.Body.Close()
+
+	var req CreateUserRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	user := req.toDomain()
+
+	err := h.service.CreateUser(r.Context(), user)
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		if writeConflictError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
 		return
 	}
+
+	respond.Created(w, r, newUserResponse(user))
+}
+
+// maxBulkBodyBytes bounds a bulk create request body
+const maxBulkBodyBytes = 1 << 20
+
+// CreateUsers handles all-or-nothing creation of a batch of users
+func (h *UserHandler) createUsers(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
 	defer r.Body.Close()
 
-	err := h.service.CreateUser(r.Context(), &user)
+	var reqs []*CreateUserRequest
+	if !decodeJSON(w, r, BodyRequired, &reqs) {
+		return
+	}
+	users := make([]*domain.User, len(reqs))
+	for i, req := range reqs {
+		if req != nil {
+			users[i] = req.toDomain()
+		}
+	}
+
+	results, err := h.service.CreateUsers(r.Context(), users)
 	if err != nil {
+		if code, message, details, ok := conflictDetails(err); ok {
+			details["results"] = newBulkItemResponses(results)
+			respond.ErrorDetails(w, r, http.StatusConflict, code, message, details)
+			return
+		}
 		switch err {
+		case services.ErrBatchTooLarge:
+			respond.ErrorDetails(w, r, http.StatusRequestEntityTooLarge, errorCode(err), err.Error(),
+				map[string]interface{}{"max": services.MaxBulkCreate})
 		case services.ErrInvalidInput:
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, map[string]string{"error": err.Error()})
-		case services.ErrDuplicateEmail:
-			render.Status(r, http.StatusConflict)
-			render.JSON(w, r, map[string]string{"error": err.Error()})
+			respond.ErrorDetails(w, r, http.StatusBadRequest, errorCode(err), err.Error(),
+				map[string]interface{}{"results": newBulkItemResponses(results)})
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
 		default:
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, map[string]string{"error": "Internal server error"})
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
 		}
 		return
 	}
 
-	render.Status(r, http.StatusCreated)
-	render.JSON(w, r, user)
+	respond.Created(w, r, BulkCreateResponse{Results: newBulkItemResponses(results)})
 }
 
 // GetUser handles fetching a single user
 func (h *UserHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
 	userID := chi.URLParam(r, "userID")
 	if userID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, map[string]string{"error": "User ID is required"})
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	user, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// HeadUser handles checking that a user exists; the response never has a body
+func (h *UserHandler) headUser(w http.ResponseWriter, r *http.Request) {
+	exists, err := h.service.UserExists(r.Context(), chi.URLParam(r, "userID"))
+	switch {
+	case err == services.ErrInvalidInput:
+		w.WriteHeader(http.StatusBadRequest)
+	case err == services.ErrUnavailable:
+		h.retryAfter.set(w)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GetCurrentUser handles fetching the authenticated user
+func (h *UserHandler) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -71,14 +218,783 @@ func (h *UserHandler) getUser(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		switch err {
 		case services.ErrUserNotFound:
-			render.Status(r, http.StatusNotFound)
-			render.JSON(w, r, map[string]string{"error": "User not found"})
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
 		default:
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, map[string]string{"error": "Internal server error"})
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
 		}
 		return
 	}
 
-	render.JSON(w, r, user)
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// UpdateUser handles replacing a user's editable fields
+func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+	if !authorizeSelfOrAdmin(w, r, userID) {
+		return
+	}
+
+	var req UpdateUserRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+	if req.ID != "" && req.ID != userID {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID in body does not match path")
+		return
+	}
+	if r.Header.Get("If-Match") == "" {
+		respond.Error(w, r, http.StatusPreconditionRequired, respond.CodePreconditionRequired, "If-Match with the user's ETag is required")
+		return
+	}
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		respond.Error(w, r, http.StatusPreconditionFailed, CodeVersionConflict, services.ErrVersionConflict.Error())
+		return
+	}
+	user := &domain.User{ID: userID, Email: req.Email, Version: version}
+
+	err := h.service.UpdateUser(r.Context(), user)
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		if writeConflictError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrVersionConflict:
+			respond.Error(w, r, http.StatusPreconditionFailed, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// authorizeSelfOrAdmin lets a request act on userID only when it is authenticated as
+// that user or as an admin. It writes 401 or 403 and reports false otherwise.
+func authorizeSelfOrAdmin(w http.ResponseWriter, r *http.Request, userID string) bool {
+	claims, ok := ports.ClaimsFromContext(r.Context())
+	if !ok {
+		respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+		return false
+	}
+	if claims.UserID != userID && claims.Role != domain.RoleAdmin {
+		respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "Forbidden")
+		return false
+	}
+	return true
+}
+
+// userETag is a strong validator for the stored version of user
+func userETag(user *domain.User) string {
+	return `"` + strconv.Itoa(user.Version) + `"`
+}
+
+// ifMatchVersion reads the version a client expects from If-Match.
+// "*" matches any version and yields 0; a value that can't match any version reports false.
+func ifMatchVersion(r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "*" {
+		return 0, true
+	}
+	// Weak validators never match under If-Match, so only a quoted integer is accepted
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// ChangePassword handles replacing a user's password once the current one is confirmed
+func (h *UserHandler) changePassword(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+	if !authorizeSelfOrAdmin(w, r, userID) {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidInput, services.ErrPasswordUnchanged:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrIncorrectPassword:
+			respond.Error(w, r, http.StatusForbidden, errorCode(err), err.Error())
+		case services.ErrVersionConflict:
+			respond.Error(w, r, http.StatusConflict, errorCode(err), err.Error())
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRole handles assigning a user's role
+func (h *UserHandler) setRole(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req SetRoleRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	user, err := h.service.SetUserRole(r.Context(), chi.URLParam(r, "userID"), req.Role)
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrLastAdmin, services.ErrVersionConflict:
+			respond.Error(w, r, http.StatusConflict, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// IssueVerification handles sending a new email verification link to a user
+func (h *UserHandler) issueVerification(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+	if !authorizeSelfOrAdmin(w, r, userID) {
+		return
+	}
+
+	err := h.service.IssueVerification(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrAlreadyVerified:
+			respond.Error(w, r, http.StatusConflict, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// VerifyEmail handles redeeming the token from a verification link
+func (h *UserHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	user, err := h.service.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "token is required")
+		case services.ErrTokenNotFound, services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, CodeInvalidToken, services.ErrTokenNotFound.Error())
+		case services.ErrTokenGone:
+			respond.Error(w, r, http.StatusGone, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// RequestPasswordReset handles sending a password reset email.
+// It answers 202 whether or not the address belongs to an account.
+func (h *UserHandler) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req PasswordResetRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	err := h.service.RequestPasswordReset(r.Context(), req.Email)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "email is required")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset handles setting a new password with a reset token
+func (h *UserHandler) confirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req ConfirmPasswordResetRequest
+	if !decodeJSON(w, r, BodyRequired, &req) {
+		return
+	}
+
+	err := h.service.ConfirmPasswordReset(r.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrTokenNotFound, services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, CodeInvalidToken, services.ErrTokenNotFound.Error())
+		case services.ErrTokenGone:
+			respond.Error(w, r, http.StatusGone, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// multipartOverhead allows for boundaries and part headers around an uploaded file
+const multipartOverhead = 16 << 10
+
+// UploadAvatar handles replacing a user's avatar with the "avatar" file of a multipart form.
+// The file is streamed to storage, never held in memory in full.
+func (h *UserHandler) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+	if !authorizeSelfOrAdmin(w, r, userID) {
+		return
+	}
+
+	// Reject uploads that announce their size before reading anything
+	if r.ContentLength > h.avatarMaxBytes+multipartOverhead {
+		writeBodyError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Avatar too large")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.avatarMaxBytes+multipartOverhead)
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		writeBodyError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, "Content-Type must be multipart/form-data")
+		return
+	}
+	form, err := r.MultipartReader()
+	if err != nil {
+		writeBodyError(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid multipart body")
+		return
+	}
+	part, err := nextFilePart(form, "avatar")
+	if err != nil {
+		writeBodyError(w, r, http.StatusBadRequest, CodeInvalidBody, "Multipart body must include an avatar file")
+		return
+	}
+	defer part.Close()
+
+	// Trust the bytes, not the declared part type
+	image := bufio.NewReader(&maxBytesReader{r: part, limit: h.avatarMaxBytes})
+	head, _ := image.Peek(512)
+	contentType := http.DetectContentType(head)
+
+	user, err := h.service.SetAvatar(r.Context(), userID, contentType, image)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeBodyError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Avatar too large")
+			return
+		}
+		switch err {
+		case services.ErrUnsupportedImage:
+			respond.Error(w, r, http.StatusUnsupportedMediaType, errorCode(err), err.Error())
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// GetAvatar handles streaming a user's avatar image
+func (h *UserHandler) getAvatar(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	image, contentType, err := h.service.OpenAvatar(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrAvatarNotFound:
+			respond.Error(w, r, http.StatusNotFound, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+	defer image.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, image)
+}
+
+// nextFilePart skips to the file part named field
+func nextFilePart(form *multipart.Reader, field string) (*multipart.Part, error) {
+	for {
+		part, err := form.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == field && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// maxBytesReader fails with *http.MaxBytesError once more than limit bytes are read
+type maxBytesReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.read > m.limit {
+		return 0, &http.MaxBytesError{Limit: m.limit}
+	}
+	// Read at most one byte past the limit, which is enough to detect an oversized stream
+	if allowed := m.limit - m.read + 1; int64(len(p)) > allowed {
+		p = p[:allowed]
+	}
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.read > m.limit {
+		return n, &http.MaxBytesError{Limit: m.limit}
+	}
+	return n, err
+}
+
+// DeleteUser handles soft-deleting a user; RestoreUser can bring it back until it is purged
+func (h *UserHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		return
+	}
+
+	requesterID, _ := middleware.UserID(r.Context())
+	err := h.service.DeleteUser(r.Context(), requesterID, userID)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrSelfDelete:
+			respond.Error(w, r, http.StatusForbidden, errorCode(err), err.Error())
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User not found")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreUser handles undoing the deletion of a user
+func (h *UserHandler) restoreUser(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	user, err := h.service.RestoreUser(r.Context(), chi.URLParam(r, "userID"))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "User ID is required")
+		case services.ErrUserNotFound:
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "No deleted user with this ID")
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	respond.JSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+// exportFlushRows is how many CSV rows are buffered before flushing to the client
+const exportFlushRows = 500
+
+// ExportUsers handles streaming users as CSV, optionally only those created after ?created_after= (RFC 3339)
+func (h *UserHandler) exportUsers(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+
+	createdAfter, ok := timeQueryParam(r.URL.Query(), "created_after")
+	if !ok {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "created_after must be an RFC 3339 timestamp")
+		return
+	}
+
+	filename := "users-" + time.Now().UTC().Format("20060102T150405Z") + ".csv"
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "email", "created_at", "updated_at"})
+	rows := 0
+	err := h.service.ExportUsers(r.Context(), createdAfter, func(user *domain.User) error {
+		// Stop scanning as soon as the client goes away
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+		out.Write([]string{
+			user.ID,
+			user.Email,
+			user.CreatedAt.UTC().Format(time.RFC3339),
+			user.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		if rows++; rows%exportFlushRows == 0 {
+			out.Flush()
+			if err := out.Error(); err != nil {
+				return err
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if rows == 0 && r.Context().Err() == nil {
+			// Nothing has reached the client yet, so a proper error can still be sent
+			w.Header().Del("Content-Disposition")
+			switch err {
+			case services.ErrUnavailable:
+				h.retryAfter.set(w)
+				respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+			default:
+				respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+			}
+			return
+		}
+		// Headers are already sent; a truncated file is all the client can get
+		log.Printf("user export aborted after %d rows: %v", rows, err)
+		return
+	}
+	out.Flush()
+}
+
+// maxImportBodyBytes bounds a CSV import upload
+const maxImportBodyBytes = 1 << 20
+
+// ImportUsers handles creating users from a text/csv body with an email,password header.
+// The whole file is parsed before anything is written, so malformed CSV never reaches the database.
+func (h *UserHandler) importUsers(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+	defer r.Body.Close()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/csv" {
+		writeBodyError(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, "Content-Type must be text/csv")
+		return
+	}
+
+	rows, err := parseImportCSV(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeBodyError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+			return
+		}
+		writeBodyError(w, r, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+
+	summary, err := h.service.ImportUsers(r.Context(), rows)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "CSV contains no rows")
+		case services.ErrBatchTooLarge:
+			respond.ErrorDetails(w, r, http.StatusRequestEntityTooLarge, errorCode(err), err.Error(),
+				map[string]interface{}{"max": services.MaxImportRows})
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.ErrorDetails(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error(),
+				map[string]interface{}{"summary": newImportSummaryResponse(summary)})
+		default:
+			if r.Context().Err() != nil {
+				// The client is gone; batches committed so far stay committed
+				log.Printf("user import cancelled: %+v", summary)
+				return
+			}
+			respond.ErrorDetails(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error",
+				map[string]interface{}{"summary": newImportSummaryResponse(summary)})
+		}
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, newImportSummaryResponse(summary))
+}
+
+// parseImportCSV reads every row of an import file, failing on a bad header or malformed CSV
+func parseImportCSV(body io.Reader) ([]services.ImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = 2
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV is empty")
+		}
+		return nil, importCSVError(err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	emailCol, hasEmail := columns["email"]
+	passwordCol, hasPassword := columns["password"]
+	if !hasEmail || !hasPassword {
+		return nil, errors.New("CSV header must be email,password")
+	}
+
+	var rows []services.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, importCSVError(err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, services.ImportRow{
+			Line:     line,
+			Email:    strings.TrimSpace(record[emailCol]),
+			Password: record[passwordCol],
+		})
+		if len(rows) > services.MaxImportRows {
+			return rows, nil // the service rejects the oversized import
+		}
+	}
+}
+
+// importCSVError keeps size errors intact and describes parse errors with their position
+func importCSVError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("malformed CSV: %v", parseErr)
+	}
+	return err
+}
+
+// ListUsers handles paging through users, newest first unless ?sort= and ?order= say otherwise,
+// optionally within ?created_after= and ?created_before= (RFC 3339), or searching them by email prefix with ?email=
+func (h *UserHandler) listUsers(w http.ResponseWriter, r *http.Request) {
+	if !decodeJSON(w, r, BodyForbidden, nil) {
+		return
+	}
+	h.writeUserList(w, r, r.URL.Query())
+}
+
+// writeUserList writes the page of users selected by query, which holds listUsers' parameters
+func (h *UserHandler) writeUserList(w http.ResponseWriter, r *http.Request, query url.Values) {
+	limit, ok := intQueryParam(query, "limit", services.DefaultListLimit)
+	if !ok || limit < 1 {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "limit must be a positive integer")
+		return
+	}
+	offset, ok := intQueryParam(query, "offset", 0)
+	if !ok || offset < 0 {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "offset must be a non-negative integer")
+		return
+	}
+
+	createdAfter, ok := timeQueryParam(query, "created_after")
+	if !ok {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "created_after must be an RFC 3339 timestamp")
+		return
+	}
+	createdBefore, ok := timeQueryParam(query, "created_before")
+	if !ok {
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "created_before must be an RFC 3339 timestamp")
+		return
+	}
+
+	var page *services.UserPage
+	var err error
+	if email := query.Get("email"); email != "" {
+		page, err = h.service.SearchUsersByEmail(r.Context(), email, limit)
+	} else {
+		page, err = h.service.ListUsers(r.Context(), services.ListUsersOptions{
+			Limit:         limit,
+			Offset:        offset,
+			Sort:          query.Get("sort"),
+			Order:         query.Get("order"),
+			CreatedAfter:  createdAfter,
+			CreatedBefore: createdBefore,
+		})
+	}
+	if err != nil {
+		if writeValidationError(w, r, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidInput:
+			respond.Error(w, r, http.StatusBadRequest, errorCode(err), err.Error())
+		case services.ErrUnavailable:
+			h.retryAfter.set(w)
+			respond.Error(w, r, http.StatusServiceUnavailable, errorCode(err), err.Error())
+		default:
+			respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+		return
+	}
+
+	if page.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+	respond.JSON(w, r, http.StatusOK, UserListResponse{
+		Users:  newUserResponses(page.Users),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+		Total:  page.Total,
+	})
+}
+
+// timeQueryParam reads an RFC 3339 query parameter, returning the zero time when it is absent
+func timeQueryParam(query url.Values, name string) (time.Time, bool) {
+	raw := query.Get(name)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// intQueryParam reads an integer query parameter, returning fallback when it is absent
+func intQueryParam(query url.Values, name string, fallback int) (int, bool) {
+	raw := query.Get(name)
+	if raw == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
 }
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/core/services"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/middleware"
+	"example.com/monolithic/internal/platform/ids"
+	"example.com/monolithic/internal/testutil"
+)
+
+var (
+	asAdmin = &ports.AccessClaims{UserID: "admin", Role: domain.RoleAdmin}
+	asAlice = &ports.AccessClaims{UserID: "alice", Role: domain.RoleUser}
+	asBob   = &ports.AccessClaims{UserID: "bob", Role: domain.RoleUser}
+)
+
+// userServer serves UserHandler.Routes under /api/users, backed by in-memory fakes
+type userServer struct {
+	router  http.Handler
+	service *services.UserService
+	users   *testutil.UserRepository
+	files   *testutil.FileStorage
+}
+
+func newUserServer(t *testing.T) *userServer {
+	t.Helper()
+	s := &userServer{
+		users: testutil.NewUserRepository(
+			factory.User().WithID("admin").WithEmail("admin@example.com").WithPassword("admin password").WithRole(domain.RoleAdmin).Build(),
+			factory.User().WithID("alice").WithEmail("alice@example.com").WithPassword("alice password").Build(),
+			factory.User().WithID("bob").WithEmail("bob@example.com").WithPassword("bob password").Build(),
+		),
+		files: testutil.NewFileStorage(),
+	}
+	audits := testutil.NewAuditRepository()
+	s.service = services.NewUserService(s.users, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		audits, testutil.NewTransactor(s.users, audits), testutil.PasswordHasher{}, ids.NewUUIDGenerator(),
+		&testutil.EmailSender{}, s.files, services.UserConfig{})
+	handler := NewUserHandler(s.service, 1<<20, func(next http.Handler) http.Handler { return next }, nil)
+
+	r := chi.NewRouter()
+	r.Mount("/api/users", handler.Routes())
+	s.router = r
+	return s
+}
+
+// do sends a request as claims, or unauthenticated when claims is nil
+func (s *userServer) do(t *testing.T, claims *ports.AccessClaims, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if claims != nil {
+		req = req.WithContext(middleware.WithClaims(req.Context(), *claims))
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCodeOf returns the code of the error envelope in rec, or "" when there is none
+func errorCodeOf(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error *respond.ErrorBody `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == nil {
+		return ""
+	}
+	return body.Error.Code
+}
+
+func TestUserRoutes(t *testing.T) {
+	ifMatch := func(etag string) http.Header { return http.Header{"If-Match": {etag}} }
+
+	tests := []struct {
+		name     string
+		claims   *ports.AccessClaims
+		method   string
+		path     string
+		body     string
+		header   http.Header
+		status   int
+		wantCode string
+	}{
+		{name: "get user", claims: asAlice, method: http.MethodGet, path: "/api/users/alice", status: http.StatusOK},
+		{name: "get missing user", claims: asAlice, method: http.MethodGet, path: "/api/users/ghost", status: http.StatusNotFound, wantCode: respond.CodeNotFound},
+		{name: "get current user", claims: asBob, method: http.MethodGet, path: "/api/users/me", status: http.StatusOK},
+		{name: "current user needs claims", method: http.MethodGet, path: "/api/users/me", status: http.StatusUnauthorized, wantCode: respond.CodeUnauthorized},
+		{name: "head user", claims: asAlice, method: http.MethodHead, path: "/api/users/bob", status: http.StatusOK},
+		{name: "head missing user", claims: asAlice, method: http.MethodHead, path: "/api/users/ghost", status: http.StatusNotFound},
+		{name: "sign up", method: http.MethodPost, path: "/api/users", body: `{"email":"carol@example.com","password":"carol password"}`, status: http.StatusCreated},
+		{name: "sign up with taken email", method: http.MethodPost, path: "/api/users", body: `{"email":"Alice@example.com","password":"carol password"}`, status: http.StatusConflict, wantCode: respond.CodeDuplicateEmail},
+		{name: "sign up with invalid fields", method: http.MethodPost, path: "/api/users", body: `{"email":"carol","password":"x"}`, status: http.StatusBadRequest, wantCode: respond.CodeValidationFailed},
+		{name: "sign up with malformed body", method: http.MethodPost, path: "/api/users", body: `{"email":`, status: http.StatusBadRequest},
+		{name: "update own email", claims: asAlice, method: http.MethodPut, path: "/api/users/alice", body: `{"email":"alice2@example.com"}`, header: ifMatch(`"1"`), status: http.StatusOK},
+		{name: "update another user", claims: asAlice, method: http.MethodPut, path: "/api/users/bob", body: `{"email":"bob2@example.com"}`, header: ifMatch(`"1"`), status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "update another user as admin", claims: asAdmin, method: http.MethodPut, path: "/api/users/bob", body: `{"email":"bob2@example.com"}`, header: ifMatch(`"1"`), status: http.StatusOK},
+		{name: "update unauthenticated", method: http.MethodPut, path: "/api/users/bob", body: `{"email":"bob2@example.com"}`, header: ifMatch(`"1"`), status: http.StatusUnauthorized, wantCode: respond.CodeUnauthorized},
+		{name: "update without If-Match", claims: asAlice, method: http.MethodPut, path: "/api/users/alice", body: `{"email":"alice2@example.com"}`, status: http.StatusPreconditionRequired, wantCode: respond.CodePreconditionRequired},
+		{name: "update with stale ETag", claims: asAlice, method: http.MethodPut, path: "/api/users/alice", body: `{"email":"alice2@example.com"}`, header: ifMatch(`"5"`), status: http.StatusPreconditionFailed, wantCode: CodeVersionConflict},
+		{name: "list as admin", claims: asAdmin, method: http.MethodGet, path: "/api/users?limit=10", status: http.StatusOK},
+		{name: "list as user", claims: asAlice, method: http.MethodGet, path: "/api/users?limit=10", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "delete as admin", claims: asAdmin, method: http.MethodDelete, path: "/api/users/bob", status: http.StatusNoContent},
+		{name: "delete self as admin", claims: asAdmin, method: http.MethodDelete, path: "/api/users/admin", status: http.StatusForbidden, wantCode: CodeSelfDelete},
+		{name: "delete as user", claims: asAlice, method: http.MethodDelete, path: "/api/users/bob", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "demote last admin", claims: asAdmin, method: http.MethodPut, path: "/api/users/admin/role", body: `{"role":"user"}`, status: http.StatusConflict, wantCode: CodeLastAdmin},
+		{name: "promote user", claims: asAdmin, method: http.MethodPut, path: "/api/users/bob/role", body: `{"role":"admin"}`, status: http.StatusOK},
+		{name: "change own password", claims: asAlice, method: http.MethodPost, path: "/api/users/alice/password", body: `{"current_password":"alice password","new_password":"alice password 2"}`, status: http.StatusNoContent},
+		{name: "change own password wrongly", claims: asAlice, method: http.MethodPost, path: "/api/users/alice/password", body: `{"current_password":"guess","new_password":"alice password 2"}`, status: http.StatusForbidden, wantCode: CodeIncorrectPassword},
+		{name: "guess another user's password", claims: asAlice, method: http.MethodPost, path: "/api/users/bob/password", body: `{"current_password":"bob password","new_password":"alice owns bob"}`, status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "change password unauthenticated", method: http.MethodPost, path: "/api/users/bob/password", body: `{"current_password":"bob password","new_password":"anyone owns bob"}`, status: http.StatusUnauthorized, wantCode: respond.CodeUnauthorized},
+		{name: "issue own verification", claims: asAlice, method: http.MethodPost, path: "/api/users/alice/verification", status: http.StatusAccepted},
+		{name: "issue another user's verification", claims: asAlice, method: http.MethodPost, path: "/api/users/bob/verification", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "probe a missing user's verification", claims: asAlice, method: http.MethodPost, path: "/api/users/ghost/verification", status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "issue verification as admin", claims: asAdmin, method: http.MethodPost, path: "/api/users/bob/verification", status: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUserServer(t)
+			rec := s.do(t, tt.claims, tt.method, tt.path, tt.body, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("%s %s = %d, want %d; body: %s", tt.method, tt.path, rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCodeOf(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q; body: %s", code, tt.wantCode, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateUserReturnsNewETag(t *testing.T) {
+	s := newUserServer(t)
+
+	rec := s.do(t, asAlice, http.MethodGet, "/api/users/alice", "", nil)
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q, want \"1\"", etag)
+	}
+
+	rec = s.do(t, asAlice, http.MethodPut, "/api/users/alice", `{"email":"alice2@example.com"}`, http.Header{"If-Match": {etag}})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("update = %d with ETag %q, want 200 with \"2\"", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	// The ETag read before the update no longer matches
+	rec = s.do(t, asAlice, http.MethodPut, "/api/users/alice", `{"email":"alice3@example.com"}`, http.Header{"If-Match": {etag}})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("update with old ETag = %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+}
+
+func TestUserCannotUpdateAnotherUser(t *testing.T) {
+	s := newUserServer(t)
+
+	rec := s.do(t, asAlice, http.MethodPut, "/api/users/bob", `{"email":"alice-owns-this@example.com"}`, http.Header{"If-Match": {"*"}})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("alice updating bob = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if bob, _ := s.users.Stored("bob"); bob.Email != "bob@example.com" || bob.Version != 1 {
+		t.Errorf("bob changed: %+v", bob)
+	}
+}
+
+// pngHeader is enough of a PNG for content sniffing to recognise it
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// avatarForm returns a multipart body carrying image as the avatar file, and its Content-Type
+func avatarForm(t *testing.T, image []byte) (string, http.Header) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(image)
+	form.Close()
+	return body.String(), http.Header{"Content-Type": {form.FormDataContentType()}}
+}
+
+func TestUploadAvatar(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *ports.AccessClaims
+		path   string
+		status int
+	}{
+		{name: "own avatar", claims: asAlice, path: "/api/users/alice/avatar", status: http.StatusOK},
+		{name: "another user's avatar", claims: asAlice, path: "/api/users/bob/avatar", status: http.StatusForbidden},
+		{name: "another user's avatar as admin", claims: asAdmin, path: "/api/users/bob/avatar", status: http.StatusOK},
+		{name: "unauthenticated", path: "/api/users/bob/avatar", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUserServer(t)
+			body, header := avatarForm(t, pngHeader)
+
+			rec := s.do(t, tt.claims, http.MethodPut, tt.path, body, header)
+			if rec.Code != tt.status {
+				t.Fatalf("PUT %s = %d, want %d; body: %s", tt.path, rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				if bob, _ := s.users.Stored("bob"); bob.AvatarURL != nil {
+					t.Errorf("refused upload set bob's avatar to %q", *bob.AvatarURL)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfterFromBackpressure(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter RetryAfter
+		want       string
+	}{
+		{name: "no estimate", want: "1"},
+		{name: "rounds up", retryAfter: func() time.Duration { return 2500 * time.Millisecond }, want: "3"},
+		{name: "at least a second", retryAfter: func() time.Duration { return 0 }, want: "1"},
+		{name: "at most a minute", retryAfter: func() time.Duration { return time.Hour }, want: "60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUserServer(t)
+			s.users.Err = ports.ErrUnavailable
+			r := chi.NewRouter()
+			r.Mount("/api/users", NewUserHandler(s.service, 1<<20, func(next http.Handler) http.Handler { return next }, tt.retryAfter).Routes())
+			s.router = r
+
+			rec := s.do(t, asAlice, http.MethodGet, "/api/users/alice", "", nil)
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.want {
+				t.Errorf("Retry-After = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// racingUserRepository fails every Update as if another writer bumped the version first
+type racingUserRepository struct {
+	*testutil.UserRepository
+}
+
+func (r racingUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return ports.ErrVersionConflict
+}
+
+func TestChangePasswordConcurrentEdit(t *testing.T) {
+	s := newUserServer(t)
+	audits := testutil.NewAuditRepository()
+	service := services.NewUserService(racingUserRepository{s.users}, testutil.NewTokenRepository(), testutil.NewPasswordResetRepository(),
+		audits, testutil.NewTransactor(s.users, audits), testutil.PasswordHasher{}, &testutil.IDGenerator{},
+		&testutil.EmailSender{}, s.files, services.UserConfig{})
+	r := chi.NewRouter()
+	r.Mount("/api/users", NewUserHandler(service, 1<<20, func(next http.Handler) http.Handler { return next }, nil).Routes())
+	s.router = r
+
+	rec := s.do(t, asAlice, http.MethodPost, "/api/users/alice/password", `{"current_password":"alice password","new_password":"alice password 2"}`, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+	if code := errorCodeOf(t, rec); code != CodeVersionConflict {
+		t.Errorf("error code = %q, want %q", code, CodeVersionConflict)
+	}
+}
+
+func TestUserContentNegotiation(t *testing.T) {
+	s := newUserServer(t)
+	accept := func(value string) http.Header { return http.Header{"Accept": {value}} }
+
+	t.Run("user as XML", func(t *testing.T) {
+		rec := s.do(t, asAlice, http.MethodGet, "/api/users/alice", "", accept("application/json;q=0.5, application/xml"))
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), respond.ContentTypeXML) {
+			t.Fatalf("status = %d, Content-Type = %q, want 200 XML: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+		var body struct {
+			XMLName xml.Name     `xml:"response"`
+			Data    UserResponse `xml:"data"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+		if body.Data.ID != "alice" || body.Data.Email != "alice@example.com" || body.Data.Role != domain.RoleUser {
+			t.Errorf("user = %+v, want alice", body.Data)
+		}
+	})
+
+	t.Run("user list as XML", func(t *testing.T) {
+		rec := s.do(t, asAdmin, http.MethodGet, "/api/users?limit=2", "", accept("application/xml"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var body struct {
+			Data UserListResponse `xml:"data"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+		if len(body.Data.Users) != 2 || body.Data.Limit != 2 || body.Data.Total == nil || *body.Data.Total != 3 {
+			t.Errorf("list = %+v, want 2 of 3 users", body.Data)
+		}
+		if !strings.Contains(rec.Body.String(), "<users><user><id>") {
+			t.Errorf("users are not wrapped as <users><user>: %s", rec.Body)
+		}
+	})
+
+	t.Run("error as XML", func(t *testing.T) {
+		rec := s.do(t, asAlice, http.MethodGet, "/api/users/ghost", "", accept("application/xml"))
+		var body struct {
+			Error respond.ErrorBody `xml:"error"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+		if rec.Code != http.StatusNotFound || body.Error.Code != respond.CodeNotFound {
+			t.Errorf("status = %d, code = %q, want 404 %s", rec.Code, body.Error.Code, respond.CodeNotFound)
+		}
+	})
+
+	t.Run("unsatisfiable Accept", func(t *testing.T) {
+		rec := s.do(t, asAlice, http.MethodGet, "/api/users/alice", "", accept("text/html, application/json;q=0"))
+		if rec.Code != http.StatusNotAcceptable || errorCodeOf(t, rec) != respond.CodeNotAcceptable {
+			t.Errorf("status = %d, code = %q, want 406 %s", rec.Code, errorCodeOf(t, rec), respond.CodeNotAcceptable)
+		}
+	})
+}
+
+func TestListUsersRejectsInjectedSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "sort", query: "sort=" + url.QueryEscape("email; DROP TABLE users"), field: "sort"},
+		{name: "order", query: "sort=email&order=" + url.QueryEscape("asc; DROP TABLE users"), field: "order"},
+		{name: "quoted identifier", query: "sort=" + url.QueryEscape(`"email"`), field: "sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUserServer(t)
+			rec := s.do(t, asAdmin, http.MethodGet, "/api/users?"+tt.query, "", nil)
+			if rec.Code != http.StatusBadRequest || errorCodeOf(t, rec) != respond.CodeValidationFailed {
+				t.Fatalf("status = %d, code = %q, want 400 %s: %s", rec.Code, errorCodeOf(t, rec), respond.CodeValidationFailed, rec.Body)
+			}
+			var body struct {
+				Error struct {
+					Details struct {
+						Fields map[string]string `json:"fields"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body, err)
+			}
+			if _, ok := body.Error.Details.Fields[tt.field]; !ok {
+				t.Errorf("fields = %v, want %s reported", body.Error.Details.Fields, tt.field)
+			}
+			if filters := s.users.Filters(); len(filters) != 0 {
+				t.Errorf("repository listed with %+v, want the request stopped before SQL", filters)
+			}
+		})
+	}
+}
+
+func TestSignUpAssignsUUID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	s := newUserServer(t)
+
+	seen := make(map[string]bool)
+	for _, email := range []string{"carol@example.com", "dave@example.com"} {
+		rec := s.do(t, nil, http.MethodPost, "/api/users", `{"email":"`+email+`","password":"long enough 1"}`, nil)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+		}
+		var body struct {
+			Data UserResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body, err)
+		}
+		id := body.Data.ID
+		if !uuidV4.MatchString(id) || seen[id] {
+			t.Errorf("id = %q, want a fresh version 4 UUID", id)
+		}
+		seen[id] = true
+		if _, ok := s.users.Stored(id); !ok {
+			t.Errorf("user stored under a different id than %q", id)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// Authentication requires a valid bearer access token on every request except
+// the public routes, given as "METHOD /path" with the full request path.
+// The authenticated user's ID and role are available to handlers through UserID and Role.
+func Authentication(tokens ports.AccessTokens, public ...string) func(http.Handler) http.Handler {
+	// Keyed by method, then path, so looking a request up builds no string
+	exempt := make(map[string]map[string]bool)
+	for _, route := range public {
+		method, path, _ := strings.Cut(route, " ")
+		if exempt[method] == nil {
+			exempt[method] = make(map[string]bool)
+		}
+		exempt[method][path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt[r.Method][r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Get token from Authorization header
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+				return
+			}
+
+			claims, err := tokens.Verify(strings.TrimSpace(token))
+			if err != nil {
+				respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// RequireRole allows only requests whose access token carries one of roles.
+// It must run after Authentication: requests without claims get 401, other roles 403.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := Role(r.Context())
+			if !ok {
+				respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+				return
+			}
+			if !allowed[role] {
+				respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithClaims returns ctx carrying claims as Authentication would store them
+func WithClaims(ctx context.Context, claims ports.AccessClaims) context.Context {
+	return ports.ContextWithClaims(ctx, claims)
+}
+
+// UserID returns the ID of the user the request was authenticated as
+func UserID(ctx context.Context) (string, bool) {
+	claims, ok := ports.ClaimsFromContext(ctx)
+	return claims.UserID, ok
+}
+
+// Role returns the role the request's access token was issued with
+func Role(ctx context.Context) (string, bool) {
+	claims, ok := ports.ClaimsFromContext(ctx)
+	return claims.Role, ok
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// okHandler answers 200 so tests can tell a request got through
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// errorCode returns the code of the error envelope in rec, or "" when there is none
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error *respond.ErrorBody `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == nil {
+		return ""
+	}
+	return body.Error.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *ports.AccessClaims
+		status   int
+		wantCode string
+	}{
+		{name: "no claims", status: http.StatusUnauthorized, wantCode: respond.CodeUnauthorized},
+		{name: "other role", claims: &ports.AccessClaims{UserID: "u1", Role: "user"}, status: http.StatusForbidden, wantCode: respond.CodeForbidden},
+		{name: "first allowed role", claims: &ports.AccessClaims{UserID: "u1", Role: "admin"}, status: http.StatusOK},
+		{name: "second allowed role", claims: &ports.AccessClaims{UserID: "u1", Role: "support"}, status: http.StatusOK},
+	}
+
+	handler := RequireRole("admin", "support")(okHandler)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.claims != nil {
+				req = req.WithContext(WithClaims(req.Context(), *tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if code := errorCode(t, rec); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q; body: %s", code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRequireRoleProblemDocument(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", respond.ProblemContentType)
+	rec := httptest.NewRecorder()
+	RequireRole("admin")(okHandler).ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != respond.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, respond.ProblemContentType)
+	}
+}
+
+// stubTokens accepts only the token "good", issued to u1
+type stubTokens struct{}
+
+func (stubTokens) Issue(userID, role string) (string, time.Time, error) {
+	return "good", time.Now().Add(time.Hour), nil
+}
+
+func (stubTokens) Verify(token string) (ports.AccessClaims, error) {
+	if token != "good" {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+	return ports.AccessClaims{UserID: "u1", Role: "user"}, nil
+}
+
+func TestAuthentication(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		status int
+	}{
+		{name: "valid token", method: http.MethodGet, path: "/api/users/me", header: "Bearer good", status: http.StatusOK},
+		{name: "scheme is case-insensitive", method: http.MethodGet, path: "/api/users/me", header: "bearer good", status: http.StatusOK},
+		{name: "missing header", method: http.MethodGet, path: "/api/users/me", status: http.StatusUnauthorized},
+		{name: "wrong scheme", method: http.MethodGet, path: "/api/users/me", header: "Basic good", status: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/api/users/me", header: "Bearer forged", status: http.StatusUnauthorized},
+		{name: "public route", method: http.MethodPost, path: "/api/auth/login", status: http.StatusOK},
+		{name: "public path with another method", method: http.MethodGet, path: "/api/auth/login", status: http.StatusUnauthorized},
+	}
+
+	handler := Authentication(stubTokens{}, "POST /api/auth/login")(okHandler)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized {
+				if code := errorCode(t, rec); code != respond.CodeUnauthorized {
+					t.Errorf("error code = %q, want %q; body: %s", code, respond.CodeUnauthorized, rec.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// ClientVersionHeader carries "<platform>/<semver>", e.g. "ios/2.4.0-beta.1"
+const ClientVersionHeader = "X-Client-Version"
+
+// HistogramOther counts requests from unknown platforms, with unparseable
+// versions, or arriving once the histogram is full
+const HistogramOther = "other"
+
+// maxHistogramKeys bounds the histogram, whose keys come from client headers
+const maxHistogramKeys = 256
+
+// ClientMinimum is the oldest supported build for a platform
+type ClientMinimum struct {
+	Version    string `json:"version"`
+	UpgradeURL string `json:"upgrade_url"`
+}
+
+// ClientVersionConfig sets the minimum supported builds and where to find an app's version
+type ClientVersionConfig struct {
+	Minimums map[string]ClientMinimum
+	// UserAgentProduct is the product token the apps send in User-Agent, e.g. "Acme" in
+	// "Acme/2.4.0 (iOS 17.1; iPhone14,2)". It is read when X-Client-Version is absent;
+	// empty leaves User-Agent alone.
+	UserAgentProduct string
+}
+
+// ClientVersionGate rejects app builds older than the configured per-platform
+// minimum with 426 Upgrade Required. Requests without a version header
+// (curl, server-to-server) pass through untouched.
+type ClientVersionGate struct {
+	product string
+
+	mu        sync.RWMutex
+	minimums  map[string]ClientMinimum
+	parsed    map[string]Version
+	histogram map[string]*int64 // counts behind pointers, so a known key is counted without building a string
+}
+
+func NewClientVersionGate(cfg ClientVersionConfig) (*ClientVersionGate, error) {
+	g := &ClientVersionGate{product: cfg.UserAgentProduct, histogram: make(map[string]*int64)}
+	if err := g.SetMinimums(cfg.Minimums); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// SetMinimums replaces the per-platform minimums; safe to call at runtime
+func (g *ClientVersionGate) SetMinimums(minimums map[string]ClientMinimum) error {
+	parsed := make(map[string]Version, len(minimums))
+	copied := make(map[string]ClientMinimum, len(minimums))
+	for platform, min := range minimums {
+		v, err := ParseVersion(min.Version)
+		if err != nil {
+			return fmt.Errorf("minimum version for %s: %w", platform, err)
+		}
+		parsed[strings.ToLower(platform)] = v
+		copied[strings.ToLower(platform)] = min
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.minimums = copied
+	g.parsed = parsed
+	return nil
+}
+
+// Minimums returns the current per-platform minimums
+func (g *ClientVersionGate) Minimums() map[string]ClientMinimum {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make(map[string]ClientMinimum, len(g.minimums))
+	for k, v := range g.minimums {
+		out[k] = v
+	}
+	return out
+}
+
+// Histogram returns request counts per "<platform>/<major.minor.patch>" for platforms
+// with a minimum; everything else counts under HistogramOther
+func (g *ClientVersionGate) Histogram() map[string]int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make(map[string]int64, len(g.histogram))
+	for k, n := range g.histogram {
+		out[k] = *n
+	}
+	return out
+}
+
+func (g *ClientVersionGate) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		platform, raw, ok := g.clientVersion(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version, err := ParseVersion(raw)
+		g.mu.RLock()
+		min, hasMin := g.parsed[platform]
+		info := g.minimums[platform]
+		g.mu.RUnlock()
+
+		if err != nil || !hasMin {
+			g.observe([]byte(HistogramOther))
+			next.ServeHTTP(w, r)
+			return
+		}
+		var key [64]byte
+		g.observe(version.appendRelease(append(append(key[:0], platform...), '/')))
+
+		if version.Compare(min) < 0 {
+			respond.ErrorDetails(w, r, http.StatusUpgradeRequired, respond.CodeUpgradeRequired, "Client version no longer supported",
+				map[string]interface{}{"minimum_version": info.Version, "upgrade_url": info.UpgradeURL})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientVersion reads the platform and raw version from X-Client-Version, falling back
+// to the app's User-Agent; ok is false for callers that send neither
+func (g *ClientVersionGate) clientVersion(r *http.Request) (platform, version string, ok bool) {
+	if header := r.Header.Get(ClientVersionHeader); header != "" {
+		platform, version, _ = strings.Cut(header, "/")
+		return strings.ToLower(strings.TrimSpace(platform)), strings.TrimSpace(version), true
+	}
+	if g.product == "" {
+		return "", "", false
+	}
+	return parseUserAgent(r.Header.Get("User-Agent"), g.product)
+}
+
+// parseUserAgent finds "<product>/<version> (<platform> ...; ...)" in a User-Agent,
+// taking the platform from the first word of the comment that follows the product
+func parseUserAgent(ua, product string) (platform, version string, ok bool) {
+	prefix := product + "/"
+	i := strings.Index(ua, prefix)
+	if i < 0 || (i > 0 && ua[i-1] != ' ') {
+		return "", "", false
+	}
+	rest := ua[i+len(prefix):]
+	version, rest, _ = strings.Cut(rest, " ")
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") {
+		return "", version, true // versioned, but with no platform to gate on
+	}
+	comment, _, _ := strings.Cut(rest[1:], ")")
+	comment, _, _ = strings.Cut(comment, ";")
+	if fields := strings.Fields(comment); len(fields) > 0 {
+		platform = strings.ToLower(fields[0])
+	}
+	return platform, version, true
+}
+
+func (g *ClientVersionGate) observe(key []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n, seen := g.histogram[string(key)]; seen {
+		*n++
+		return
+	}
+	k := string(key)
+	if len(g.histogram) >= maxHistogramKeys {
+		k = HistogramOther
+	}
+	if n, seen := g.histogram[k]; seen {
+		*n++
+		return
+	}
+	n := int64(1)
+	g.histogram[k] = &n
+}
+
+// Version is a parsed semantic version; build metadata is ignored for ordering
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          []string
+}
+
+// ParseVersion parses MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD]
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+
+	nums := [3]int{}
+	for i, rest := 0, core; ; i++ {
+		part, next, more := strings.Cut(rest, ".")
+		n, err := strconv.Atoi(part)
+		if i == len(nums) || err != nil || n < 0 || (len(part) > 1 && part[0] == '0') {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+		if !more {
+			break
+		}
+		rest = next
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		for _, id := range strings.Split(pre, ".") {
+			if id == "" {
+				return v, fmt.Errorf("invalid version %q", s)
+			}
+			v.Prerelease = append(v.Prerelease, id)
+		}
+	}
+	return v, nil
+}
+
+// Release returns the version without its prerelease identifiers
+func (v Version) Release() string {
+	return string(v.appendRelease(make([]byte, 0, 16)))
+}
+
+func (v Version) appendRelease(b []byte) []byte {
+	b = strconv.AppendInt(b, int64(v.Major), 10)
+	b = append(b, '.')
+	b = strconv.AppendInt(b, int64(v.Minor), 10)
+	b = append(b, '.')
+	return strconv.AppendInt(b, int64(v.Patch), 10)
+}
+
+func (v Version) String() string {
+	s := v.Release()
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 following semver precedence rules
+func (v Version) Compare(o Version) int {
+	for _, d := range [3]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// A release ranks above any of its prereleases
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		a, b := v.Prerelease[i], o.Prerelease[i]
+		an, aErr := strconv.Atoi(a)
+		bn, bErr := strconv.Atoi(b)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1 // numeric identifiers rank below alphanumeric ones
+		case bErr == nil:
+			return 1
+		case a != b:
+			return strings.Compare(a, b)
+		}
+	}
+	return sign(len(v.Prerelease) - len(o.Prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+func TestClientVersionGateUpgradeRequired(t *testing.T) {
+	gate, err := NewClientVersionGate(ClientVersionConfig{Minimums: map[string]ClientMinimum{
+		"ios": {Version: "2.0.0", UpgradeURL: "https://example.com/ios"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(ClientVersionHeader, "ios/1.9.9")
+	rec := httptest.NewRecorder()
+	gate.Handler(okHandler).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUpgradeRequired)
+	}
+	var body struct {
+		Error struct {
+			Code    string            `json:"code"`
+			Details map[string]string `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != respond.CodeUpgradeRequired || body.Error.Details["minimum_version"] != "2.0.0" ||
+		body.Error.Details["upgrade_url"] != "https://example.com/ios" {
+		t.Errorf("body = %s", rec.Body)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1.2.3", want: "1.2.3"},
+		{in: "v1.2.3", want: "1.2.3"},
+		{in: "2", want: "2.0.0"},
+		{in: "2.1", want: "2.1.0"},
+		{in: "1.0.0-beta.1", want: "1.0.0-beta.1"},
+		{in: "1.0.0+build.5", want: "1.0.0"},
+		{in: "1.0.0-rc.1+build.5", want: "1.0.0-rc.1"},
+		{in: "", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "01.2.3", wantErr: true},
+		{in: "1.-2.3", wantErr: true},
+		{in: "1.x.3", wantErr: true},
+		{in: "1.0.0-", wantErr: true},
+		{in: "1.0.0-beta..1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := ParseVersion(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && v.String() != tt.want {
+				t.Errorf("ParseVersion(%q) = %s, want %s", tt.in, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Each version ranks strictly above the one before it, per the semver spec's example
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"2.0.0",
+		"10.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			if got, want := a.Compare(b), sign(i-j); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	a, _ := ParseVersion("1.0.0+build.1")
+	b, _ := ParseVersion("1.0.0+build.2")
+	if a.Compare(b) != 0 {
+		t.Error("build metadata affected ordering")
+	}
+}
+
+func TestClientVersionGate(t *testing.T) {
+	gate, err := NewClientVersionGate(ClientVersionConfig{
+		Minimums: map[string]ClientMinimum{
+			"ios":     {Version: "2.0.0", UpgradeURL: "https://example.com/ios"},
+			"android": {Version: "1.5.0-rc.2", UpgradeURL: "https://example.com/android"},
+		},
+		UserAgentProduct: "Acme",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		version   string
+		userAgent string
+		status    int
+		wantKey   string
+	}{
+		{name: "supported", version: "ios/2.0.0", status: http.StatusOK, wantKey: "ios/2.0.0"},
+		{name: "too old", version: "ios/1.9.9", status: http.StatusUpgradeRequired, wantKey: "ios/1.9.9"},
+		{name: "prerelease of the minimum", version: "ios/2.0.0-beta.1", status: http.StatusUpgradeRequired, wantKey: "ios/2.0.0"},
+		{name: "later prerelease than a prerelease minimum", version: "android/1.5.0-rc.10", status: http.StatusOK, wantKey: "android/1.5.0"},
+		{name: "platform case", version: "IOS/2.1.0", status: http.StatusOK, wantKey: "ios/2.1.0"},
+		{name: "unknown platform", version: "windows/0.1.0", status: http.StatusOK, wantKey: HistogramOther},
+		{name: "malformed version", version: "ios/latest", status: http.StatusOK, wantKey: HistogramOther},
+		{name: "missing version", version: "ios", status: http.StatusOK, wantKey: HistogramOther},
+		{name: "unversioned caller", userAgent: "curl/8.4.0", status: http.StatusOK},
+		{name: "browser", userAgent: "Mozilla/5.0 (Linux; Android 10) Chrome/120.0", status: http.StatusOK},
+		{name: "another product", userAgent: "NotAcme/1.0.0 (iOS 17.1)", status: http.StatusOK},
+		{name: "user agent too old", userAgent: "Acme/1.2.0 (iOS 17.1; iPhone14,2)", status: http.StatusUpgradeRequired, wantKey: "ios/1.2.0"},
+		{name: "user agent supported", userAgent: "Acme/1.6.0 (Android 14; Pixel 8) okhttp/4.12", status: http.StatusOK, wantKey: "android/1.6.0"},
+		{name: "user agent without platform", userAgent: "Acme/1.0.0", status: http.StatusOK, wantKey: HistogramOther},
+		{name: "header wins over user agent", version: "ios/2.0.0", userAgent: "Acme/1.0.0 (iOS 17.1)", status: http.StatusOK, wantKey: "ios/2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := gate.Histogram()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.version != "" {
+				req.Header.Set(ClientVersionHeader, tt.version)
+			}
+			req.Header.Set("User-Agent", tt.userAgent)
+			rec := httptest.NewRecorder()
+			gate.Handler(okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			after := gate.Histogram()
+			for key, n := range after {
+				want := before[key]
+				if key == tt.wantKey {
+					want++
+				}
+				if n != want {
+					t.Errorf("histogram[%q] = %d, want %d", key, n, want)
+				}
+			}
+			if tt.wantKey != "" && after[tt.wantKey] == 0 {
+				t.Errorf("histogram has no %q: %v", tt.wantKey, after)
+			}
+		})
+	}
+}
+
+func TestClientVersionHistogramIsBounded(t *testing.T) {
+	gate, err := NewClientVersionGate(ClientVersionConfig{Minimums: map[string]ClientMinimum{"ios": {Version: "1.0.0"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const requests = 2 * maxHistogramKeys
+	for i := range requests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(ClientVersionHeader, "ios/1.0."+strconv.Itoa(i)+"-build."+strconv.Itoa(i))
+		gate.Handler(okHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	histogram := gate.Histogram()
+	if len(histogram) > maxHistogramKeys+1 {
+		t.Errorf("histogram has %d keys, want at most %d", len(histogram), maxHistogramKeys+1)
+	}
+	var total int64
+	for _, n := range histogram {
+		total += n
+	}
+	if total != requests || histogram[HistogramOther] == 0 {
+		t.Errorf("histogram counted %d requests with %d under %q, want %d with overflow under it",
+			total, histogram[HistogramOther], HistogramOther, requests)
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// IdempotencyConfig controls how long responses are kept for replay
+type IdempotencyConfig struct {
+	// TTL is how long a key and its response are kept
+	TTL time.Duration
+	// CleanupInterval is how often Run deletes expired keys
+	CleanupInterval time.Duration
+	// MaxBodyBytes caps the request body read for hashing
+	MaxBodyBytes int64
+	// RetryAfter estimates the wait sent with 503s caused by storage backpressure; nil suggests one second
+	RetryAfter func() time.Duration
+	Logger     *log.Logger
+}
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// Error codes for requests refused by Idempotency
+const (
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+)
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header, instead of running the handler again. Routes opt
+// in with r.With(idem.Handler); requests without the header pass straight through.
+// Keys are scoped to the method, path and authenticated user.
+type Idempotency struct {
+	store ports.IdempotencyRepository
+	cfg   IdempotencyConfig
+}
+
+func NewIdempotency(store ports.IdempotencyRepository, cfg IdempotencyConfig) *Idempotency {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = time.Hour
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	if cfg.RetryAfter == nil {
+		cfg.RetryAfter = func() time.Duration { return time.Second }
+	}
+	return &Idempotency{store: store, cfg: cfg}
+}
+
+func (idem *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Idempotency-Key")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(header) > maxIdempotencyKeyLength {
+			idempotencyError(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, idem.cfg.MaxBodyBytes))
+		r.Body.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				idempotencyError(w, r, http.StatusRequestEntityTooLarge, respond.CodeTooLarge, "Request body too large")
+				return
+			}
+			idempotencyError(w, r, http.StatusBadRequest, respond.CodeInvalidInput, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := UserID(r.Context())
+		key := r.Method + " " + r.URL.Path + " " + userID + " " + header
+		sum := sha256.Sum256(body)
+		now := time.Now()
+		record := &domain.IdempotencyRecord{
+			Key:         key,
+			RequestHash: hex.EncodeToString(sum[:]),
+			ExpiresAt:   now.Add(idem.cfg.TTL),
+			CreatedAt:   now,
+		}
+
+		err = idem.store.Reserve(r.Context(), record)
+		switch {
+		case err == nil:
+			idem.serve(w, r, next, key)
+		case errors.Is(err, ports.ErrConflict):
+			idem.replay(w, r, record)
+		case errors.Is(err, ports.ErrUnavailable):
+			setRetryAfter(w, idem.cfg.RetryAfter())
+			idempotencyError(w, r, http.StatusServiceUnavailable, respond.CodeUnavailable, "Service temporarily unavailable")
+		default:
+			idem.cfg.Logger.Printf("idempotency: reserve %q: %v", header, err)
+			idempotencyError(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		}
+	})
+}
+
+// Run deletes expired keys every CleanupInterval until ctx is cancelled
+func (idem *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(idem.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := idem.store.DeleteExpired(ctx, now)
+			if err != nil {
+				idem.cfg.Logger.Printf("idempotency: cleanup failed: %v", err)
+			} else if deleted > 0 {
+				idem.cfg.Logger.Printf("idempotency: deleted %d expired keys", deleted)
+			}
+		}
+	}
+}
+
+// serve runs the handler for a newly reserved key and stores its response.
+// Server errors release the key so the client's retry runs the request again.
+func (idem *Idempotency) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+	completed := false
+	defer func() {
+		// Also runs when the handler panics, so the key isn't stuck in progress
+		ctx := context.WithoutCancel(r.Context())
+		if completed && rec.status < http.StatusInternalServerError {
+			if err := idem.store.Complete(ctx, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				idem.cfg.Logger.Printf("idempotency: store response: %v", err)
+			}
+			return
+		}
+		if err := idem.store.Delete(ctx, key); err != nil {
+			idem.cfg.Logger.Printf("idempotency: release key: %v", err)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+	completed = true
+}
+
+// replay answers a repeated key from the stored record
+func (idem *Idempotency) replay(w http.ResponseWriter, r *http.Request, attempt *domain.IdempotencyRecord) {
+	stored, err := idem.store.GetByKey(r.Context(), attempt.Key)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			// Released or expired between Reserve and now; the client can simply retry
+			w.Header().Set("Retry-After", "1")
+			idempotencyError(w, r, http.StatusConflict, CodeIdempotencyInProgress, "A request with this Idempotency-Key is in progress")
+			return
+		}
+		if errors.Is(err, ports.ErrUnavailable) {
+			setRetryAfter(w, idem.cfg.RetryAfter())
+			idempotencyError(w, r, http.StatusServiceUnavailable, respond.CodeUnavailable, "Service temporarily unavailable")
+			return
+		}
+		idem.cfg.Logger.Printf("idempotency: load key: %v", err)
+		idempotencyError(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
+		return
+	}
+
+	if stored.RequestHash != attempt.RequestHash {
+		idempotencyError(w, r, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+		return
+	}
+	if stored.ResponseStatus == 0 {
+		w.Header().Set("Retry-After", "1")
+		idempotencyError(w, r, http.StatusConflict, CodeIdempotencyInProgress, "A request with this Idempotency-Key is in progress")
+		return
+	}
+
+	if stored.ResponseContentType != "" {
+		w.Header().Set("Content-Type", stored.ResponseContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.ResponseStatus)
+	w.Write(stored.ResponseBody)
+}
+
+func idempotencyError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	respond.Error(w, r, status, code, message)
+}
+
+// recordingWriter passes a response through while keeping a copy for replay
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int64
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":"u1"}}`))
+	})
+	idem := NewIdempotency(testutil.NewIdempotencyRepository(), IdempotencyConfig{Logger: log.New(io.Discard, "", 0)})
+	handler := idem.Handler(created)
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		body      string
+		status    int
+		wantCode  string
+		wantCalls int64
+		replayed  bool
+	}{
+		{name: "first request", key: "k1", body: `{"a":1}`, status: http.StatusCreated, wantCalls: 1},
+		{name: "retry replays", key: "k1", body: `{"a":1}`, status: http.StatusCreated, wantCalls: 1, replayed: true},
+		{name: "key reused with another body", key: "k1", body: `{"a":2}`, status: http.StatusUnprocessableEntity, wantCode: CodeIdempotencyKeyReused, wantCalls: 1},
+		{name: "key too long", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: `{}`, status: http.StatusBadRequest, wantCode: respond.CodeInvalidInput, wantCalls: 1},
+		{name: "no key", body: `{"a":1}`, status: http.StatusCreated, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(tt.key, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, rec); code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", code, tt.wantCode)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+			if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.replayed)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// LogSampler logs 1 in N successful requests per route pattern while always
+// logging errors and slow requests. The decision is derived from the request
+// ID, so every layer that calls Sampled for a request agrees with it.
+type LogSampler struct {
+	slow time.Duration
+
+	mu     sync.RWMutex
+	rates  map[string]int    // route pattern -> N; patterns not listed are always logged
+	labels map[string]string // chi's unjoined route patterns -> the route pattern, built once per route
+
+	seen   sync.Map // route pattern -> *atomic.Int64
+	logged sync.Map // route pattern -> *atomic.Int64
+}
+
+func NewLogSampler(slow time.Duration, rates map[string]int) *LogSampler {
+	s := &LogSampler{slow: slow, labels: make(map[string]string)}
+	s.SetRates(rates)
+	return s
+}
+
+// SetRates replaces the per-route sampling rates; safe to call at runtime
+func (s *LogSampler) SetRates(rates map[string]int) {
+	copied := make(map[string]int, len(rates))
+	for pattern, n := range rates {
+		copied[pattern] = n
+	}
+
+	s.mu.Lock()
+	s.rates = copied
+	s.mu.Unlock()
+}
+
+// Rates returns the current per-route sampling rates
+func (s *LogSampler) Rates() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]int, len(s.rates))
+	for pattern, n := range s.rates {
+		out[pattern] = n
+	}
+	return out
+}
+
+// RoutePatterns lists every route registered on routes as chi reports it through
+// RoutePattern once a request matched it, which is how LogSampler keys its rates.
+// Walk spells a mounted router's root with a trailing slash; RoutePattern doesn't.
+func RoutePatterns(routes chi.Routes) (map[string]bool, error) {
+	patterns := make(map[string]bool)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.ReplaceAll(route, "/*/", "/")
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		patterns[route] = true
+		return nil
+	})
+	return patterns, err
+}
+
+// CheckRates reports rates that can never apply: patterns no route on routes matches,
+// which would silently leave a route unsampled, and rates below 1
+func CheckRates(routes chi.Routes, rates map[string]int) error {
+	patterns, err := RoutePatterns(routes)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for pattern, n := range rates {
+		if !patterns[pattern] {
+			problems = append(problems, fmt.Sprintf("%q matches no route", pattern))
+		} else if n < 1 {
+			problems = append(problems, fmt.Sprintf("%q has rate %d, want at least 1", pattern, n))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("log sampling rates: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Counts returns requests seen and logged per route pattern so totals stay derivable
+func (s *LogSampler) Counts() (seen, logged map[string]int64) {
+	seen, logged = make(map[string]int64), make(map[string]int64)
+	s.seen.Range(func(k, v any) bool {
+		seen[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	s.logged.Range(func(k, v any) bool {
+		logged[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	return seen, logged
+}
+
+// Sampled reports whether successful-path log lines for the request should be written
+func (s *LogSampler) Sampled(r *http.Request, pattern string) bool {
+	s.mu.RLock()
+	n, ok := s.rates[pattern]
+	s.mu.RUnlock()
+	if !ok || n <= 1 {
+		return true
+	}
+
+	id := chimw.GetReqID(r.Context())
+	if id == "" {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return h.Sum32()%uint32(n) == 0
+}
+
+func (s *LogSampler) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := statusWriters.Get().(*statusWriter)
+		ww.ResponseWriter, ww.status = w, 0
+		next.ServeHTTP(ww, r)
+		elapsed := time.Since(start)
+		status := ww.status
+		ww.ResponseWriter = nil
+		statusWriters.Put(ww)
+
+		pattern := s.routeLabel(r)
+		counter(&s.seen, pattern).Add(1)
+
+		if status == 0 {
+			status = http.StatusOK
+		}
+		bypass := status >= http.StatusInternalServerError || (s.slow > 0 && elapsed >= s.slow)
+		sampled := !bypass && s.rateFor(pattern) > 1
+		if !bypass && !s.Sampled(r, pattern) {
+			return
+		}
+		counter(&s.logged, pattern).Add(1)
+
+		// Built by hand rather than with log.Printf, which allocates for every argument
+		line := logLines.Get().(*[]byte)
+		b := append((*line)[:0], r.Method...)
+		b = append(b, ' ')
+		b = append(b, r.RequestURI...)
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, int64(status), 10)
+		b = append(b, ' ')
+		b = append(b, elapsed.String()...)
+		b = append(b, " sampled="...)
+		b = strconv.AppendBool(b, sampled)
+		log.Output(1, string(b))
+		*line = b
+		logLines.Put(line)
+	})
+}
+
+// routeLabel returns the pattern of the route r matched, spelled as chi's RoutePattern
+// spells it, or r's path when no route matched. Joining the patterns of mounted routers
+// allocates, so each route's label is built once and looked up after that.
+func (s *LogSampler) routeLabel(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || len(rctx.RoutePatterns) == 0 {
+		return r.URL.Path
+	}
+	var buf [128]byte
+	key := buf[:0]
+	for _, p := range rctx.RoutePatterns {
+		key = append(key, p...)
+	}
+
+	s.mu.RLock()
+	label, ok := s.labels[string(key)]
+	s.mu.RUnlock()
+	if !ok {
+		label = rctx.RoutePattern()
+		s.mu.Lock()
+		s.labels[string(key)] = label
+		s.mu.Unlock()
+	}
+	if label == "" {
+		return r.URL.Path
+	}
+	return label
+}
+
+func (s *LogSampler) rateFor(pattern string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rates[pattern]
+}
+
+func counter(m *sync.Map, key string) *atomic.Int64 {
+	if v, ok := m.Load(key); ok {
+		return v.(*atomic.Int64)
+	}
+	v, _ := m.LoadOrStore(key, new(atomic.Int64))
+	return v.(*atomic.Int64)
+}
+
+// statusWriter records the status a handler writes. They are pooled, so one must
+// not be kept past the request it was taken for.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+var statusWriters = sync.Pool{New: func() any { return new(statusWriter) }}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers, such as the CSV export, flush through the wrapper
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logLines are the buffers request log lines are built in
+var logLines = sync.Pool{New: func() any {
+	line := make([]byte, 0, 256)
+	return &line
+}}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// sampledRouter mirrors the server's layout: the sampler wraps an /api router with a mounted events router
+func sampledRouter(s *LogSampler, events http.HandlerFunc) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(chimw.RequestID)
+	r.Use(s.Handler)
+	r.Route("/api", func(r chi.Router) {
+		sub := chi.NewRouter()
+		sub.Post("/", events)
+		r.Mount("/events", sub)
+		r.Get("/users/{userID}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	return r
+}
+
+func discardLogs(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func TestLogSamplerRatio(t *testing.T) {
+	discardLogs(t)
+	s := NewLogSampler(0, map[string]int{"/api/events": 100})
+	r := sampledRouter(s, func(w http.ResponseWriter, r *http.Request) {})
+
+	const requests = 20000
+	for i := range requests {
+		req := httptest.NewRequest(http.MethodPost, "/api/events", nil)
+		req.Header.Set(chimw.RequestIDHeader, "req-"+strconv.Itoa(i))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	seen, logged := s.Counts()
+	if seen["/api/events"] != requests {
+		t.Fatalf("seen = %v, want %d under /api/events", seen, requests)
+	}
+	// 1 in 100 expected; allow for hashing noise
+	if got := logged["/api/events"]; got < requests/200 || got > requests/50 {
+		t.Errorf("logged %d of %d, want about %d", got, requests, requests/100)
+	}
+}
+
+func TestLogSamplerBypass(t *testing.T) {
+	discardLogs(t)
+
+	tests := []struct {
+		name    string
+		slow    time.Duration
+		handler http.HandlerFunc
+		rate    int
+		want    int64
+	}{
+		{name: "sampled out", rate: 1 << 30, handler: func(w http.ResponseWriter, r *http.Request) {}, want: 0},
+		{name: "server errors always logged", rate: 1 << 30, handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, want: 10},
+		{name: "slow requests always logged", rate: 1 << 30, slow: time.Nanosecond, handler: func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Microsecond)
+		}, want: 10},
+		{name: "unlisted route always logged", handler: func(w http.ResponseWriter, r *http.Request) {}, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates := map[string]int{}
+			if tt.rate > 0 {
+				rates["/api/events"] = tt.rate
+			}
+			s := NewLogSampler(tt.slow, rates)
+			r := sampledRouter(s, tt.handler)
+			for range 10 {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/events", nil))
+			}
+			if _, logged := s.Counts(); logged["/api/events"] != tt.want {
+				t.Errorf("logged = %d, want %d", logged["/api/events"], tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRates(t *testing.T) {
+	r := sampledRouter(NewLogSampler(0, nil), func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		rates   map[string]int
+		wantErr bool
+	}{
+		{name: "mounted root", rates: map[string]int{"/api/events": 100}},
+		{name: "route with parameter", rates: map[string]int{"/api/users/{userID}": 10}},
+		{name: "mounted root with trailing slash", rates: map[string]int{"/api/events/": 100}, wantErr: true},
+		{name: "unknown route", rates: map[string]int{"/api/evnets": 100}, wantErr: true},
+		{name: "rate below one", rates: map[string]int{"/api/events": 0}, wantErr: true},
+		{name: "none", rates: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckRates(r, tt.rates); (err != nil) != tt.wantErr {
+				t.Errorf("CheckRates() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogSamplerKeepsFlusher(t *testing.T) {
+	discardLogs(t)
+	s := NewLogSampler(0, nil)
+	var flushed bool
+	r := sampledRouter(s, func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("handler's writer is not an http.Flusher")
+		}
+		w.Write([]byte("partial"))
+		f.Flush()
+		flushed = true
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events", nil))
+	if !flushed || !rec.Flushed {
+		t.Errorf("flushed = %v, recorder flushed = %v; want the flush passed through", flushed, rec.Flushed)
+	}
+	if seen, _ := s.Counts(); seen["/api/events"] != 1 {
+		t.Errorf("seen = %v, want the request counted under /api/events", seen)
+	}
+}
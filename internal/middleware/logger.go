@@ -3,7 +3,10 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"runtime/debug"
 	"time"
+
+	"example.com/monolithic/internal/handlers/respond"
 )
 
 func Logger(next http.Handler) http.Handler {
@@ -27,24 +30,38 @@ func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) ht
 	}
 }
 
-// Recoverer middleware
+// Recoverer turns a panic into a logged stack trace and a 500 error body in the
+// format the client negotiated. http.ErrAbortHandler is re-raised untouched.
 func Recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				respond.Error(w, r, http.StatusInternalServerError, respond.CodeInternal, "Internal server error")
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
+// Values of the CORS headers, shared by every response
+var (
+	corsOrigin  = []string{"*"}
+	corsMethods = []string{"GET, POST, PUT, DELETE, OPTIONS"}
+	corsHeaders = []string{"Content-Type, Authorization"}
+)
+
 // CORS middleware
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		// Assigned rather than Set, which would allocate the same values for every request
+		h := w.Header()
+		h["Access-Control-Allow-Origin"] = corsOrigin
+		h["Access-Control-Allow-Methods"] = corsMethods
+		h["Access-Control-Allow-Headers"] = corsHeaders
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -54,20 +71,3 @@ func CORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
-
-// Authentication middleware
-func Authentication(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get token from Authorization header
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		// TODO: Implement proper token validation
-		// This is just a placeholder. You should implement proper JWT validation
-
-		next.ServeHTTP(w, r)
-	})
-}
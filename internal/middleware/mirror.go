@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// MirrorConfig configures request mirroring for a route
+type MirrorConfig struct {
+	Handler       http.Handler  // in-process alternate handler; takes precedence over URL
+	URL           *url.URL      // remote base URL; the request path and query are appended
+	Percent       int           // share of eligible requests to mirror (0–100); change it with Mirror.SetPercent
+	MaxBodyBytes  int64         // requests with larger bodies are not mirrored
+	AllowMutating bool          // mirror methods other than GET/HEAD/OPTIONS
+	Timeout       time.Duration // upper bound on each mirrored call
+	Client        *http.Client
+}
+
+// MirrorStats reports mirroring counters
+type MirrorStats struct {
+	Mirrored          int64
+	Succeeded         int64
+	Failed            int64
+	TotalLatencyDelta time.Duration // sum of (mirror - primary) durations across completed mirrors
+}
+
+// Mirror duplicates sampled requests to an alternate target in the background.
+// The primary response is always served first and never depends on the mirror.
+type Mirror struct {
+	cfg          MirrorConfig
+	percent      atomic.Int32
+	mirrored     atomic.Int64
+	succeeded    atomic.Int64
+	failed       atomic.Int64
+	latencyDelta atomic.Int64
+}
+
+func NewMirror(cfg MirrorConfig) *Mirror {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	m := &Mirror{cfg: cfg}
+	m.SetPercent(cfg.Percent)
+	return m
+}
+
+// SetPercent changes the share of eligible requests mirrored, clamped to 0–100. Safe to call at runtime.
+func (m *Mirror) SetPercent(percent int) {
+	percent = max(0, min(100, percent))
+	m.percent.Store(int32(percent))
+}
+
+// Percent returns the share of eligible requests mirrored
+func (m *Mirror) Percent() int {
+	return int(m.percent.Load())
+}
+
+// Stats returns the current mirroring counters
+func (m *Mirror) Stats() MirrorStats {
+	return MirrorStats{
+		Mirrored:          m.mirrored.Load(),
+		Succeeded:         m.succeeded.Load(),
+		Failed:            m.failed.Load(),
+		TotalLatencyDelta: time.Duration(m.latencyDelta.Load()),
+	}
+}
+
+// Handler is the middleware that mirrors requests reaching next
+func (m *Mirror) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.eligible(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, ok := m.bufferBody(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mirror := r.Clone(context.WithoutCancel(r.Context()))
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		primary := time.Since(start)
+
+		m.mirrored.Add(1)
+		go m.dispatch(mirror, body, primary)
+	})
+}
+
+func (m *Mirror) eligible(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !m.cfg.AllowMutating {
+			return false
+		}
+	}
+
+	p := m.percent.Load()
+	return p > 0 && (p >= 100 || rand.Int32N(100) < p)
+}
+
+// bufferBody copies up to MaxBodyBytes of the body so both paths can read it.
+// Oversized bodies are stitched back together for the primary and not mirrored.
+func (m *Mirror) bufferBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, m.cfg.MaxBodyBytes+1))
+	if err != nil || int64(len(buf)) > m.cfg.MaxBodyBytes {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return nil, false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(buf))
+	return buf, true
+}
+
+func (m *Mirror) dispatch(r *http.Request, body []byte, primary time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), m.cfg.Timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	start := time.Now()
+	var err error
+	if m.cfg.Handler != nil {
+		err = m.serveInProcess(r)
+	} else {
+		err = m.serveRemote(r, body)
+	}
+	m.latencyDelta.Add(int64(time.Since(start) - primary))
+
+	if err != nil {
+		m.failed.Add(1)
+		log.Printf("mirror %s %s failed: %v", r.Method, r.URL.Path, err)
+		return
+	}
+	m.succeeded.Add(1)
+}
+
+// serveInProcess runs the alternate handler, isolating its panics and giving up once the mirror timeout expires
+func (m *Mirror) serveInProcess(r *http.Request) error {
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				result <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+
+		w := &discardResponseWriter{header: make(http.Header), status: http.StatusOK}
+		m.cfg.Handler.ServeHTTP(w, r)
+		if w.status >= http.StatusInternalServerError {
+			result <- fmt.Errorf("status %d", w.status)
+			return
+		}
+		result <- nil
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
+
+func (m *Mirror) serveRemote(r *http.Request, body []byte) error {
+	target := *m.cfg.URL
+	target.Path = target.Path + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = r.Header.Clone()
+
+	resp, err := m.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// discardResponseWriter records only the status of a mirrored in-process response
+type discardResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(status int)      { w.status = status }
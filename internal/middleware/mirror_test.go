@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// primaryHandler answers every request with 200 "primary" and echoes nothing of the mirror
+var primaryHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	w.Write([]byte("primary"))
+})
+
+// waitForMirrors waits until every mirrored request has finished
+func waitForMirrors(t *testing.T, m *Mirror) MirrorStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := m.Stats()
+		if stats.Succeeded+stats.Failed == stats.Mirrored || time.Now().After(deadline) {
+			return stats
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMirrorPrimaryUnaffected(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	hang := make(chan struct{})
+	t.Cleanup(func() { close(hang) })
+
+	tests := []struct {
+		name       string
+		mirror     http.HandlerFunc
+		wantFailed int64
+	}{
+		{name: "mirror writes its own response", mirror: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Mirror", "yes")
+			w.WriteHeader(http.StatusTeapot)
+			w.Write([]byte("mirror"))
+		}},
+		{name: "mirror fails", mirror: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, wantFailed: 1},
+		{name: "mirror panics", mirror: func(w http.ResponseWriter, r *http.Request) {
+			panic("mirror broke")
+		}, wantFailed: 1},
+		{name: "mirror hangs", mirror: func(w http.ResponseWriter, r *http.Request) {
+			<-hang
+		}, wantFailed: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMirror(MirrorConfig{Handler: tt.mirror, Percent: 100, Timeout: 50 * time.Millisecond})
+
+			start := time.Now()
+			rec := httptest.NewRecorder()
+			m.Handler(primaryHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users?q=a", nil))
+			if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+				t.Errorf("primary took %s, waiting on the mirror", elapsed)
+			}
+			if rec.Code != http.StatusOK || rec.Body.String() != "primary" || rec.Header().Get("X-Mirror") != "" {
+				t.Errorf("primary response = %d %q with headers %v", rec.Code, rec.Body, rec.Header())
+			}
+
+			stats := waitForMirrors(t, m)
+			if stats.Mirrored != 1 || stats.Failed != tt.wantFailed {
+				t.Errorf("stats = %+v, want 1 mirrored with %d failed", stats, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestMirrorRemoteResponseDiscarded(t *testing.T) {
+	var got atomic.Value
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.URL.String() + " " + r.Header.Get("Authorization"))
+		w.Header().Set("Set-Cookie", "mirror=1")
+		w.Write([]byte("mirror"))
+	}))
+	defer remote.Close()
+	target, _ := url.Parse(remote.URL + "/candidate")
+
+	m := NewMirror(MirrorConfig{URL: target, Percent: 100})
+	req := httptest.NewRequest(http.MethodGet, "/api/users?q=a", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	m.Handler(primaryHandler).ServeHTTP(rec, req)
+
+	if rec.Body.String() != "primary" || rec.Header().Get("Set-Cookie") != "" {
+		t.Errorf("primary response = %q with headers %v", rec.Body, rec.Header())
+	}
+	if stats := waitForMirrors(t, m); stats.Succeeded != 1 {
+		t.Fatalf("stats = %+v, want 1 succeeded", stats)
+	}
+	if want := "/candidate/api/users?q=a Bearer token"; got.Load() != want {
+		t.Errorf("remote received %q, want %q", got.Load(), want)
+	}
+}
+
+func TestMirrorEligibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		percent       int
+		allowMutating bool
+		want          int64
+	}{
+		{name: "get", method: http.MethodGet, percent: 100, want: 1},
+		{name: "head", method: http.MethodHead, percent: 100, want: 1},
+		{name: "post", method: http.MethodPost, body: `{}`, percent: 100},
+		{name: "put", method: http.MethodPut, body: `{}`, percent: 100},
+		{name: "patch", method: http.MethodPatch, body: `{}`, percent: 100},
+		{name: "delete", method: http.MethodDelete, percent: 100},
+		{name: "post when allowed", method: http.MethodPost, body: `{}`, percent: 100, allowMutating: true, want: 1},
+		{name: "oversized body", method: http.MethodPost, body: strings.Repeat("x", 100), percent: 100, allowMutating: true},
+		{name: "zero percent", method: http.MethodGet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mirrored atomic.Int64
+			m := NewMirror(MirrorConfig{
+				Handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { mirrored.Add(1) }),
+				Percent:       tt.percent,
+				MaxBodyBytes:  10,
+				AllowMutating: tt.allowMutating,
+			})
+
+			var primaryBody string
+			primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				primaryBody = string(b)
+			})
+			m.Handler(primary).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/api/users", strings.NewReader(tt.body)))
+
+			if primaryBody != tt.body {
+				t.Errorf("primary read body %q, want %q", primaryBody, tt.body)
+			}
+			waitForMirrors(t, m)
+			if mirrored.Load() != tt.want {
+				t.Errorf("mirrored %d requests, want %d", mirrored.Load(), tt.want)
+			}
+		})
+	}
+}
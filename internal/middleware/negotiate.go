@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// Negotiate rejects requests whose Accept header allows none of the media types
+// the response envelope can be written as, before the handler runs
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !respond.Acceptable(r) {
+			respond.ErrorDetails(w, r, http.StatusNotAcceptable, respond.CodeNotAcceptable,
+				"None of the requested media types can be produced",
+				map[string]interface{}{"supported": respond.Offered})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		accept  string
+		wantRun bool
+	}{
+		{name: "no preference", accept: "", wantRun: true},
+		{name: "json", accept: "application/json", wantRun: true},
+		{name: "xml by q-value", accept: "application/json;q=0.2, application/xml;q=0.8", wantRun: true},
+		{name: "wildcard", accept: "text/html, */*;q=0.1", wantRun: true},
+		{name: "problem documents only", accept: "application/problem+json", wantRun: true},
+		{name: "html only", accept: "text/html"},
+		{name: "every offered type excluded", accept: "application/json;q=0, application/xml;q=0, text/*;q=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			h := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ran = true }))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if ran != tt.wantRun {
+				t.Fatalf("handler ran = %v, want %v", ran, tt.wantRun)
+			}
+			if tt.wantRun {
+				return
+			}
+
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Details struct {
+						Supported []string `json:"supported"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body, err)
+			}
+			if rec.Code != http.StatusNotAcceptable || body.Error.Code != respond.CodeNotAcceptable {
+				t.Errorf("status = %d, code = %q, want 406 %s", rec.Code, body.Error.Code, respond.CodeNotAcceptable)
+			}
+			if !slices.Equal(body.Error.Details.Supported, respond.Offered) {
+				t.Errorf("supported = %v, want %v", body.Error.Details.Supported, respond.Offered)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// RateLimiter decides whether the caller identified by key may make another request.
+// When it may not, retryAfter says how long until it can.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RateLimit caps how fast one caller can send mutating requests (POST, PUT, PATCH, DELETE).
+// Callers are keyed by authenticated user ID, or by client IP for anonymous requests,
+// so it must run after Authentication and RealIP. Limiter errors let the request through.
+func RateLimit(limiter RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// RealIP leaves the port on RemoteAddr when no proxy header was sent
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			key := "ip:" + host
+			if userID, ok := UserID(r.Context()); ok {
+				key = "user:" + userID
+			}
+
+			allowed, retryAfter, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				log.Printf("rate limit: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				setRetryAfter(w, retryAfter)
+				respond.Error(w, r, http.StatusTooManyRequests, respond.CodeTooManyRequests, "Too many requests")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRetryAfter writes wait as Retry-After in whole seconds, rounding up to at least one
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+}
+
+// MemoryRateLimiter is an in-process token bucket per key: each key may burst
+// up to Burst requests and then sustains Rate requests per second.
+type MemoryRateLimiter struct {
+	rate  float64
+	burst float64
+	// idle is how long a bucket takes to refill completely; after that it's
+	// indistinguishable from a new one and can be forgotten
+	idle time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var _ RateLimiter = (*MemoryRateLimiter)(nil)
+
+func NewMemoryRateLimiter(rate float64, burst int) *MemoryRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &MemoryRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		idle:    time.Duration(float64(burst) / rate * float64(time.Second)),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.idle {
+		l.sweep(now)
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait, nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// sweep forgets buckets that have refilled completely
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// bucketState is a token bucket as saved across a restart
+type bucketState struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// MarshalState encodes the buckets that haven't refilled yet, for state.File
+func (l *MemoryRateLimiter) MarshalState() ([]byte, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	buckets := make(map[string]bucketState, len(l.buckets))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) < l.idle {
+			buckets[key] = bucketState{Tokens: bucket.tokens, Last: bucket.last}
+		}
+	}
+	l.mu.Unlock()
+
+	return json.Marshal(buckets)
+}
+
+// RestoreState loads buckets saved by MarshalState. Tokens refill for the time the
+// server was down, and buckets already seen since startup are kept as they are.
+func (l *MemoryRateLimiter) RestoreState(data []byte) error {
+	var buckets map[string]bucketState
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return err
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, saved := range buckets {
+		if _, ok := l.buckets[key]; ok || math.IsNaN(saved.Tokens) {
+			continue
+		}
+		// A clock that went backwards must not leave a bucket unable to refill
+		last := saved.Last
+		if last.After(now) {
+			last = now
+		}
+		l.buckets[key] = &tokenBucket{tokens: math.Max(0, math.Min(l.burst, saved.Tokens)), last: last}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/handlers/respond"
+	"example.com/monolithic/internal/platform/state"
+)
+
+func TestRateLimitRefusesWithEnvelope(t *testing.T) {
+	handler := RateLimit(NewMemoryRateLimiter(1, 1))(okHandler)
+	send := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/users", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(http.MethodPost); rec.Code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", rec.Code)
+	}
+	if rec := send(http.MethodGet); rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want reads never limited", rec.Code)
+	}
+	rec := send(http.MethodPost)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", rec.Code)
+	}
+	if code := errorCode(t, rec); code != respond.CodeTooManyRequests {
+		t.Errorf("error code = %q, want %q", code, respond.CodeTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+}
+
+func TestMemoryRateLimiterKeysAreIndependent(t *testing.T) {
+	limiter := NewMemoryRateLimiter(1, 2)
+	ctx := context.Background()
+
+	for i := range 2 {
+		if ok, _, _ := limiter.Allow(ctx, "a"); !ok {
+			t.Fatalf("request %d within burst refused", i)
+		}
+	}
+	if ok, wait, _ := limiter.Allow(ctx, "a"); ok || wait <= 0 || wait > time.Second {
+		t.Errorf("Allow() past burst = %v with wait %v, want refused within a second", ok, wait)
+	}
+	if ok, _, _ := limiter.Allow(ctx, "b"); !ok {
+		t.Error("another key was limited")
+	}
+}
+
+func TestRateLimitRetryAfterFollowsDeficit(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		sent  int
+		want  string
+	}{
+		{name: "one per second", rate: 1, burst: 1, sent: 1, want: "1"},
+		{name: "one every four seconds", rate: 0.25, burst: 1, sent: 1, want: "4"},
+		{name: "one every ten seconds", rate: 0.1, burst: 3, sent: 3, want: "10"},
+		{name: "fast refill rounds up", rate: 20, burst: 1, sent: 1, want: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RateLimit(NewMemoryRateLimiter(tt.rate, tt.burst))(okHandler)
+			var rec *httptest.ResponseRecorder
+			for range tt.sent + 1 {
+				req := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+			}
+			if rec.Code != http.StatusTooManyRequests {
+				t.Fatalf("request past the burst = %d, want 429", rec.Code)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.want {
+				t.Errorf("Retry-After = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	quiet := log.New(io.Discard, "", 0)
+	send := func(handler http.Handler, addr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Before the restart: one client uses up its burst, another sends a single request
+	before := NewMemoryRateLimiter(0.01, 2)
+	handler := RateLimit(before)(okHandler)
+	for range 2 {
+		send(handler, "192.0.2.1:1234")
+	}
+	send(handler, "192.0.2.2:1234")
+	if code := send(handler, "192.0.2.1:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("request past the burst = %d, want 429", code)
+	}
+	saved := state.NewFile(path, time.Minute, quiet)
+	saved.Register("rate_limit", before)
+	if err := saved.Save(time.Now()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		restoreAt   time.Time
+		wantLimited bool
+	}{
+		{name: "fresh state", restoreAt: time.Now(), wantLimited: true},
+		{name: "stale state", restoreAt: time.Now().Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := NewMemoryRateLimiter(0.01, 2)
+			restored := state.NewFile(path, time.Minute, quiet)
+			restored.Register("rate_limit", after)
+			restored.Restore(tt.restoreAt)
+			handler := RateLimit(after)(okHandler)
+
+			wantCode := http.StatusOK
+			if tt.wantLimited {
+				wantCode = http.StatusTooManyRequests
+			}
+			if code := send(handler, "192.0.2.1:1234"); code != wantCode {
+				t.Errorf("throttled client after restart = %d, want %d", code, wantCode)
+			}
+			// The other client kept the one request it had left, and no more
+			if code := send(handler, "192.0.2.2:1234"); code != http.StatusOK {
+				t.Errorf("other client's first request after restart = %d, want 200", code)
+			}
+			if code := send(handler, "192.0.2.2:1234"); (code == http.StatusTooManyRequests) != tt.wantLimited {
+				t.Errorf("other client's second request after restart = %d, want limited %v", code, tt.wantLimited)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimiterRestoreStateRejectsCorruptData(t *testing.T) {
+	limiter := NewMemoryRateLimiter(1, 2)
+	if err := limiter.RestoreState([]byte(`{"ip:192.0.2.1":`)); err == nil {
+		t.Error("RestoreState() accepted truncated data")
+	}
+	if ok, _, _ := limiter.Allow(context.Background(), "ip:192.0.2.1"); !ok {
+		t.Error("client limited after a failed restore")
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// requestIDPrefix starts every generated request ID: the host name and a random
+// tag, so IDs from different processes never collide
+var requestIDPrefix = newRequestIDPrefix()
+
+func newRequestIDPrefix() string {
+	hostname, err := os.Hostname()
+	if hostname == "" || err != nil {
+		hostname = "localhost"
+	}
+	var buf [12]byte
+	var tag string
+	for len(tag) < 10 {
+		rand.Read(buf[:])
+		tag = strings.NewReplacer("+", "", "/", "").Replace(base64.StdEncoding.EncodeToString(buf[:]))
+	}
+	return hostname + "/" + tag[:10] + "-"
+}
+
+// RequestID is chi's RequestID without its per-request fmt.Sprintf: it keeps the
+// client's X-Request-Id or generates "<host>/<tag>-<counter>", stored where
+// chimw.GetReqID finds it
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(chimw.RequestIDHeader)
+		if id == "" {
+			id = nextRequestID()
+		}
+		ctx := context.WithValue(r.Context(), chimw.RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// nextRequestID formats the next ID with its counter zero-padded to six digits
+func nextRequestID() string {
+	var buf [96]byte
+	id := append(buf[:0], requestIDPrefix...)
+
+	var digits [20]byte
+	n := strconv.AppendUint(digits[:0], chimw.NextRequestID(), 10)
+	for i := len(n); i < 6; i++ {
+		id = append(id, '0')
+	}
+	return string(append(id, n...))
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	var got []string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, chimw.GetReqID(r.Context()))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(chimw.RequestIDHeader, "client-chosen")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if got[0] != "client-chosen" {
+		t.Errorf("request ID = %q, want the client's", got[0])
+	}
+	generated := regexp.MustCompile(`^.+/[A-Za-z0-9]{10}-\d{6,}$`)
+	for _, id := range got[1:] {
+		if !generated.MatchString(id) {
+			t.Errorf("generated request ID %q, want <host>/<tag>-<counter>", id)
+		}
+	}
+	if got[1] == got[2] {
+		t.Errorf("generated the request ID %q twice", got[1])
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type timeoutKey struct{}
+
+// timeoutHeader is X-Request-Timeout in the canonical form Header's map is keyed by
+const timeoutHeader = "X-Request-Timeout"
+
+// Timeout cancels the request context after d, answering 504 if the handler
+// returns past it, and advertises the effective timeout to clients in
+// X-Request-Timeout (whole seconds). A nested Timeout can only shorten the
+// budget set by an outer one, so the header always reflects the deadline the
+// handler actually runs under.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	// Built once, as every request under this Timeout carries the same ones
+	header := []string{strconv.Itoa(int(d.Seconds()))}
+	var budget interface{} = d
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			effective, value, values := d, budget, header
+			if outer, ok := RequestTimeout(r.Context()); ok && outer < d {
+				effective, value = outer, outer
+				values = []string{strconv.Itoa(int(outer.Seconds()))}
+			}
+
+			w.Header()[timeoutHeader] = values
+			noteTimeout(r.Context(), effective)
+			ctx, cancel := context.WithTimeout(context.WithValue(r.Context(), timeoutKey{}, value), d)
+			defer func() {
+				cancel()
+				if ctx.Err() == context.DeadlineExceeded {
+					w.WriteHeader(http.StatusGatewayTimeout)
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestTimeout returns the effective timeout configured for the request, if any
+func RequestTimeout(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	return d, ok
+}
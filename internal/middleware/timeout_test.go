@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestTimeoutHeader(t *testing.T) {
+	// Laid out like the server: a global timeout, with a group that sets its own
+	r := chi.NewRouter()
+	r.Use(Timeout(60 * time.Second))
+	r.Get("/api/events", func(w http.ResponseWriter, r *http.Request) {})
+	r.Group(func(r chi.Router) {
+		r.Use(Timeout(200 * time.Second))
+		r.Get("/api/users", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(Timeout(5 * time.Second))
+		r.Get("/api/auth", func(w http.ResponseWriter, r *http.Request) {
+			if d, ok := RequestTimeout(r.Context()); !ok || d != 5*time.Second {
+				t.Errorf("RequestTimeout() = %s, %v; want 5s", d, ok)
+			}
+		})
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/events", want: "60"},
+		{path: "/api/users", want: "60"}, // the outer deadline still applies
+		{path: "/api/auth", want: "5"},
+		{path: "/missing", want: "60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := rec.Header().Get("X-Request-Timeout"); got != tt.want {
+				t.Errorf("X-Request-Timeout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"example.com/monolithic/internal/handlers/respond"
+)
+
+// WatchdogConfig controls when an in-flight request is reported as stuck
+type WatchdogConfig struct {
+	// Factor multiplies a request's effective timeout to get the point at which it's reported
+	Factor float64
+	// DefaultTimeout is used for requests that never pass through Timeout
+	DefaultTimeout time.Duration
+	// Interval is how often in-flight requests are checked
+	Interval time.Duration
+	// StackBytes caps the stack excerpt logged per stuck request
+	StackBytes int
+	Logger     *log.Logger
+}
+
+// InFlightRequest describes one request currently being served
+type InFlightRequest struct {
+	RequestID string        `json:"request_id,omitempty"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Client    string        `json:"client"`
+	Start     time.Time     `json:"start"`
+	Age       time.Duration `json:"age_ns"`
+	Overdue   bool          `json:"overdue"`
+}
+
+type watchdogEntry struct {
+	info      InFlightRequest
+	goroutine uint64       // 0 when unknown
+	timeout   atomic.Int64 // effective timeout in ns, set by Timeout
+	reported  bool         // guarded by Watchdog.mu
+}
+
+type watchdogKey struct{}
+
+// Watchdog tracks every in-flight request and logs the ones running far past
+// their timeout, with a stack excerpt of the goroutine serving them. It must
+// be installed outside Recoverer and Timeout so it sees every request.
+type Watchdog struct {
+	cfg WatchdogConfig
+
+	mu       sync.Mutex
+	inflight map[uint64]*watchdogEntry
+	nextID   uint64
+
+	overdue atomic.Int64
+}
+
+func NewWatchdog(cfg WatchdogConfig) *Watchdog {
+	if cfg.Factor <= 0 {
+		cfg.Factor = 2
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.StackBytes <= 0 {
+		cfg.StackBytes = 4 << 10
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	return &Watchdog{cfg: cfg, inflight: make(map[uint64]*watchdogEntry)}
+}
+
+func (wd *Watchdog) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &watchdogEntry{
+			info: InFlightRequest{
+				RequestID: chimw.GetReqID(r.Context()),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Client:    r.RemoteAddr,
+				Start:     time.Now(),
+			},
+			goroutine: currentGoroutine(),
+		}
+
+		wd.mu.Lock()
+		wd.nextID++
+		id := wd.nextID
+		wd.inflight[id] = entry
+		wd.mu.Unlock()
+
+		// Deferred so the entry is removed even when the handler panics
+		defer func() {
+			wd.mu.Lock()
+			delete(wd.inflight, id)
+			wd.mu.Unlock()
+		}()
+
+		ctx := context.WithValue(r.Context(), watchdogKey{}, entry)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Run checks in-flight requests every Interval until ctx is cancelled
+func (wd *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(wd.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			wd.check(now)
+		}
+	}
+}
+
+// Overdue returns how many in-flight requests were past their threshold at the last check
+func (wd *Watchdog) Overdue() int64 {
+	return wd.overdue.Load()
+}
+
+// InFlight returns a snapshot of in-flight requests, oldest first
+func (wd *Watchdog) InFlight() []InFlightRequest {
+	now := time.Now()
+
+	wd.mu.Lock()
+	requests := make([]InFlightRequest, 0, len(wd.inflight))
+	for _, entry := range wd.inflight {
+		info := entry.info
+		info.Age = now.Sub(info.Start)
+		info.Overdue = info.Age > wd.threshold(entry)
+		requests = append(requests, info)
+	}
+	wd.mu.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Start.Before(requests[j].Start) })
+	return requests
+}
+
+// InFlightHandler lists in-flight requests as JSON; mount it behind admin authentication
+func (wd *Watchdog) InFlightHandler(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, r, http.StatusOK, map[string]interface{}{
+		"in_flight": wd.InFlight(),
+		"overdue":   wd.Overdue(),
+	})
+}
+
+func (wd *Watchdog) check(now time.Time) {
+	var stuck []*watchdogEntry
+	var overdue int64
+
+	wd.mu.Lock()
+	for _, entry := range wd.inflight {
+		if now.Sub(entry.info.Start) <= wd.threshold(entry) {
+			continue
+		}
+		overdue++
+		if !entry.reported {
+			entry.reported = true
+			stuck = append(stuck, entry)
+		}
+	}
+	wd.mu.Unlock()
+
+	wd.overdue.Store(overdue)
+	if len(stuck) == 0 {
+		return
+	}
+
+	stacks := allStacks()
+	for _, entry := range stuck {
+		wd.cfg.Logger.Printf("watchdog: request stuck request_id=%s method=%s path=%s client=%s age=%s\n%s",
+			entry.info.RequestID, entry.info.Method, entry.info.Path, entry.info.Client,
+			now.Sub(entry.info.Start).Round(time.Millisecond), wd.excerpt(stacks, entry.goroutine))
+	}
+}
+
+func (wd *Watchdog) threshold(entry *watchdogEntry) time.Duration {
+	timeout := time.Duration(entry.timeout.Load())
+	if timeout == 0 {
+		timeout = wd.cfg.DefaultTimeout
+	}
+	if timeout == 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(float64(timeout) * wd.cfg.Factor)
+}
+
+// excerpt returns the stack of the given goroutine, truncated to StackBytes
+func (wd *Watchdog) excerpt(stacks []byte, goroutine uint64) []byte {
+	header := []byte("goroutine " + strconv.FormatUint(goroutine, 10) + " [")
+	start := bytes.Index(stacks, header)
+	if goroutine == 0 || start < 0 {
+		return []byte("(stack unavailable)")
+	}
+	stack := stacks[start:]
+	if end := bytes.Index(stack, []byte("\n\n")); end >= 0 {
+		stack = stack[:end]
+	}
+	if len(stack) > wd.cfg.StackBytes {
+		stack = stack[:wd.cfg.StackBytes]
+	}
+	return stack
+}
+
+// noteTimeout records the effective timeout of the request on its watchdog entry, if any
+func noteTimeout(ctx context.Context, d time.Duration) {
+	if entry, ok := ctx.Value(watchdogKey{}).(*watchdogEntry); ok {
+		entry.timeout.Store(int64(d))
+	}
+}
+
+// stackHeads hold the first line of a goroutine's stack, which runtime.Stack would otherwise allocate for
+var stackHeads = sync.Pool{New: func() any { return new([64]byte) }}
+
+// currentGoroutine returns the ID of the calling goroutine as printed in stack traces, or 0.
+// It runs for every request, so it parses the ID in place rather than through a string.
+func currentGoroutine() uint64 {
+	stack := stackHeads.Get().(*[64]byte)
+	defer stackHeads.Put(stack)
+	buf := stack[:runtime.Stack(stack[:], false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	var id uint64
+	for i, c := range buf {
+		switch {
+		case c >= '0' && c <= '9':
+			id = id*10 + uint64(c-'0')
+		case c == ' ' && i > 0:
+			return id
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+func allStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
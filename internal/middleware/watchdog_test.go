@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWatchdog(buf *bytes.Buffer) *Watchdog {
+	return NewWatchdog(WatchdogConfig{Factor: 2, Logger: log.New(buf, "", 0)})
+}
+
+// waitInFlight polls until the watchdog tracks n requests
+func waitInFlight(t *testing.T, wd *Watchdog, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(wd.InFlight()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("in-flight = %+v, want %d requests", wd.InFlight(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWatchdogReportsHangingHandler(t *testing.T) {
+	var logs bytes.Buffer
+	wd := newTestWatchdog(&logs)
+
+	release := make(chan struct{})
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		<-release // ignores its context, like a handler stuck on a lock
+	}
+	h := wd.Handler(Timeout(time.Second)(http.HandlerFunc(hang)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+	}()
+	waitInFlight(t, wd, 1)
+
+	// Within Factor x timeout the request is not reported
+	wd.check(time.Now())
+	if wd.Overdue() != 0 || logs.Len() != 0 {
+		t.Fatalf("overdue = %d, logs = %q before the threshold", wd.Overdue(), logs.String())
+	}
+
+	wd.check(time.Now().Add(3 * time.Second))
+	if wd.Overdue() != 1 {
+		t.Errorf("Overdue() = %d, want 1", wd.Overdue())
+	}
+	out := logs.String()
+	for _, want := range []string{"watchdog: request stuck", "path=/api/slow", "TestWatchdogReportsHangingHandler"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+	if requests := wd.InFlight(); len(requests) != 1 || requests[0].Path != "/api/slow" {
+		t.Errorf("InFlight() = %+v, want the hanging request", requests)
+	}
+
+	// A request is reported once, however long it stays stuck
+	logs.Reset()
+	wd.check(time.Now().Add(time.Minute))
+	if logs.Len() != 0 {
+		t.Errorf("stuck request reported again: %q", logs.String())
+	}
+
+	close(release)
+	<-done
+	if requests := wd.InFlight(); len(requests) != 0 {
+		t.Errorf("InFlight() after completion = %+v, want none", requests)
+	}
+	wd.check(time.Now().Add(time.Minute))
+	if wd.Overdue() != 0 {
+		t.Errorf("Overdue() after completion = %d, want 0", wd.Overdue())
+	}
+}
+
+func TestWatchdogDrains(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantCode int
+	}{
+		{
+			name:     "completed",
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "timed out",
+			handler:  func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() },
+			wantCode: http.StatusGatewayTimeout,
+		},
+		{
+			name:     "panicked",
+			handler:  func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	log.SetOutput(io.Discard) // Recoverer logs the panic
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			wd := newTestWatchdog(&logs)
+			h := wd.Handler(Recoverer(Timeout(20 * time.Millisecond)(tt.handler)))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if requests := wd.InFlight(); len(requests) != 0 {
+				t.Errorf("InFlight() = %+v, want none", requests)
+			}
+			wd.check(time.Now().Add(time.Minute))
+			if wd.Overdue() != 0 || logs.Len() != 0 {
+				t.Errorf("overdue = %d, logs = %q for a finished request", wd.Overdue(), logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// BreakerConfig opens the circuit once Threshold acquisitions are refused with
+// ErrPoolSaturated within Window. While open, every acquisition fails at once
+// with ErrCircuitOpen, for Cooldown. A zero Threshold disables the breaker.
+type BreakerConfig struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+}
+
+// breaker counts saturation events and holds the circuit open after too many
+type breaker struct {
+	cfg BreakerConfig
+
+	mu          sync.Mutex
+	windowStart time.Time
+	events      int
+	openUntil   time.Time
+	trips       int64
+}
+
+// remaining reports how much longer the circuit stays open at now; 0 means it is closed
+func (b *breaker) remaining(now time.Time) time.Duration {
+	if b.cfg.Threshold <= 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(0, b.openUntil.Sub(now))
+}
+
+// record counts a saturation event at now and opens the circuit on reaching the threshold
+func (b *breaker) record(now time.Time) {
+	if b.cfg.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Sub(b.windowStart) >= b.cfg.Window {
+		b.windowStart = now
+		b.events = 0
+	}
+	b.events++
+	if b.events >= b.cfg.Threshold {
+		b.openUntil = now.Add(b.cfg.Cooldown)
+		b.events = 0
+		b.trips++
+	}
+}
+
+func (b *breaker) tripCount() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.trips
+}
+
+// breakerState is a breaker as saved across a restart
+type breakerState struct {
+	WindowStart time.Time `json:"window_start"`
+	Events      int       `json:"events"`
+	OpenUntil   time.Time `json:"open_until"`
+	Trips       int64     `json:"trips"`
+}
+
+func (b *breaker) marshalState() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return json.Marshal(breakerState{WindowStart: b.windowStart, Events: b.events, OpenUntil: b.openUntil, Trips: b.trips})
+}
+
+func (b *breaker) restoreState(data []byte) error {
+	var saved breakerState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.windowStart, b.events, b.openUntil, b.trips = saved.WindowStart, saved.Events, saved.OpenUntil, saved.Trips
+	// A cooldown saved under a longer Cooldown setting is cut to the current one
+	if limit := time.Now().Add(b.cfg.Cooldown); b.openUntil.After(limit) {
+		b.openUntil = limit
+	}
+	return nil
+}
+
+// MarshalState encodes the circuit breaker, so an open circuit stays open across a restart
+func (db *DB) MarshalState() ([]byte, error) {
+	return db.breaker.marshalState()
+}
+
+// RestoreState loads a circuit breaker saved by MarshalState
+func (db *DB) RestoreState(data []byte) error {
+	return db.breaker.restoreState(data)
+}
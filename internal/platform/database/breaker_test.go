@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	start := time.Now()
+	b := &breaker{cfg: BreakerConfig{Threshold: 3, Window: time.Second, Cooldown: 5 * time.Second}}
+
+	b.record(start)
+	b.record(start.Add(500 * time.Millisecond))
+	if b.remaining(start.Add(500*time.Millisecond)) != 0 {
+		t.Fatal("opened below the threshold")
+	}
+
+	// The window has passed, so counting starts over
+	b.record(start.Add(1500 * time.Millisecond))
+	b.record(start.Add(1600 * time.Millisecond))
+	if b.remaining(start.Add(1600*time.Millisecond)) != 0 {
+		t.Fatal("counted saturation from an earlier window")
+	}
+
+	b.record(start.Add(1700 * time.Millisecond))
+	if got := b.remaining(start.Add(2700 * time.Millisecond)); got != 4*time.Second {
+		t.Errorf("remaining = %s, want 4s of the cooldown", got)
+	}
+	if got := b.remaining(start.Add(6700 * time.Millisecond)); got != 0 {
+		t.Errorf("remaining after cooldown = %s, want closed", got)
+	}
+	if b.tripCount() != 1 {
+		t.Errorf("trips = %d, want 1", b.tripCount())
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := &breaker{}
+	now := time.Now()
+	for range 100 {
+		b.record(now)
+	}
+	if b.remaining(now) != 0 || b.tripCount() != 0 {
+		t.Error("a breaker without a threshold opened")
+	}
+}
+
+func TestAcquireOpensBreakerOnSaturation(t *testing.T) {
+	// Every slot is taken, so acquire is refused before it reaches the pool
+	db := &DB{
+		slots:   make(chan struct{}, 1),
+		breaker: breaker{cfg: BreakerConfig{Threshold: 2, Window: time.Minute, Cooldown: time.Minute}},
+	}
+	db.slots <- struct{}{}
+
+	want := []error{ErrPoolSaturated, ErrPoolSaturated, ErrCircuitOpen, ErrCircuitOpen}
+	for i, wantErr := range want {
+		if _, _, err := db.acquire(context.Background()); !errors.Is(err, wantErr) {
+			t.Fatalf("acquire #%d error = %v, want %v", i+1, err, wantErr)
+		}
+	}
+
+	// The open breaker refuses even once a slot frees up
+	<-db.slots
+	if _, _, err := db.acquire(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("acquire with a free slot error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if !IsPoolSaturated(ErrCircuitOpen) {
+		t.Error("IsPoolSaturated(ErrCircuitOpen) = false; repositories would not map it to unavailable")
+	}
+
+	stats := db.Stats()
+	if stats.Rejected != 5 || stats.CircuitTrips != 1 || stats.CircuitOpen <= 0 {
+		t.Errorf("stats = %+v, want 5 rejected and an open breaker", stats)
+	}
+	if got := db.RetryAfter(); got < 59*time.Second || got > time.Minute {
+		t.Errorf("RetryAfter() = %s, want the rest of the minute's cooldown", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		conns   int32
+		waiting int64
+		hold    time.Duration
+		want    time.Duration
+	}{
+		{name: "no history", conns: 4, waiting: 10, want: 0},
+		{name: "nobody queued", conns: 4, hold: 100 * time.Millisecond, want: 100 * time.Millisecond},
+		{name: "short queue", conns: 4, waiting: 3, hold: 100 * time.Millisecond, want: 100 * time.Millisecond},
+		{name: "queue of two rounds", conns: 4, waiting: 7, hold: 100 * time.Millisecond, want: 200 * time.Millisecond},
+		{name: "long queue", conns: 2, waiting: 50, hold: 40 * time.Millisecond, want: 26 * 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DB{cfg: Config{MaxPoolSize: tt.conns}}
+			db.waiting.Store(tt.waiting)
+			db.avgHold.Store(int64(tt.hold))
+			if got := db.RetryAfter(); got != tt.want {
+				t.Errorf("RetryAfter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveHold(t *testing.T) {
+	var db DB
+	db.observeHold(80 * time.Millisecond)
+	if got := time.Duration(db.avgHold.Load()); got != 80*time.Millisecond {
+		t.Fatalf("first sample = %s, want 80ms", got)
+	}
+	db.observeHold(160 * time.Millisecond)
+	if got := time.Duration(db.avgHold.Load()); got != 90*time.Millisecond {
+		t.Errorf("average = %s, want 90ms", got)
+	}
+}
+
+func TestBreakerStateSurvivesRestart(t *testing.T) {
+	cfg := BreakerConfig{Threshold: 1, Window: time.Minute, Cooldown: time.Minute}
+	before := &DB{slots: make(chan struct{}, 1), breaker: breaker{cfg: cfg}}
+	before.slots <- struct{}{}
+	before.acquire(context.Background()) // saturated, which opens the circuit
+
+	data, err := before.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState() error = %v", err)
+	}
+
+	// The restarted server has a free slot, but the circuit is still open
+	after := &DB{slots: make(chan struct{}, 1), breaker: breaker{cfg: cfg}}
+	if err := after.RestoreState(data); err != nil {
+		t.Fatalf("RestoreState() error = %v", err)
+	}
+	if _, _, err := after.acquire(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("acquire after restart error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if trips := after.breaker.tripCount(); trips != 1 {
+		t.Errorf("trips = %d, want the 1 from before the restart", trips)
+	}
+
+	// A shorter cooldown configured since caps what's left of the saved one
+	shorter := &DB{breaker: breaker{cfg: BreakerConfig{Threshold: 1, Window: time.Minute, Cooldown: time.Second}}}
+	shorter.RestoreState(data)
+	if got := shorter.breaker.remaining(time.Now()); got > time.Second {
+		t.Errorf("remaining = %s, want at most the new 1s cooldown", got)
+	}
+
+	if err := after.RestoreState([]byte(`{"open_until":`)); err == nil {
+		t.Error("RestoreState() accepted truncated data")
+	}
+}
@@ -13,6 +13,13 @@ var (
 	UniqueViolationCode     = "23505" //pgx.UniqueViolationCode
 	ForeignKeyViolationCode = "23503"
 	CheckViolationCode      = "23514"
+
+	// ErrPoolSaturated is returned instead of queueing once MaxWaiters callers are already waiting for a connection
+	ErrPoolSaturated = errors.New("database connection pool saturated")
+	// ErrCircuitOpen is returned without trying the pool while repeated saturation holds the breaker open
+	ErrCircuitOpen = errors.New("database circuit open")
+	// ErrNoTransaction is returned by DB.LockEntity when ctx carries no transaction to hold the lock
+	ErrNoTransaction = errors.New("entity lock requires a transaction")
 )
 
 // IsNoRowsError checks if the error is a "no rows" error
@@ -27,3 +34,23 @@ func IsUniqueViolation(err error) bool {
 	}
 	return false
 }
+
+// ConstraintFields maps constraint names to the logical field they protect.
+// Each repository declares its own so raw constraint names never reach clients.
+type ConstraintFields map[string]string
+
+// UniqueViolationField returns the logical field behind a unique violation.
+// ok is false when err isn't a unique violation; field is empty for undeclared constraints.
+func (c ConstraintFields) UniqueViolationField(err error) (field string, ok bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != UniqueViolationCode {
+		return "", false
+	}
+	return c[pgErr.ConstraintName], true
+}
+
+// IsPoolSaturated checks if the error was caused by connection pool backpressure,
+// including the breaker that saturation opens
+func IsPoolSaturated(err error) bool {
+	return errors.Is(err, ErrPoolSaturated) || errors.Is(err, ErrCircuitOpen)
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v4"
+)
+
+// ExplainConfig controls query plan sampling. Set it at runtime with DB.SetExplainConfig.
+type ExplainConfig struct {
+	SampleRate      float64       // fraction of statements to explain (0–1)
+	SlowThreshold   time.Duration // statements at least this slow are always eligible; 0 disables
+	Analyze         bool          // use EXPLAIN ANALYZE for read-only statements
+	BudgetPerMinute int           // hard cap on plans captured per minute
+}
+
+// Validate reports settings that would sample nothing or never stop sampling
+func (c ExplainConfig) Validate() error {
+	switch {
+	case c.SampleRate < 0 || c.SampleRate > 1:
+		return errors.New("explain sample rate must be between 0 and 1")
+	case c.SlowThreshold < 0:
+		return errors.New("explain slow threshold must not be negative")
+	case c.SampleRate == 0 && c.SlowThreshold == 0:
+		return errors.New("explain needs a sample rate or a slow threshold")
+	case c.BudgetPerMinute < 1:
+		return errors.New("explain budget per minute must be at least 1")
+	}
+	return nil
+}
+
+var (
+	explainableStatement = regexp.MustCompile(`(?is)^\s*(select|with|values|insert|update|delete)\b`)
+	readOnlyStatement    = regexp.MustCompile(`(?is)^\s*(select|with|values)\b`)
+	mutatingKeyword      = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|truncate|create|drop|alter|copy|grant|revoke|call)\b`)
+)
+
+// explainer samples statements and logs their plans within a per-minute budget
+type explainer struct {
+	mu          sync.Mutex
+	cfg         *ExplainConfig
+	windowStart time.Time
+	used        int
+}
+
+// SetExplainConfig enables plan sampling with cfg, or disables it when cfg is nil
+func (db *DB) SetExplainConfig(cfg *ExplainConfig) {
+	if cfg != nil {
+		copied := *cfg
+		cfg = &copied
+	}
+	db.explain.mu.Lock()
+	defer db.explain.mu.Unlock()
+	db.explain.cfg = cfg
+}
+
+// ExplainConfig returns the plan sampling settings, or nil when sampling is disabled
+func (db *DB) ExplainConfig() *ExplainConfig {
+	db.explain.mu.Lock()
+	defer db.explain.mu.Unlock()
+	if db.explain.cfg == nil {
+		return nil
+	}
+	cfg := *db.explain.cfg
+	return &cfg
+}
+
+// maybeExplain captures the plan of a just-executed statement in the background when it is sampled
+func (db *DB) maybeExplain(ctx context.Context, query string, args []interface{}, elapsed time.Duration) {
+	cfg, ok := db.explain.take(elapsed)
+	if !ok || !explainableStatement.MatchString(query) {
+		return
+	}
+
+	analyze := cfg.Analyze && isReadOnly(query)
+	requestID := middleware.GetReqID(ctx)
+	go db.logPlan(requestID, query, args, elapsed, analyze)
+}
+
+// take reports whether a statement should be explained and consumes budget if so
+func (e *explainer) take(elapsed time.Duration) (ExplainConfig, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cfg == nil {
+		return ExplainConfig{}, false
+	}
+	cfg := *e.cfg
+
+	slow := cfg.SlowThreshold > 0 && elapsed >= cfg.SlowThreshold
+	if !slow && (cfg.SampleRate <= 0 || rand.Float64() >= cfg.SampleRate) {
+		return cfg, false
+	}
+
+	now := time.Now()
+	if now.Sub(e.windowStart) >= time.Minute {
+		e.windowStart = now
+		e.used = 0
+	}
+	if e.used >= cfg.BudgetPerMinute {
+		return cfg, false
+	}
+	e.used++
+	return cfg, true
+}
+
+func (db *DB) logPlan(requestID, query string, args []interface{}, elapsed time.Duration, analyze bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, release, err := db.acquire(ctx)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	// Run inside a read-only transaction that is always rolled back, so even
+	// a misclassified statement cannot change data when ANALYZE executes it
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		log.Printf("explain [%s]: begin failed: %v", requestID, err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	prefix := "EXPLAIN "
+	if analyze {
+		prefix = "EXPLAIN (ANALYZE, BUFFERS) "
+	}
+	rows, err := tx.Query(ctx, prefix+query, args...)
+	if err != nil {
+		log.Printf("explain [%s]: %v", requestID, err)
+		return
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			log.Printf("explain [%s]: %v", requestID, err)
+			return
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("explain [%s]: %v", requestID, err)
+		return
+	}
+
+	log.Printf("explain [%s] took %s: %s\n%s", requestID, elapsed, strings.Join(strings.Fields(query), " "), strings.Join(plan, "\n"))
+}
+
+// isReadOnly reports whether a statement is safe to execute under EXPLAIN ANALYZE
+func isReadOnly(query string) bool {
+	return readOnlyStatement.MatchString(query) && !mutatingKeyword.MatchString(query)
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExplainerBudget(t *testing.T) {
+	e := &explainer{cfg: &ExplainConfig{SampleRate: 1, BudgetPerMinute: 3}}
+
+	taken := 0
+	for range 10 {
+		if _, ok := e.take(time.Millisecond); ok {
+			taken++
+		}
+	}
+	if taken != 3 {
+		t.Fatalf("took %d plans in one minute, want the budget of 3", taken)
+	}
+
+	// The budget refills once the minute is over
+	e.windowStart = e.windowStart.Add(-time.Minute)
+	if _, ok := e.take(time.Millisecond); !ok {
+		t.Error("take() refused a plan in a new minute")
+	}
+}
+
+func TestExplainerSampling(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *ExplainConfig
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "disabled", cfg: nil, elapsed: time.Hour, want: false},
+		{name: "sampled", cfg: &ExplainConfig{SampleRate: 1, BudgetPerMinute: 10}, elapsed: time.Millisecond, want: true},
+		{name: "not sampled", cfg: &ExplainConfig{SampleRate: 0, SlowThreshold: time.Second, BudgetPerMinute: 10}, elapsed: time.Millisecond, want: false},
+		{name: "slow", cfg: &ExplainConfig{SampleRate: 0, SlowThreshold: time.Second, BudgetPerMinute: 10}, elapsed: time.Second, want: true},
+		{name: "slow over budget", cfg: &ExplainConfig{SlowThreshold: time.Second}, elapsed: time.Second, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &explainer{cfg: tt.cfg}
+			if _, ok := e.take(tt.elapsed); ok != tt.want {
+				t.Errorf("take(%s) = %v, want %v", tt.elapsed, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT id FROM users WHERE email = $1", want: true},
+		{query: "  select count(*) from users", want: true},
+		{query: "WITH recent AS (SELECT * FROM events) SELECT * FROM recent", want: true},
+		{query: "VALUES (1), (2)", want: true},
+		{query: "INSERT INTO users (id) VALUES ($1)", want: false},
+		{query: "UPDATE users SET email = $1", want: false},
+		{query: "DELETE FROM users WHERE id = $1", want: false},
+		{query: "WITH gone AS (DELETE FROM users RETURNING id) SELECT * FROM gone", want: false},
+		{query: "SELECT * FROM users FOR UPDATE", want: false},
+		{query: "TRUNCATE users", want: false},
+		{query: "CREATE TABLE t AS SELECT 1", want: false},
+		{query: "SELECT 1; DROP TABLE users", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := isReadOnly(tt.query); got != tt.want {
+				t.Errorf("isReadOnly(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ExplainConfig
+		wantErr bool
+	}{
+		{name: "sample rate", cfg: ExplainConfig{SampleRate: 0.01, BudgetPerMinute: 10}},
+		{name: "slow threshold", cfg: ExplainConfig{SlowThreshold: time.Second, BudgetPerMinute: 10}},
+		{name: "nothing to sample", cfg: ExplainConfig{BudgetPerMinute: 10}, wantErr: true},
+		{name: "sample rate above one", cfg: ExplainConfig{SampleRate: 2, BudgetPerMinute: 10}, wantErr: true},
+		{name: "no budget", cfg: ExplainConfig{SampleRate: 0.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"log"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LeakConfig enables tracking of Rows returned by QueryContext so result sets
+// that are never closed can be traced back to the code that opened them.
+// Set it with DB.SetLeakDetection; tracking costs one atomic load when off.
+type LeakConfig struct {
+	Threshold time.Duration // rows still open after this long are logged; 0 only reports at GC time
+	Logger    *log.Logger
+}
+
+// LeakedRows describes a result set that is still open
+type LeakedRows struct {
+	Query string
+	Age   time.Duration
+	Stack string // where QueryContext was called
+}
+
+// rowsTracker records every open result set while leak detection is enabled
+type rowsTracker struct {
+	cfg atomic.Pointer[LeakConfig]
+
+	mu   sync.Mutex
+	open map[*rowsRecord]struct{}
+}
+
+type rowsRecord struct {
+	query   string
+	opened  time.Time
+	callers []uintptr
+	timer   *time.Timer
+	closed  atomic.Bool
+}
+
+// SetLeakDetection enables Rows leak detection with cfg, or disables it when cfg is nil.
+// Result sets opened while it was enabled stay tracked until they are closed.
+func (db *DB) SetLeakDetection(cfg *LeakConfig) {
+	if cfg != nil && cfg.Logger == nil {
+		copied := *cfg
+		copied.Logger = log.Default()
+		cfg = &copied
+	}
+	db.leaks.cfg.Store(cfg)
+}
+
+// OpenRows returns the tracked result sets that have been open for at least minAge, oldest first
+func (db *DB) OpenRows(minAge time.Duration) []LeakedRows {
+	now := time.Now()
+
+	db.leaks.mu.Lock()
+	leaked := make([]LeakedRows, 0, len(db.leaks.open))
+	for record := range db.leaks.open {
+		if age := now.Sub(record.opened); age >= minAge {
+			leaked = append(leaked, LeakedRows{Query: record.query, Age: age, Stack: record.stack()})
+		}
+	}
+	db.leaks.mu.Unlock()
+
+	sort.Slice(leaked, func(i, j int) bool { return leaked[i].Age > leaked[j].Age })
+	return leaked
+}
+
+// track starts tracking rows when leak detection is enabled
+func (db *DB) track(rows *releasingRows, query string) {
+	cfg := db.leaks.cfg.Load()
+	if cfg == nil {
+		return
+	}
+
+	record := &rowsRecord{query: query, opened: time.Now()}
+	callers := make([]uintptr, 32)
+	// Skip runtime.Callers, track and QueryContext so the stack starts at the caller
+	record.callers = callers[:runtime.Callers(3, callers)]
+
+	db.leaks.mu.Lock()
+	if db.leaks.open == nil {
+		db.leaks.open = make(map[*rowsRecord]struct{})
+	}
+	db.leaks.open[record] = struct{}{}
+	db.leaks.mu.Unlock()
+
+	if cfg.Threshold > 0 {
+		record.timer = time.AfterFunc(cfg.Threshold, func() {
+			if !record.closed.Load() {
+				cfg.Logger.Printf("database: rows still open after %s\nquery: %s\nopened at:\n%s",
+					cfg.Threshold, record.query, record.stack())
+			}
+		})
+	}
+
+	// The finalizer closes over the record only, so untracked rows can still be collected
+	runtime.SetFinalizer(rows, func(*releasingRows) {
+		if !record.closed.Load() {
+			cfg.Logger.Printf("database: rows garbage collected without Close\nquery: %s\nopened at:\n%s",
+				record.query, record.stack())
+			db.untrack(record)
+		}
+	})
+	release := rows.release
+	rows.release = func() {
+		release()
+		db.untrack(record)
+	}
+}
+
+func (db *DB) untrack(record *rowsRecord) {
+	if record == nil || record.closed.Swap(true) {
+		return
+	}
+	if record.timer != nil {
+		record.timer.Stop()
+	}
+
+	db.leaks.mu.Lock()
+	delete(db.leaks.open, record)
+	db.leaks.mu.Unlock()
+}
+
+func (r *rowsRecord) stack() string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(r.callers)
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+		if !more {
+			return b.String()
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// emptyRows is a result set with no rows that needs no connection
+type emptyRows struct {
+	pgx.Rows
+}
+
+func (emptyRows) Next() bool { return false }
+func (emptyRows) Close()     {}
+
+// syncBuffer collects log output written from timer and finalizer goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// query opens a tracked result set the way QueryContext does, so the recorded stack starts at its caller
+func query(db *DB, sql string) *releasingRows {
+	rows := &releasingRows{Rows: emptyRows{}, release: func() {}}
+	db.track(rows, sql)
+	return rows
+}
+
+func newLeakDB(threshold time.Duration) (*DB, *syncBuffer) {
+	logs := &syncBuffer{}
+	db := &DB{}
+	db.SetLeakDetection(&LeakConfig{Threshold: threshold, Logger: log.New(logs, "", 0)})
+	return db, logs
+}
+
+func TestLeakDetectionReportsUnclosedRows(t *testing.T) {
+	db, logs := newLeakDB(10 * time.Millisecond)
+
+	rows := query(db, "SELECT id FROM leaked")
+	defer rows.Close()
+
+	leaked := db.OpenRows(0)
+	if len(leaked) != 1 || leaked[0].Query != "SELECT id FROM leaked" {
+		t.Fatalf("OpenRows() = %+v, want the leaked query", leaked)
+	}
+	if !strings.Contains(leaked[0].Stack, "TestLeakDetectionReportsUnclosedRows") {
+		t.Errorf("stack does not point at the caller:\n%s", leaked[0].Stack)
+	}
+	if got := db.OpenRows(time.Hour); len(got) != 0 {
+		t.Errorf("OpenRows(1h) = %+v, want none that young", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "rows still open after 10ms") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no leak logged past the threshold, logs = %q", logs.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if out := logs.String(); !strings.Contains(out, "SELECT id FROM leaked") || !strings.Contains(out, "TestLeakDetectionReportsUnclosedRows") {
+		t.Errorf("log %q does not name the query and its caller", out)
+	}
+}
+
+func TestLeakDetectionReportsCollectedRows(t *testing.T) {
+	db, logs := newLeakDB(0)
+
+	func() {
+		query(db, "SELECT id FROM dropped") // never closed or kept
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "rows garbage collected without Close") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no leak logged after GC, logs = %q", logs.String())
+		}
+		runtime.GC()
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := db.OpenRows(0); len(got) != 0 {
+		t.Errorf("OpenRows() = %+v, want collected rows untracked", got)
+	}
+}
+
+func TestLeakDetectionCleanPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(*releasingRows)
+	}{
+		{name: "closed", close: func(rows *releasingRows) { rows.Close() }},
+		{name: "read to the end", close: func(rows *releasingRows) {
+			for rows.Next() {
+			}
+		}},
+		{name: "read to the end and closed", close: func(rows *releasingRows) {
+			for rows.Next() {
+			}
+			rows.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, logs := newLeakDB(10 * time.Millisecond)
+
+			tt.close(query(db, "SELECT id FROM users"))
+			if got := db.OpenRows(0); len(got) != 0 {
+				t.Errorf("OpenRows() = %+v, want none", got)
+			}
+
+			time.Sleep(30 * time.Millisecond)
+			runtime.GC()
+			if out := logs.String(); out != "" {
+				t.Errorf("clean path logged %q", out)
+			}
+		})
+	}
+}
+
+func TestLeakDetectionDisabled(t *testing.T) {
+	db := &DB{}
+	rows := query(db, "SELECT id FROM users")
+	defer rows.Close()
+	if got := db.OpenRows(0); len(got) != 0 {
+		t.Errorf("OpenRows() = %+v with detection off, want none", got)
+	}
+}
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS drop_analytics_events_partitions_before(date);
+DROP FUNCTION IF EXISTS create_analytics_events_partition(date);
+DROP TABLE IF EXISTS analytics_events;
@@ -0,0 +1,46 @@
+CREATE TABLE "analytics_events" (
+  "id" bigserial,
+  "name" varchar NOT NULL,
+  "properties" jsonb NOT NULL DEFAULT '{}',
+  "occurred_at" timestamptz NOT NULL,
+  "received_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("id", "received_at")
+) PARTITION BY RANGE ("received_at");
+
+CREATE INDEX ON "analytics_events" ("name", "received_at");
+
+CREATE OR REPLACE FUNCTION create_analytics_events_partition(day date) RETURNS void AS $$
+BEGIN
+  EXECUTE format(
+    'CREATE TABLE IF NOT EXISTS %I PARTITION OF analytics_events FOR VALUES FROM (%L) TO (%L)',
+    'analytics_events_' || to_char(day, 'YYYYMMDD'),
+    day,
+    day + 1
+  );
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION drop_analytics_events_partitions_before(cutoff date) RETURNS integer AS $$
+DECLARE
+  part record;
+  dropped integer := 0;
+BEGIN
+  FOR part IN
+    SELECT c.relname
+    FROM pg_inherits i
+    JOIN pg_class c ON c.oid = i.inhrelid
+    JOIN pg_class p ON p.oid = i.inhparent
+    WHERE p.relname = 'analytics_events'
+      AND c.relname ~ '^analytics_events_[0-9]{8}$'
+      AND to_date(right(c.relname, 8), 'YYYYMMDD') < cutoff
+  LOOP
+    EXECUTE format('DROP TABLE IF EXISTS %I', part.relname);
+    dropped := dropped + 1;
+  END LOOP;
+  RETURN dropped;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_analytics_events_partition(current_date + i) FROM generate_series(0, 7) AS i;
+
+COMMENT ON COLUMN "analytics_events"."received_at" IS 'partition key, set by the server';
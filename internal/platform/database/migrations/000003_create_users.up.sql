@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" varchar PRIMARY KEY,
+  "email" varchar NOT NULL,
+  "password" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+-- ILIKE cannot use a btree index, so prefix search matches lower(email) LIKE 'prefix%'.
+-- text_pattern_ops makes that LIKE indexable regardless of the database collation.
+CREATE INDEX IF NOT EXISTS "idx_users_email_lower_pattern" ON "users" (lower("email") text_pattern_ops);
+
+CREATE INDEX IF NOT EXISTS "idx_users_created_at" ON "users" ("created_at");
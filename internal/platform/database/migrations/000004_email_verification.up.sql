@@ -0,0 +1,12 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified_at" timestamptz;
+
+-- Only the SHA-256 of a token is stored; the plaintext token exists solely in the email sent to the user.
+CREATE TABLE IF NOT EXISTS "verification_tokens" (
+  "token_hash" varchar PRIMARY KEY,
+  "user_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_verification_tokens_user_id" ON "verification_tokens" ("user_id");
@@ -0,0 +1,10 @@
+-- Like verification_tokens, only the SHA-256 of each reset token is stored.
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" (
+  "token_hash" varchar PRIMARY KEY,
+  "user_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
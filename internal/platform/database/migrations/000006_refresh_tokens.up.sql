@@ -0,0 +1,13 @@
+-- Refresh tokens rotate on every use. All tokens descended from one login share a family_id,
+-- so presenting an already-rotated token can revoke the whole chain.
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+  "token_hash" varchar PRIMARY KEY,
+  "user_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "family_id" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
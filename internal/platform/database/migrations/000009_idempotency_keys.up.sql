@@ -0,0 +1,13 @@
+-- Responses to requests sent with an Idempotency-Key, replayed when the same request is retried.
+-- response_status is NULL while the first request is still being served.
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+  "key" varchar PRIMARY KEY,
+  "request_hash" varchar NOT NULL,
+  "response_status" integer,
+  "response_content_type" varchar,
+  "response_body" bytea,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
@@ -0,0 +1,5 @@
+-- Deleting a user sets deleted_at; the row is kept for audits until it is purged.
+-- email stays unique across deleted rows, so an address can't belong to two accounts.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The original casing of rewritten emails is not kept, so only the index is reverted.
+DROP INDEX IF EXISTS idx_users_email_lower;
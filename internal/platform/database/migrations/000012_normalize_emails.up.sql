@@ -0,0 +1,23 @@
+-- Emails are stored trimmed and lower-cased. Rows written before that are rewritten here,
+-- unless two of them would end up equal: that needs a person to decide which account wins.
+DO $$
+DECLARE
+  collisions text;
+BEGIN
+  SELECT string_agg(normalized, ', ') INTO collisions
+  FROM (
+    SELECT lower(btrim("email")) AS normalized
+    FROM "users"
+    GROUP BY 1
+    HAVING count(*) > 1
+  ) dup;
+
+  IF collisions IS NOT NULL THEN
+    RAISE EXCEPTION 'cannot normalize user emails, these addresses differ only in case or whitespace: %', collisions;
+  END IF;
+END $$;
+
+UPDATE "users" SET "email" = lower(btrim("email")) WHERE "email" <> lower(btrim("email"));
+
+-- Enforces case-insensitive uniqueness even for writes that bypass the service's normalization
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email_lower" ON "users" (lower("email"));
@@ -0,0 +1,15 @@
+-- Who changed what. Rows are written in the same transaction as the change they describe.
+-- actor_id is NULL for changes nobody was signed in for, such as signup, and has no
+-- foreign key so the trail outlives purged users.
+CREATE TABLE IF NOT EXISTS "audit_events" (
+  "id" varchar PRIMARY KEY,
+  "actor_id" varchar,
+  "action" varchar NOT NULL,
+  "entity_type" varchar NOT NULL,
+  "entity_id" varchar NOT NULL,
+  "diff" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_audit_events_entity" ON "audit_events" ("entity_type", "entity_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_actor_id" ON "audit_events" ("actor_id", "created_at");
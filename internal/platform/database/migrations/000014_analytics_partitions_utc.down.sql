@@ -0,0 +1,13 @@
+-- Partitions keep their UTC bounds; only partitions created from now on are bounded by date again
+DROP FUNCTION IF EXISTS rebound_analytics_events_partitions();
+
+CREATE OR REPLACE FUNCTION create_analytics_events_partition(day date) RETURNS void AS $$
+BEGIN
+  EXECUTE format(
+    'CREATE TABLE IF NOT EXISTS %I PARTITION OF analytics_events FOR VALUES FROM (%L) TO (%L)',
+    'analytics_events_' || to_char(day, 'YYYYMMDD'),
+    day,
+    day + 1
+  );
+END;
+$$ LANGUAGE plpgsql;
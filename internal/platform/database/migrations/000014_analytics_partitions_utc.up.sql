@@ -0,0 +1,57 @@
+-- Partition bounds were dates, which Postgres reads in the session time zone, while
+-- the server names and maintains partitions by UTC day. Bound each partition by
+-- UTC midnight instead.
+CREATE OR REPLACE FUNCTION create_analytics_events_partition(day date) RETURNS void AS $$
+BEGIN
+  EXECUTE format(
+    'CREATE TABLE IF NOT EXISTS %I PARTITION OF analytics_events FOR VALUES FROM (%L) TO (%L)',
+    'analytics_events_' || to_char(day, 'YYYYMMDD'),
+    day::timestamp AT TIME ZONE 'UTC',
+    (day + 1)::timestamp AT TIME ZONE 'UTC'
+  );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions created while the session was not in UTC overlap their UTC-bounded
+-- neighbours, so new days can't be attached next to them. Detach every such
+-- partition, create UTC-bounded ones for its day and for every day its rows fall
+-- on, and move the rows across. Partitions that already have UTC bounds are kept.
+-- Bounds are compared as text, which the function renders in UTC whatever the
+-- caller's time zone.
+CREATE OR REPLACE FUNCTION rebound_analytics_events_partitions() RETURNS integer AS $$
+DECLARE
+  stale text[];
+  part text;
+  day date;
+BEGIN
+  SELECT coalesce(array_agg(c.relname), '{}') INTO stale
+  FROM pg_inherits i
+  JOIN pg_class c ON c.oid = i.inhrelid
+  JOIN pg_class p ON p.oid = i.inhparent
+  WHERE p.relname = 'analytics_events'
+    AND c.relname ~ '^analytics_events_[0-9]{8}$'
+    AND pg_get_expr(c.relpartbound, c.oid) <> format(
+      'FOR VALUES FROM (%L) TO (%L)',
+      to_date(right(c.relname, 8), 'YYYYMMDD')::timestamp AT TIME ZONE 'UTC',
+      (to_date(right(c.relname, 8), 'YYYYMMDD') + 1)::timestamp AT TIME ZONE 'UTC'
+    );
+
+  FOREACH part IN ARRAY stale LOOP
+    EXECUTE format('ALTER TABLE analytics_events DETACH PARTITION %I', part);
+    EXECUTE format('ALTER TABLE %I RENAME TO %I', part, part || '_stale');
+  END LOOP;
+
+  FOREACH part IN ARRAY stale LOOP
+    PERFORM create_analytics_events_partition(to_date(right(part, 8), 'YYYYMMDD'));
+    FOR day IN EXECUTE format('SELECT DISTINCT (received_at AT TIME ZONE ''UTC'')::date FROM %I', part || '_stale') LOOP
+      PERFORM create_analytics_events_partition(day);
+    END LOOP;
+    EXECUTE format('INSERT INTO analytics_events SELECT * FROM %I', part || '_stale');
+    EXECUTE format('DROP TABLE %I', part || '_stale');
+  END LOOP;
+
+  RETURN cardinality(stale);
+END;
+$$ LANGUAGE plpgsql SET timezone = 'UTC';
+
+SELECT rebound_analytics_events_partitions();
@@ -0,0 +1,15 @@
+-- Named list filters saved by admins. params holds query parameter values by name; they
+-- are checked against the listing's current parameters when used, not by the database.
+CREATE TABLE IF NOT EXISTS "saved_filters" (
+  "id" varchar PRIMARY KEY,
+  "owner_id" varchar NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  "resource" varchar NOT NULL,
+  "params" jsonb NOT NULL,
+  "shared" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_saved_filters_owner_name" ON "saved_filters" ("owner_id", "name");
+CREATE INDEX IF NOT EXISTS "idx_saved_filters_shared" ON "saved_filters" ("shared") WHERE "shared";
@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgconn"
@@ -23,12 +25,46 @@ type Config struct {
 	MaxLifetime time.Duration
 	HealthCheck time.Duration
 	SSLMode     string // Added for SSL configuration
+	MaxWaiters  int32  // Callers allowed to queue for a connection once the pool is exhausted; 0 means unbounded
+	// Breaker makes callers fail fast for a while after repeated saturation; it needs MaxWaiters
+	Breaker BreakerConfig
+
+	// PasswordFunc, when set, supplies the password for every new connection so rotated credentials apply without a restart
+	PasswordFunc func(ctx context.Context) (string, error)
 }
 
 // DB represents our database connection
 type DB struct {
 	pool *pgxpool.Pool
 	cfg  Config
+
+	// slots bounds connections in use plus callers waiting for one; nil when unbounded
+	slots    chan struct{}
+	waiting  atomic.Int64
+	rejected atomic.Int64
+	breaker  breaker
+	// avgHold is a moving average of how long a connection stays checked out, in nanoseconds
+	avgHold atomic.Int64
+
+	explain explainer
+	leaks   rowsTracker
+
+	lockWaits    atomic.Int64
+	lockWaitTime atomic.Int64
+}
+
+// PoolStats reports backpressure counters for the connection pool
+type PoolStats struct {
+	Total        int32         // Connections open
+	Acquired     int32         // Connections checked out
+	Waiting      int64         // Callers currently blocked acquiring a connection
+	Rejected     int64         // Acquisitions refused with ErrPoolSaturated or ErrCircuitOpen
+	CircuitTrips int64         // Times saturation opened the breaker
+	CircuitOpen  time.Duration // How much longer the breaker stays open; 0 when closed
+	AvgHold      time.Duration // Moving average of how long a connection stays checked out
+	RetryAfter   time.Duration // See DB.RetryAfter
+	LockWaits    int64         // Entity locks taken with LockEntity
+	LockWaitTime time.Duration // Total time spent waiting for entity locks
 }
 
 func (c *Config) GetConnectionURL() string {
@@ -64,7 +100,7 @@ func NewConnection(cfg Config) (*DB, error) {
 	// Configure the connection pool
 	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing connection string: %v", err)
+		return nil, fmt.Errorf("error parsing connection string: %w", err)
 	}
 
 	// Set pool configuration
@@ -72,16 +108,30 @@ func NewConnection(cfg Config) (*DB, error) {
 	poolConfig.MinConns = cfg.MinPoolSize
 	poolConfig.MaxConnLifetime = cfg.MaxLifetime
 	poolConfig.MaxConnIdleTime = cfg.MaxIdleTime
+	if cfg.PasswordFunc != nil {
+		poolConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			password, err := cfg.PasswordFunc(ctx)
+			if err != nil {
+				return fmt.Errorf("error refreshing database password: %v", err)
+			}
+			connConfig.Password = password
+			return nil
+		}
+	}
 
 	// Create the connection pool
 	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to the database: %v", err)
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
 	}
 
 	db := &DB{
-		pool: pool,
-		cfg:  cfg,
+		pool:    pool,
+		cfg:     cfg,
+		breaker: breaker{cfg: cfg.Breaker},
+	}
+	if cfg.MaxWaiters > 0 {
+		db.slots = make(chan struct{}, cfg.MaxPoolSize+cfg.MaxWaiters)
 	}
 
 	// Start health check if configured
@@ -120,43 +170,254 @@ func (db *DB) GetPool() *pgxpool.Pool {
 	return db.pool
 }
 
+// Stats returns the current backpressure counters
+func (db *DB) Stats() PoolStats {
+	stats := PoolStats{
+		Waiting:      db.waiting.Load(),
+		Rejected:     db.rejected.Load(),
+		CircuitTrips: db.breaker.tripCount(),
+		CircuitOpen:  db.breaker.remaining(time.Now()),
+		AvgHold:      time.Duration(db.avgHold.Load()),
+		RetryAfter:   db.RetryAfter(),
+		LockWaits:    db.lockWaits.Load(),
+		LockWaitTime: time.Duration(db.lockWaitTime.Load()),
+	}
+	if db.pool != nil {
+		stat := db.pool.Stat()
+		stats.Total, stats.Acquired = stat.TotalConns(), stat.AcquiredConns()
+	}
+	return stats
+}
+
+// RetryAfter estimates how long a caller refused for backpressure should wait:
+// the rest of an open breaker's cooldown, or else the time the pool needs to
+// serve the callers already queued at the average connection hold time
+func (db *DB) RetryAfter() time.Duration {
+	if open := db.breaker.remaining(time.Now()); open > 0 {
+		return open
+	}
+	conns := max(int64(db.cfg.MaxPoolSize), 1)
+	rounds := (db.waiting.Load() + conns) / conns // queued callers ahead, plus this one
+	return time.Duration(db.avgHold.Load()) * time.Duration(rounds)
+}
+
+// acquire checks a connection out of the pool, failing fast with
+// ErrPoolSaturated when MaxWaiters callers are already queued and with
+// ErrCircuitOpen while repeated saturation holds the breaker open
+func (db *DB) acquire(ctx context.Context) (*pgxpool.Conn, func(), error) {
+	if db.slots != nil {
+		now := time.Now()
+		if db.breaker.remaining(now) > 0 {
+			db.rejected.Add(1)
+			return nil, nil, ErrCircuitOpen
+		}
+		select {
+		case db.slots <- struct{}{}:
+		default:
+			db.rejected.Add(1)
+			db.breaker.record(now)
+			return nil, nil, ErrPoolSaturated
+		}
+	}
+
+	db.waiting.Add(1)
+	conn, err := db.pool.Acquire(ctx)
+	db.waiting.Add(-1)
+	if err != nil {
+		db.freeSlot()
+		return nil, nil, err
+	}
+
+	acquired := time.Now()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			conn.Release()
+			db.freeSlot()
+			db.observeHold(time.Since(acquired))
+		})
+	}
+	return conn, release, nil
+}
+
+// observeHold folds a connection's hold time into avgHold, weighting it 1/8.
+// Concurrent updates may drop a sample, which an estimate can afford.
+func (db *DB) observeHold(d time.Duration) {
+	old := db.avgHold.Load()
+	if old == 0 {
+		db.avgHold.Store(int64(d))
+		return
+	}
+	db.avgHold.Store(old + (int64(d)-old)/8)
+}
+
+func (db *DB) freeSlot() {
+	if db.slots != nil {
+		<-db.slots
+	}
+}
+
 // Transaction represents a database transaction
 type Transaction struct {
-	tx pgx.Tx
+	db      *DB
+	tx      pgx.Tx
+	release func()
 }
 
 // BeginTx starts a new transaction
 func (db *DB) BeginTx(ctx context.Context) (*Transaction, error) {
-	tx, err := db.pool.Begin(ctx)
+	conn, release, err := db.acquire(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		release()
 		return nil, fmt.Errorf("error beginning transaction: %v", err)
 	}
-	return &Transaction{tx: tx}, nil
+	return &Transaction{db: db, tx: tx, release: release}, nil
 }
 
 // Commit commits the transaction
 func (t *Transaction) Commit(ctx context.Context) error {
+	defer t.release()
 	return t.tx.Commit(ctx)
 }
 
 // Rollback rolls back the transaction
 func (t *Transaction) Rollback(ctx context.Context) error {
+	defer t.release()
 	return t.tx.Rollback(ctx)
 }
 
+// ExecContext executes a query without returning any rows inside the transaction
+func (t *Transaction) ExecContext(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.tx.Exec(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows inside the transaction
+func (t *Transaction) QueryContext(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return t.tx.Query(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that returns a single row inside the transaction
+func (t *Transaction) QueryRowContext(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return t.tx.QueryRow(ctx, query, args...)
+}
+
+// LockEntity serializes mutations to one entity until the transaction ends by
+// taking a pg_advisory_xact_lock keyed on (entityType, id). Different entities
+// hash to different keys and stay concurrent.
+func (t *Transaction) LockEntity(ctx context.Context, entityType, id string) error {
+	start := time.Now()
+	_, err := t.tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, entityType, id)
+	t.db.lockWaits.Add(1)
+	t.db.lockWaitTime.Add(int64(time.Since(start)))
+	if err != nil {
+		return fmt.Errorf("error locking %s %s: %w", entityType, id, err)
+	}
+	return nil
+}
+
+// LockEntity takes the Transaction.LockEntity lock in the transaction WithinTx stored
+// in ctx, holding it until that transaction ends. It fails with ErrNoTransaction outside WithinTx.
+func (db *DB) LockEntity(ctx context.Context, entityType, id string) error {
+	tx, ok := db.txFrom(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+	return tx.LockEntity(ctx, entityType, id)
+}
+
+type txKey struct{}
+
+// WithinTx runs fn in a transaction, committing when fn succeeds and rolling back otherwise.
+// ExecContext, QueryContext and QueryRowContext called on db with the ctx passed to fn run
+// inside the transaction; a WithinTx nested in fn joins it rather than starting another.
+// BeginTx always starts a separate transaction.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := db.txFrom(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if not committed
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// txFrom returns the transaction WithinTx stored in ctx, if it belongs to db
+func (db *DB) txFrom(ctx context.Context) (*Transaction, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Transaction)
+	return tx, ok && tx.db == db
+}
+
 // ExecContext executes a query without returning any rows
 func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
-	return db.pool.Exec(ctx, query, args...)
+	if tx, ok := db.txFrom(ctx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+
+	conn, release, err := db.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	tag, err := conn.Exec(ctx, query, args...)
+	if err == nil {
+		db.maybeExplain(ctx, query, args, time.Since(start))
+	}
+	return tag, err
 }
 
 // QueryContext executes a query that returns rows
 func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
-	return db.pool.Query(ctx, query, args...)
+	if tx, ok := db.txFrom(ctx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+
+	conn, release, err := db.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	db.maybeExplain(ctx, query, args, time.Since(start))
+	tracked := &releasingRows{Rows: rows, release: release}
+	db.track(tracked, query)
+	return tracked, nil
 }
 
 // QueryRowContext executes a query that returns a single row
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) pgx.Row {
-	return db.pool.QueryRow(ctx, query, args...)
+	if tx, ok := db.txFrom(ctx); ok {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+
+	conn, release, err := db.acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+
+	start := time.Now()
+	row := conn.QueryRow(ctx, query, args...)
+	db.maybeExplain(ctx, query, args, time.Since(start))
+	return &releasingRow{row: row, release: release}
 }
 
 // Example usage of transactions
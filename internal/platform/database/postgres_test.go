@@ -0,0 +1,163 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/platform/database"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestLockEntityRequiresTransaction(t *testing.T) {
+	var db database.DB
+	if err := db.LockEntity(context.Background(), "user", "u1"); !errors.Is(err, database.ErrNoTransaction) {
+		t.Errorf("LockEntity() outside WithinTx error = %v, want %v", err, database.ErrNoTransaction)
+	}
+}
+
+func TestLockEntity(t *testing.T) {
+	db := testutil.OpenDB(t)
+	ctx := context.Background()
+
+	// Hold the lock for user u1 until released
+	locked, release, done := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		done <- db.WithinTx(ctx, func(ctx context.Context) error {
+			if err := db.LockEntity(ctx, "user", "u1"); err != nil {
+				return err
+			}
+			close(locked)
+			<-release
+			return nil
+		})
+	}()
+	<-locked
+
+	tests := []struct {
+		name       string
+		entityType string
+		id         string
+		wantBlock  bool
+	}{
+		{name: "same entity", entityType: "user", id: "u1", wantBlock: true},
+		{name: "other id", entityType: "user", id: "u2"},
+		{name: "other type", entityType: "role", id: "u1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer cancel()
+			err := db.WithinTx(ctx, func(ctx context.Context) error {
+				return db.LockEntity(ctx, tt.entityType, tt.id)
+			})
+			if blocked := err != nil; blocked != tt.wantBlock {
+				t.Errorf("LockEntity() error = %v, want blocked %v", err, tt.wantBlock)
+			}
+		})
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("holder: %v", err)
+	}
+	// Committing released the lock
+	err := db.WithinTx(ctx, func(ctx context.Context) error {
+		return db.LockEntity(ctx, "user", "u1")
+	})
+	if err != nil {
+		t.Errorf("LockEntity() after release error = %v", err)
+	}
+	if stats := db.Stats(); stats.LockWaits < 5 {
+		t.Errorf("LockWaits = %d, want at least 5", stats.LockWaits)
+	}
+}
+
+// overload runs 10x as many concurrent 50ms queries as the pool has connections
+// and returns the slowest caller's latency and how many were refused
+func overload(t *testing.T, cfg database.Config) (slowest time.Duration, refused int) {
+	t.Helper()
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Close()
+
+	type result struct {
+		latency time.Duration
+		err     error
+	}
+	callers := 10 * int(cfg.MaxPoolSize)
+	results := make(chan result, callers)
+	for range callers {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			start := time.Now()
+			_, err := db.ExecContext(ctx, "SELECT pg_sleep(0.05)")
+			results <- result{time.Since(start), err}
+		}()
+	}
+	for range callers {
+		r := <-results
+		switch {
+		case database.IsPoolSaturated(r.err):
+			refused++
+		case r.err != nil:
+			t.Fatalf("query: %v", r.err)
+		}
+		slowest = max(slowest, r.latency)
+	}
+	return slowest, refused
+}
+
+func TestBackpressureBoundsLatency(t *testing.T) {
+	cfg := testutil.DBConfig(t)
+	cfg.MaxPoolSize, cfg.MinPoolSize = 4, 4
+
+	unboundedSlowest, unboundedRefused := overload(t, cfg)
+
+	cfg.MaxWaiters = 4
+	boundedSlowest, boundedRefused := overload(t, cfg)
+
+	t.Logf("unbounded: slowest %s, %d refused; bounded: slowest %s, %d refused",
+		unboundedSlowest, unboundedRefused, boundedSlowest, boundedRefused)
+	if unboundedRefused != 0 {
+		t.Errorf("unbounded pool refused %d callers", unboundedRefused)
+	}
+	if boundedRefused == 0 {
+		t.Error("bounded pool refused nobody under 10x overload")
+	}
+	// 40 callers queue for ten rounds of 50ms without a bound, two with one
+	if boundedSlowest*2 > unboundedSlowest {
+		t.Errorf("bounded slowest %s is not well under unbounded %s", boundedSlowest, unboundedSlowest)
+	}
+}
+
+func TestQueryContextLeakDetection(t *testing.T) {
+	db := testutil.OpenDB(t)
+	db.SetLeakDetection(&database.LeakConfig{})
+	t.Cleanup(func() { db.SetLeakDetection(nil) })
+	ctx := context.Background()
+
+	closed, err := db.QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	closed.Close()
+	leaked, err := db.QueryContext(ctx, "SELECT 2")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer leaked.Close()
+
+	open := db.OpenRows(0)
+	if len(open) != 1 || open[0].Query != "SELECT 2" {
+		t.Fatalf("OpenRows() = %+v, want only the unclosed query", open)
+	}
+	if !strings.Contains(open[0].Stack, "TestQueryContextLeakDetection") {
+		t.Errorf("stack does not point at the caller:\n%s", open[0].Stack)
+	}
+}
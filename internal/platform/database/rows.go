@@ -0,0 +1,42 @@
+package database
+
+import "github.com/jackc/pgx/v4"
+
+// releasingRows returns its connection to the pool once the result set is exhausted or closed
+type releasingRows struct {
+	pgx.Rows
+	release func()
+}
+
+func (r *releasingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r *releasingRows) Close() {
+	r.Rows.Close()
+	r.release()
+}
+
+// releasingRow returns its connection to the pool after Scan
+type releasingRow struct {
+	row     pgx.Row
+	release func()
+}
+
+func (r *releasingRow) Scan(dest ...interface{}) error {
+	defer r.release()
+	return r.row.Scan(dest...)
+}
+
+// errRow is returned by QueryRowContext when no connection could be acquired
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,149 @@
+// Package diagnostics turns low-level startup failures into specific,
+// actionable messages for operators.
+package diagnostics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgconn"
+)
+
+// Class identifies the kind of startup failure
+type Class string
+
+const (
+	ClassDNS             Class = "dns"
+	ClassRefused         Class = "connection_refused"
+	ClassTimeout         Class = "timeout"
+	ClassAuth            Class = "authentication"
+	ClassMissingDatabase Class = "missing_database"
+	ClassTLS             Class = "tls"
+	ClassInvalidPort     Class = "invalid_port"
+	ClassAddressInUse    Class = "address_in_use"
+	ClassUnknown         Class = "unknown"
+)
+
+// Diagnosis wraps a startup error with its class, the sanitized target and a hint
+type Diagnosis struct {
+	Class  Class
+	Target string
+	Hint   string
+	Err    error
+}
+
+func (d *Diagnosis) Error() string {
+	return fmt.Sprintf("%s (target %s): %s: %v", d.Class, d.Target, d.Hint, d.Err)
+}
+
+func (d *Diagnosis) Unwrap() error {
+	return d.Err
+}
+
+// PostgreSQL SQLSTATE codes relevant to connecting
+const (
+	invalidPasswordCode      = "28P01"
+	invalidAuthorizationCode = "28000"
+	invalidCatalogNameCode   = "3D000"
+)
+
+// DatabaseTarget formats a connection target for logs without the password
+func DatabaseTarget(user, host string, port int, database string) string {
+	return fmt.Sprintf("postgresql://%s@%s/%s", user, net.JoinHostPort(host, strconv.Itoa(port)), database)
+}
+
+// Database classifies an error returned while connecting to or pinging PostgreSQL
+func Database(err error, target string) error {
+	if err == nil {
+		return nil
+	}
+
+	d := &Diagnosis{Class: ClassUnknown, Target: target, Err: err, Hint: "unexpected database error; check DB_* settings"}
+
+	var dnsErr *net.DNSError
+	var pgErr *pgconn.PgError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		d.Class = ClassDNS
+		d.Hint = fmt.Sprintf("cannot resolve host %q; check DB_HOST and DNS", dnsErr.Name)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		d.Class = ClassRefused
+		d.Hint = "connection refused; is PostgreSQL running and listening on DB_HOST:DB_PORT?"
+	case errors.As(err, &pgErr) && pgErr.Code == invalidPasswordCode:
+		d.Class = ClassAuth
+		d.Hint = "password authentication failed; check DB_USER and DB_PASSWORD"
+	case errors.As(err, &pgErr) && pgErr.Code == invalidAuthorizationCode && strings.Contains(pgErr.Message, "encryption"):
+		d.Class = ClassTLS
+		d.Hint = "server requires an encrypted connection; set DB_SSLMODE=require (or stricter)"
+	case errors.As(err, &pgErr) && pgErr.Code == invalidAuthorizationCode:
+		d.Class = ClassAuth
+		d.Hint = "server rejected the login (pg_hba.conf); check DB_USER and allowed hosts"
+	case errors.As(err, &pgErr) && pgErr.Code == invalidCatalogNameCode:
+		d.Class = ClassMissingDatabase
+		d.Hint = "database does not exist; create it or fix DB_NAME"
+	case errors.As(err, &certErr), errors.As(err, &authorityErr), errors.As(err, &hostnameErr):
+		d.Class = ClassTLS
+		d.Hint = "server certificate could not be verified; check DB_SSLMODE and the CA bundle"
+	case strings.Contains(err.Error(), "server refused TLS connection"):
+		d.Class = ClassTLS
+		d.Hint = "server does not support TLS; set DB_SSLMODE=disable or enable SSL on the server"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		d.Class = ClassTimeout
+		d.Hint = "timed out connecting; check network reachability and firewalls"
+	}
+
+	return d
+}
+
+// CheckPort verifies that a configured port is in the valid TCP range
+func CheckPort(key string, port int) error {
+	if port < 1 || port > 65535 {
+		return &Diagnosis{
+			Class:  ClassInvalidPort,
+			Target: key,
+			Hint:   fmt.Sprintf("%s must be between 1 and 65535", key),
+			Err:    fmt.Errorf("invalid port %d", port),
+		}
+	}
+	return nil
+}
+
+// CheckListenAddress verifies the server can bind addr, naming the owning
+// process when the address is taken and the OS lets us discover it
+func CheckListenAddress(addr string) error {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return &Diagnosis{Class: ClassInvalidPort, Target: addr, Hint: "listen address must be host:port", Err: err}
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return &Diagnosis{Class: ClassInvalidPort, Target: addr, Hint: "listen port must be numeric", Err: err}
+	}
+	if err := CheckPort("SERVER_ADDRESS", port); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			hint := "address already in use"
+			if pid, ok := listenerPID(port); ok {
+				hint = fmt.Sprintf("address already in use by PID %d", pid)
+			}
+			return &Diagnosis{Class: ClassAddressInUse, Target: addr, Hint: hint, Err: err}
+		}
+		return &Diagnosis{Class: ClassUnknown, Target: addr, Hint: "cannot listen on address", Err: err}
+	}
+	return ln.Close()
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package diagnostics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the socket state value for LISTEN in /proc/net/tcp
+const tcpListen = "0A"
+
+// listenerPID finds the process listening on port by matching the socket
+// inode from /proc/net/tcp{,6} against open file descriptors in /proc
+func listenerPID(port int) (int, bool) {
+	inode, ok := listeningInode(port)
+	if !ok {
+		return 0, false
+	}
+
+	target := fmt.Sprintf("socket:[%s]", inode)
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || link != target {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.Split(fd, "/")[2])
+		if err == nil {
+			return pid, true
+		}
+	}
+	return 0, false
+}
+
+func listeningInode(port int) (string, bool) {
+	suffix := fmt.Sprintf(":%04X", port)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 9 && strings.HasSuffix(fields[1], suffix) && fields[3] == tcpListen {
+				f.Close()
+				return fields[9], true
+			}
+		}
+		f.Close()
+	}
+	return "", false
+}
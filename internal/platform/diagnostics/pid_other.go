@@ -0,0 +1,8 @@
+//go:build !linux
+
+package diagnostics
+
+// listenerPID is not supported on this platform
+func listenerPID(port int) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// RecipientLimiter caps how many emails of each kind one address receives per window.
+// Messages over the cap are dropped silently and counted, so whoever triggered
+// them can't tell from the response that the limit was hit.
+type RecipientLimiter struct {
+	next   ports.EmailSender
+	limits map[string]int // kind -> max per window; kinds not listed are unlimited
+	window time.Duration
+
+	mu        sync.Mutex
+	sent      map[string][]time.Time // kind + recipient -> send times within the window
+	lastSweep time.Time
+
+	dropped sync.Map // kind -> *atomic.Int64
+}
+
+var _ ports.EmailSender = (*RecipientLimiter)(nil)
+
+func NewRecipientLimiter(next ports.EmailSender, window time.Duration, limits map[string]int) *RecipientLimiter {
+	return &RecipientLimiter{
+		next:   next,
+		limits: limits,
+		window: window,
+		sent:   make(map[string][]time.Time),
+	}
+}
+
+func (l *RecipientLimiter) Send(ctx context.Context, msg ports.EmailMessage) error {
+	if !l.allow(msg.Kind, msg.To, time.Now()) {
+		counter, _ := l.dropped.LoadOrStore(msg.Kind, new(atomic.Int64))
+		counter.(*atomic.Int64).Add(1)
+		return nil
+	}
+	return l.next.Send(ctx, msg)
+}
+
+// Dropped returns the number of messages discarded per kind
+func (l *RecipientLimiter) Dropped() map[string]int64 {
+	dropped := make(map[string]int64)
+	l.dropped.Range(func(k, v any) bool {
+		dropped[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	return dropped
+}
+
+// Clear lifts the limit for an address across all kinds, for support to unblock a user
+func (l *RecipientLimiter) Clear(recipient string) {
+	suffix := "\x00" + normalizeRecipient(recipient)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.sent {
+		if strings.HasSuffix(key, suffix) {
+			delete(l.sent, key)
+		}
+	}
+}
+
+func (l *RecipientLimiter) allow(kind, recipient string, now time.Time) bool {
+	limit, ok := l.limits[kind]
+	if !ok {
+		return true
+	}
+	key := kind + "\x00" + normalizeRecipient(recipient)
+	cutoff := now.Add(-l.window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.window {
+		l.sweep(cutoff)
+		l.lastSweep = now
+	}
+
+	recent := prune(l.sent[key], cutoff)
+	if len(recent) >= limit {
+		l.sent[key] = recent
+		return false
+	}
+	l.sent[key] = append(recent, now)
+	return true
+}
+
+// sweep forgets recipients with no sends inside the window
+func (l *RecipientLimiter) sweep(cutoff time.Time) {
+	for key, times := range l.sent {
+		if recent := prune(times, cutoff); len(recent) == 0 {
+			delete(l.sent, key)
+		} else {
+			l.sent[key] = recent
+		}
+	}
+}
+
+// prune drops send times at or before cutoff; times are in ascending order
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+func normalizeRecipient(recipient string) string {
+	return strings.ToLower(strings.TrimSpace(recipient))
+}
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/testutil"
+)
+
+func newTestLimiter(limit int) (*RecipientLimiter, *testutil.EmailSender) {
+	sent := &testutil.EmailSender{}
+	return NewRecipientLimiter(sent, time.Hour, map[string]int{
+		ports.EmailVerification:  limit,
+		ports.EmailPasswordReset: limit,
+	}), sent
+}
+
+// delivered counts the messages of kind that reached the next sender
+func delivered(sent *testutil.EmailSender, kind string) int {
+	n := 0
+	for _, msg := range sent.Messages() {
+		if msg.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRecipientLimiterCap(t *testing.T) {
+	tests := []struct {
+		name          string
+		kind          string
+		sends         []string // recipients, in order
+		wantDelivered int
+		wantDropped   int64
+	}{
+		{name: "under the cap", kind: ports.EmailVerification, sends: []string{"a@example.com", "a@example.com"}, wantDelivered: 2},
+		{name: "at the cap", kind: ports.EmailPasswordReset, sends: []string{"a@example.com", "a@example.com", "a@example.com"}, wantDelivered: 3},
+		{name: "over the cap", kind: ports.EmailPasswordReset, sends: []string{"a@example.com", "a@example.com", "a@example.com", "a@example.com", "a@example.com"}, wantDelivered: 3, wantDropped: 2},
+		{name: "address spellings share a cap", kind: ports.EmailVerification, sends: []string{"a@example.com", "A@Example.com", " a@example.com ", "a@EXAMPLE.com"}, wantDelivered: 3, wantDropped: 1},
+		{name: "each recipient has its own cap", kind: ports.EmailVerification, sends: []string{"a@example.com", "a@example.com", "a@example.com", "b@example.com"}, wantDelivered: 4},
+		{name: "unlisted kinds are unlimited", kind: "welcome", sends: []string{"a@example.com", "a@example.com", "a@example.com", "a@example.com"}, wantDelivered: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, sent := newTestLimiter(3)
+			for _, to := range tt.sends {
+				// Dropped messages look like sent ones to the caller
+				if err := limiter.Send(context.Background(), ports.EmailMessage{Kind: tt.kind, To: to}); err != nil {
+					t.Fatalf("Send() error = %v", err)
+				}
+			}
+			if got := delivered(sent, tt.kind); got != tt.wantDelivered {
+				t.Errorf("delivered = %d, want %d", got, tt.wantDelivered)
+			}
+			if got := limiter.Dropped()[tt.kind]; got != tt.wantDropped {
+				t.Errorf("Dropped()[%s] = %d, want %d", tt.kind, got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRecipientLimiterCountsKindsAcrossEndpoints(t *testing.T) {
+	limiter, sent := newTestLimiter(2)
+	ctx := context.Background()
+
+	// Signup, an admin resend and a self-service resend all send verification mail to
+	// the same inbox; one cap applies however the send was triggered, and reset mail
+	// to the same inbox is held to the same limit separately
+	for _, msg := range []ports.EmailMessage{
+		{Kind: ports.EmailVerification, To: "victim@example.com", Subject: "signup"},
+		{Kind: ports.EmailPasswordReset, To: "Victim@example.com", Subject: "forgot password"},
+		{Kind: ports.EmailVerification, To: "victim@example.com", Subject: "admin resend"},
+		{Kind: ports.EmailVerification, To: "VICTIM@example.com", Subject: "self resend"},
+		{Kind: ports.EmailPasswordReset, To: "victim@example.com", Subject: "forgot password"},
+		{Kind: ports.EmailPasswordReset, To: "victim@example.com", Subject: "forgot password"},
+	} {
+		if err := limiter.Send(ctx, msg); err != nil {
+			t.Fatalf("Send(%s) error = %v", msg.Subject, err)
+		}
+	}
+
+	for _, kind := range []string{ports.EmailVerification, ports.EmailPasswordReset} {
+		if got := delivered(sent, kind); got != 2 {
+			t.Errorf("delivered %s = %d, want 2", kind, got)
+		}
+		if got := limiter.Dropped()[kind]; got != 1 {
+			t.Errorf("Dropped()[%s] = %d, want 1", kind, got)
+		}
+	}
+}
+
+func TestRecipientLimiterWindow(t *testing.T) {
+	limiter, _ := newTestLimiter(2)
+	start := time.Now()
+
+	if !limiter.allow(ports.EmailVerification, "a@example.com", start) ||
+		!limiter.allow(ports.EmailVerification, "a@example.com", start.Add(10*time.Minute)) {
+		t.Fatal("refused below the cap")
+	}
+	if limiter.allow(ports.EmailVerification, "a@example.com", start.Add(59*time.Minute)) {
+		t.Error("allowed over the cap inside the window")
+	}
+	// The first send has left the window, freeing one slot
+	if !limiter.allow(ports.EmailVerification, "a@example.com", start.Add(61*time.Minute)) {
+		t.Error("refused after the oldest send left the window")
+	}
+	if limiter.allow(ports.EmailVerification, "a@example.com", start.Add(62*time.Minute)) {
+		t.Error("allowed a second send while the 10 minute one is still in the window")
+	}
+}
+
+func TestRecipientLimiterClear(t *testing.T) {
+	limiter, sent := newTestLimiter(1)
+	ctx := context.Background()
+
+	for _, kind := range []string{ports.EmailVerification, ports.EmailPasswordReset} {
+		for _, to := range []string{"a@example.com", "a@example.com", "b@example.com", "b@example.com"} {
+			limiter.Send(ctx, ports.EmailMessage{Kind: kind, To: to})
+		}
+	}
+	limiter.Clear(" A@example.com")
+
+	for _, kind := range []string{ports.EmailVerification, ports.EmailPasswordReset} {
+		limiter.Send(ctx, ports.EmailMessage{Kind: kind, To: "a@example.com"})
+		limiter.Send(ctx, ports.EmailMessage{Kind: kind, To: "b@example.com"})
+		// a got one before the cap and one after Clear; b stays capped at one
+		if got := delivered(sent, kind); got != 3 {
+			t.Errorf("delivered %s = %d, want 3", kind, got)
+		}
+	}
+}
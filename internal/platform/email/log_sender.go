@@ -0,0 +1,25 @@
+package email
+
+import (
+	"context"
+	"log"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// LogSender writes emails to a logger instead of delivering them.
+// It stands in for a real provider in development.
+type LogSender struct {
+	logger *log.Logger
+}
+
+var _ ports.EmailSender = (*LogSender)(nil)
+
+func NewLogSender(logger *log.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg ports.EmailMessage) error {
+	s.logger.Printf("email to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
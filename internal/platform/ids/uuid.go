@@ -0,0 +1,30 @@
+// Package ids implements ports.IDGenerator
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// UUIDGenerator mints random RFC 4122 version 4 UUIDs
+type UUIDGenerator struct{}
+
+var _ ports.IDGenerator = UUIDGenerator{}
+
+func NewUUIDGenerator() UUIDGenerator {
+	return UUIDGenerator{}
+}
+
+// NewID returns a UUID such as 9f1c0a3e-5b7d-4e2a-8c61-2d4f0b9a7e13. It panics
+// if the system's random source fails, which crypto/rand treats as unrecoverable.
+func (UUIDGenerator) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("ids: reading random bytes: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
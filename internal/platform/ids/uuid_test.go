@@ -0,0 +1,25 @@
+package ids
+
+import (
+	"regexp"
+	"testing"
+)
+
+// uuidV4 matches the canonical form of an RFC 4122 version 4 UUID: version
+// nibble 4 and variant bits 10 in the first hex digit of the fourth group
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDGenerator(t *testing.T) {
+	gen := NewUUIDGenerator()
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := gen.NewID()
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("NewID() = %q, not a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
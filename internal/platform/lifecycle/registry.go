@@ -0,0 +1,275 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a hook's Start and Stop when the hook sets no Timeout
+const DefaultTimeout = 10 * time.Second
+
+// Hook is a named subsystem started and stopped by a Registry
+type Hook struct {
+	Name string
+	// DependsOn names hooks that must be running before this one starts; they stop after it
+	DependsOn []string
+	// Timeout bounds each of Start and Stop; the call is abandoned once it passes
+	Timeout time.Duration
+	Start   func(ctx context.Context) error
+	Stop    func(ctx context.Context) error // optional
+}
+
+// State of a hook
+const (
+	StatePending  = "pending"
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateStopping = "stopping"
+	StateStopped  = "stopped"
+	StateFailed   = "failed"
+)
+
+// HookState describes a hook for readiness reporting
+type HookState struct {
+	Name     string        `json:"name"`
+	State    string        `json:"state"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"` // of the last Start or Stop
+}
+
+// Registry starts hooks in dependency order and stops them in reverse.
+// If a hook fails to start, the hooks already running are stopped before Start returns.
+type Registry struct {
+	logger *log.Logger
+
+	mu      sync.Mutex
+	hooks   []*entry
+	byName  map[string]*entry
+	started []*entry // in start order
+}
+
+type entry struct {
+	hook     Hook
+	state    string // guarded by Registry.mu
+	err      error
+	duration time.Duration
+}
+
+func NewRegistry(logger *log.Logger) *Registry {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Registry{logger: logger, byName: make(map[string]*entry)}
+}
+
+// Register adds a hook; it must be called before Start
+func (r *Registry) Register(h Hook) error {
+	if h.Name == "" || h.Start == nil {
+		return errors.New("lifecycle: hook needs a name and a Start func")
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[h.Name]; ok {
+		return fmt.Errorf("lifecycle: hook %q registered twice", h.Name)
+	}
+	e := &entry{hook: h, state: StatePending}
+	r.hooks = append(r.hooks, e)
+	r.byName[h.Name] = e
+	return nil
+}
+
+// Start runs every hook's Start in dependency order. On the first failure the
+// hooks already started are stopped in reverse and the failure is returned.
+func (r *Registry) Start(ctx context.Context) error {
+	order, err := r.order()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range order {
+		r.setState(e, StateStarting, nil, 0)
+		begin := time.Now()
+		err := call(ctx, e.hook.Timeout, e.hook.Start)
+		took := time.Since(begin)
+		if err != nil {
+			r.setState(e, StateFailed, err, took)
+			r.logger.Printf("lifecycle: %s failed to start after %s: %v", e.hook.Name, took.Round(time.Millisecond), err)
+			if stopErr := r.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+				r.logger.Printf("lifecycle: rollback after failed start: %v", stopErr)
+			}
+			return fmt.Errorf("lifecycle: start %s: %w", e.hook.Name, err)
+		}
+
+		r.mu.Lock()
+		r.started = append(r.started, e)
+		r.mu.Unlock()
+		r.setState(e, StateRunning, nil, took)
+		r.logger.Printf("lifecycle: %s started in %s", e.hook.Name, took.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// Stop runs Stop for every started hook in reverse start order. A hook that
+// fails or overruns its timeout is logged and skipped so the rest still stop.
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	r.started = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+		if e.hook.Stop == nil {
+			r.setState(e, StateStopped, nil, 0)
+			continue
+		}
+
+		r.setState(e, StateStopping, nil, 0)
+		begin := time.Now()
+		err := call(ctx, e.hook.Timeout, e.hook.Stop)
+		took := time.Since(begin)
+		if err != nil {
+			r.setState(e, StateFailed, err, took)
+			r.logger.Printf("lifecycle: %s failed to stop after %s: %v", e.hook.Name, took.Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("lifecycle: stop %s: %w", e.hook.Name, err))
+			continue
+		}
+		r.setState(e, StateStopped, nil, took)
+		r.logger.Printf("lifecycle: %s stopped in %s", e.hook.Name, took.Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// States returns every hook's state in registration order
+func (r *Registry) States() []HookState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	states := make([]HookState, 0, len(r.hooks))
+	for _, e := range r.hooks {
+		state := HookState{Name: e.hook.Name, State: e.state, Duration: e.duration}
+		if e.err != nil {
+			state.Error = e.err.Error()
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// Ready reports whether every hook is running
+func (r *Registry) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.hooks {
+		if e.state != StateRunning {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Registry) setState(e *entry, state string, err error, took time.Duration) {
+	r.mu.Lock()
+	e.state, e.err, e.duration = state, err, took
+	r.mu.Unlock()
+}
+
+// order sorts hooks so each comes after its dependencies, keeping registration order otherwise
+func (r *Registry) order() ([]*entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := make(map[*entry]int, len(r.hooks))
+	order := make([]*entry, 0, len(r.hooks))
+
+	var visit func(e *entry, path []string) error
+	visit = func(e *entry, path []string) error {
+		switch marks[e] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("lifecycle: dependency cycle %v", append(path, e.hook.Name))
+		}
+		marks[e] = visiting
+		for _, name := range e.hook.DependsOn {
+			dep, ok := r.byName[name]
+			if !ok {
+				return fmt.Errorf("lifecycle: %s depends on unknown hook %q", e.hook.Name, name)
+			}
+			if err := visit(dep, append(path, e.hook.Name)); err != nil {
+				return err
+			}
+		}
+		marks[e] = done
+		order = append(order, e)
+		return nil
+	}
+
+	for _, e := range r.hooks {
+		if err := visit(e, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// call runs fn with a deadline and returns once the deadline passes even if fn
+// ignores its context, so one stuck hook can't hold up startup or shutdown.
+func call(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+	}
+}
+
+// Go returns a hook that runs fn in its own goroutine until Stop cancels its
+// context, then waits for fn to return.
+func Go(name string, fn func(ctx context.Context), dependsOn ...string) Hook {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	return Hook{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				fn(runCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
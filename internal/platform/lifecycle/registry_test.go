@@ -0,0 +1,229 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder notes the order hooks start and stop in
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (rec *recorder) note(call string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.calls = append(rec.calls, call)
+}
+
+func (rec *recorder) Calls() []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return slices.Clone(rec.calls)
+}
+
+// hook returns a hook that records its calls and fails to start with startErr
+func (rec *recorder) hook(name string, startErr error, dependsOn ...string) Hook {
+	return Hook{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			rec.note("start " + name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			rec.note("stop " + name)
+			return nil
+		},
+	}
+}
+
+func newTestRegistry(t *testing.T, hooks ...Hook) *Registry {
+	t.Helper()
+	r := NewRegistry(log.New(io.Discard, "", 0))
+	for _, h := range hooks {
+		if err := r.Register(h); err != nil {
+			t.Fatalf("Register(%s) error = %v", h.Name, err)
+		}
+	}
+	return r
+}
+
+func statesOf(r *Registry) map[string]string {
+	states := make(map[string]string)
+	for _, s := range r.States() {
+		states[s.Name] = s.State
+	}
+	return states
+}
+
+func TestRegistryStartsInDependencyOrder(t *testing.T) {
+	rec := &recorder{}
+	r := newTestRegistry(t,
+		rec.hook("http", nil, "events", "db"),
+		rec.hook("events", nil, "db"),
+		rec.hook("db", nil),
+	)
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !r.Ready() {
+		t.Errorf("Ready() = false with every hook running, states %v", statesOf(r))
+	}
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	want := []string{"start db", "start events", "start http", "stop http", "stop events", "stop db"}
+	if got := rec.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	if r.Ready() {
+		t.Error("Ready() = true after Stop")
+	}
+}
+
+func TestRegistryRejectsBadDependencies(t *testing.T) {
+	rec := &recorder{}
+	tests := []struct {
+		name    string
+		hooks   []Hook
+		wantErr string
+	}{
+		{name: "unknown", hooks: []Hook{rec.hook("http", nil, "db")}, wantErr: `unknown hook "db"`},
+		{name: "cycle", hooks: []Hook{rec.hook("a", nil, "b"), rec.hook("b", nil, "a")}, wantErr: "dependency cycle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(t, tt.hooks...)
+			err := r.Start(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Start() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if calls := rec.Calls(); len(calls) != 0 {
+		t.Errorf("hooks ran despite invalid dependencies: %v", calls)
+	}
+}
+
+func TestRegistryRollsBackFailedStart(t *testing.T) {
+	rec := &recorder{}
+	errBind := errors.New("address already in use")
+	r := newTestRegistry(t,
+		rec.hook("db", nil),
+		rec.hook("events", nil, "db"),
+		rec.hook("http", errBind, "events"),
+		rec.hook("metrics", nil, "http"),
+	)
+
+	err := r.Start(context.Background())
+	if !errors.Is(err, errBind) {
+		t.Fatalf("Start() error = %v, want %v", err, errBind)
+	}
+
+	// The hooks already running stop in reverse; the failed and later ones never stop
+	want := []string{"start db", "start events", "start http", "stop events", "stop db"}
+	if got := rec.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	wantStates := map[string]string{"db": StateStopped, "events": StateStopped, "http": StateFailed, "metrics": StatePending}
+	if got := statesOf(r); !maps.Equal(got, wantStates) {
+		t.Errorf("states = %v, want %v", got, wantStates)
+	}
+	for _, s := range r.States() {
+		if s.Name == "http" && s.Error != errBind.Error() {
+			t.Errorf("http error = %q, want %q", s.Error, errBind)
+		}
+	}
+	if r.Ready() {
+		t.Error("Ready() = true after a failed start")
+	}
+
+	// Nothing is left to stop
+	if err := r.Stop(context.Background()); err != nil || len(rec.Calls()) != len(want) {
+		t.Errorf("Stop() after rollback = %v, calls %v", err, rec.Calls())
+	}
+}
+
+func TestRegistryEnforcesTimeouts(t *testing.T) {
+	rec := &recorder{}
+	release := make(chan struct{})
+	defer close(release)
+	stuck := func(context.Context) error {
+		<-release // ignores its context
+		return nil
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		r := newTestRegistry(t,
+			rec.hook("db", nil),
+			Hook{Name: "stuck", DependsOn: []string{"db"}, Timeout: 20 * time.Millisecond, Start: func(context.Context) error { return nil }, Stop: stuck},
+			rec.hook("http", nil, "stuck"),
+		)
+		if err := r.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		begin := time.Now()
+		err := r.Stop(context.Background())
+		if took := time.Since(begin); took > time.Second {
+			t.Errorf("Stop() took %s, want it bounded by the 20ms hook timeout", took)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop stuck") {
+			t.Errorf("Stop() error = %v, want the stuck hook's timeout", err)
+		}
+		// The overrunning hook is skipped and the rest still stop
+		if calls := rec.Calls(); !slices.Contains(calls, "stop http") || !slices.Contains(calls, "stop db") {
+			t.Errorf("calls = %v, want http and db stopped around the stuck hook", calls)
+		}
+		if got := statesOf(r)["stuck"]; got != StateFailed {
+			t.Errorf("stuck state = %s, want %s", got, StateFailed)
+		}
+	})
+
+	t.Run("start", func(t *testing.T) {
+		r := newTestRegistry(t,
+			Hook{Name: "stuck", Timeout: 20 * time.Millisecond, Start: stuck},
+		)
+		begin := time.Now()
+		err := r.Start(context.Background())
+		if took := time.Since(begin); took > time.Second {
+			t.Errorf("Start() took %s, want it bounded by the 20ms hook timeout", took)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Start() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func TestGoHookStopsItsGoroutine(t *testing.T) {
+	stopped := make(chan struct{})
+	r := newTestRegistry(t, Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}))
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Stop() returned before the goroutine exited")
+	}
+}
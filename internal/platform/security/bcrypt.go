@@ -0,0 +1,52 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+
+	"example.com/monolithic/internal/core/ports"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultCost is the bcrypt work factor used unless configured otherwise
+const DefaultCost = bcrypt.DefaultCost
+
+// BcryptHasher hashes passwords with bcrypt at a fixed cost
+type BcryptHasher struct {
+	cost int
+}
+
+var _ ports.PasswordHasher = (*BcryptHasher)(nil)
+
+// NewBcryptHasher creates a hasher, refusing a cost outside bcrypt's range rather
+// than hashing every password at a work factor nobody configured
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return &BcryptHasher{cost: cost}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Compare(hash, password string) error {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return ports.ErrNotHashed
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ports.ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
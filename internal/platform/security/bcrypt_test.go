@@ -0,0 +1,27 @@
+package security
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewBcryptHasherRejectsCostOutOfRange(t *testing.T) {
+	for _, cost := range []int{0, bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if h, err := NewBcryptHasher(cost); err == nil {
+			t.Errorf("NewBcryptHasher(%d) = %+v, want an error instead of another cost", cost, h)
+		}
+	}
+
+	h, err := NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher(%d) error = %v", bcrypt.MinCost, err)
+	}
+	hash, err := h.Hash("correct horse 1")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != bcrypt.MinCost {
+		t.Errorf("hashed at cost %d, want %d", cost, bcrypt.MinCost)
+	}
+}
@@ -0,0 +1,93 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// MinJWTKeyLength is the shortest HMAC key accepted for signing tokens
+const MinJWTKeyLength = 32
+
+// jwtHeader is the only header JWT issues or accepts; pinning alg rules out "none" and algorithm confusion
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// JWT issues and verifies HS256-signed access tokens
+type JWT struct {
+	key []byte
+	ttl time.Duration
+}
+
+var _ ports.AccessTokens = (*JWT)(nil)
+
+func NewJWT(key []byte, ttl time.Duration) (*JWT, error) {
+	if len(key) < MinJWTKeyLength {
+		return nil, errors.New("jwt signing key must be at least 32 bytes")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("jwt ttl must be positive")
+	}
+	return &JWT{key: key, ttl: ttl}, nil
+}
+
+func (j *JWT) Issue(userID, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(j.ttl)
+	payload, err := json.Marshal(jwtClaims{
+		Subject:   userID,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + j.sign(signed), time.Unix(expiresAt.Unix(), 0), nil
+}
+
+func (j *JWT) Verify(token string) (ports.AccessClaims, error) {
+	header, rest, ok := strings.Cut(token, ".")
+	if !ok || header != jwtHeader {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+	payload, signature, ok := strings.Cut(rest, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(j.sign(header+"."+payload))) {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Subject == "" {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if !time.Now().Before(expiresAt) {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+
+	return ports.AccessClaims{UserID: claims.Subject, Role: claims.Role, ExpiresAt: expiresAt}, nil
+}
+
+func (j *JWT) sign(signed string) string {
+	mac := hmac.New(sha256.New, j.key)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
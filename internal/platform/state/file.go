@@ -0,0 +1,179 @@
+// Package state carries in-memory protective state, such as rate limiter buckets and
+// circuit breakers, across a graceful restart so a deploy doesn't reset them
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Version is the format written by Save. Files of any other version are ignored.
+const Version = 1
+
+// Section is a component whose state survives a restart. MarshalState runs during
+// shutdown, once the component has stopped taking traffic; RestoreState runs at
+// startup, before it takes any, with data from an earlier MarshalState.
+type Section interface {
+	MarshalState() ([]byte, error)
+	RestoreState(data []byte) error
+}
+
+// File saves registered sections to a local file on shutdown and restores them on
+// startup. A missing, stale, unreadable or corrupt file is ignored with a warning
+// so the server always starts, with clean state if need be.
+type File struct {
+	path   string
+	maxAge time.Duration
+	logger *log.Logger
+
+	sections map[string]Section
+}
+
+// document is the file's layout. Checksum covers Sections, so a truncated or
+// partly overwritten file is detected even when it still parses.
+type document struct {
+	Version  int                        `json:"version"`
+	SavedAt  time.Time                  `json:"saved_at"`
+	Checksum string                     `json:"checksum"`
+	Sections map[string]json.RawMessage `json:"sections"`
+}
+
+// NewFile keeps state at path; state saved more than maxAge before startup is discarded
+func NewFile(path string, maxAge time.Duration, logger *log.Logger) *File {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &File{path: path, maxAge: maxAge, logger: logger, sections: make(map[string]Section)}
+}
+
+// Register adds a section under name; it must be called before Restore or Save
+func (f *File) Register(name string, section Section) {
+	f.sections[name] = section
+}
+
+// Restore loads the file into the registered sections. Sections missing from the
+// file, or whose data fails to restore, start clean; the others are still restored.
+func (f *File) Restore(now time.Time) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		f.logger.Printf("state: ignoring %s: %v", f.path, err)
+		return
+	}
+
+	doc, err := decode(data)
+	if err != nil {
+		f.logger.Printf("state: ignoring %s: %v", f.path, err)
+		return
+	}
+	if age := now.Sub(doc.SavedAt); age > f.maxAge || age < 0 {
+		f.logger.Printf("state: ignoring %s saved at %s, outside the %s freshness window",
+			f.path, doc.SavedAt.Format(time.RFC3339), f.maxAge)
+		return
+	}
+
+	for _, name := range sortedNames(f.sections) {
+		raw, ok := doc.Sections[name]
+		if !ok {
+			continue
+		}
+		if err := f.sections[name].RestoreState(raw); err != nil {
+			f.logger.Printf("state: starting %s clean: %v", name, err)
+			continue
+		}
+		f.logger.Printf("state: restored %s from %s", name, doc.SavedAt.Format(time.RFC3339))
+	}
+}
+
+// Save writes every registered section to the file, replacing it atomically.
+// A section that fails to marshal is left out rather than failing the rest.
+func (f *File) Save(now time.Time) error {
+	doc := document{Version: Version, SavedAt: now.UTC(), Sections: make(map[string]json.RawMessage, len(f.sections))}
+	for _, name := range sortedNames(f.sections) {
+		data, err := f.sections[name].MarshalState()
+		if err != nil {
+			f.logger.Printf("state: not saving %s: %v", name, err)
+			continue
+		}
+		doc.Sections[name] = data
+	}
+	sum, err := checksum(doc.Sections)
+	if err != nil {
+		return err
+	}
+	doc.Checksum = sum
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func decode(data []byte) (*document, error) {
+	var doc document
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unreadable: %w", err)
+	}
+	if doc.Version != Version {
+		return nil, fmt.Errorf("version %d, want %d", doc.Version, Version)
+	}
+	sum, err := checksum(doc.Sections)
+	if err != nil || sum != doc.Checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+	return &doc, nil
+}
+
+// checksum hashes sections in name order, so it doesn't depend on map iteration
+func checksum(sections map[string]json.RawMessage) (string, error) {
+	h := sha256.New()
+	for _, name := range sortedNames(sections) {
+		// Compacting makes the sum independent of how the section was indented
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, sections[name]); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, compact.Len())
+		h.Write(compact.Bytes())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,148 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memorySection holds its state as raw bytes
+type memorySection struct {
+	data       []byte
+	restoreErr error
+}
+
+func (s *memorySection) MarshalState() ([]byte, error) { return s.data, nil }
+
+func (s *memorySection) RestoreState(data []byte) error {
+	if s.restoreErr != nil {
+		return s.restoreErr
+	}
+	s.data = data
+	return nil
+}
+
+func newTestFile(t *testing.T, path string) (*File, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	return NewFile(path, time.Minute, log.New(&logs, "", 0)), &logs
+}
+
+// saveState writes a state file at path with the limiter and breaker sections
+func saveState(t *testing.T, path string, now time.Time) {
+	t.Helper()
+	f, _ := newTestFile(t, path)
+	f.Register("rate_limit", &memorySection{data: []byte(`{"user:alice":{"tokens":0}}`)})
+	f.Register("db_breaker", &memorySection{data: []byte(`{"trips":3}`)})
+	if err := f.Save(now); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+	saveState(t, path, now)
+
+	f, logs := newTestFile(t, path)
+	limiter, breaker, unsaved := &memorySection{}, &memorySection{}, &memorySection{data: []byte(`"clean"`)}
+	f.Register("rate_limit", limiter)
+	f.Register("db_breaker", breaker)
+	f.Register("added_since", unsaved)
+	f.Restore(now.Add(30 * time.Second))
+
+	if string(limiter.data) != `{"user:alice":{"tokens":0}}` || string(breaker.data) != `{"trips":3}` {
+		t.Errorf("restored %s and %s", limiter.data, breaker.data)
+	}
+	if string(unsaved.data) != `"clean"` {
+		t.Errorf("section missing from the file restored to %s", unsaved.data)
+	}
+	if !strings.Contains(logs.String(), "restored rate_limit") {
+		t.Errorf("logs = %q, want the restore noted", logs.String())
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestFileIgnoresUnusableState(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, path string)
+		restore  time.Time
+		wantWarn string
+	}{
+		{name: "missing", prepare: func(t *testing.T, path string) {}, restore: now},
+		{name: "stale", prepare: func(t *testing.T, path string) { saveState(t, path, now) }, restore: now.Add(2 * time.Minute), wantWarn: "freshness window"},
+		{name: "from the future", prepare: func(t *testing.T, path string) { saveState(t, path, now.Add(time.Hour)) }, restore: now, wantWarn: "freshness window"},
+		{name: "garbage", prepare: writeFile("\x00\x01 not json"), restore: now, wantWarn: "unreadable"},
+		{name: "empty", prepare: writeFile(""), restore: now, wantWarn: "unreadable"},
+		{name: "truncated", prepare: func(t *testing.T, path string) {
+			saveState(t, path, now)
+			data, _ := os.ReadFile(path)
+			os.WriteFile(path, data[:len(data)/2], 0o600)
+		}, restore: now, wantWarn: "unreadable"},
+		{name: "tampered", prepare: func(t *testing.T, path string) {
+			saveState(t, path, now)
+			data, _ := os.ReadFile(path)
+			os.WriteFile(path, bytes.Replace(data, []byte(`"trips":3`), []byte(`"trips":4`), 1), 0o600)
+		}, restore: now, wantWarn: "checksum mismatch"},
+		{name: "other version", prepare: writeFile(`{"version":2,"saved_at":"` + now.UTC().Format(time.RFC3339Nano) + `","sections":{}}`), restore: now, wantWarn: "version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			tt.prepare(t, path)
+
+			f, logs := newTestFile(t, path)
+			limiter := &memorySection{data: []byte("clean")}
+			f.Register("rate_limit", limiter)
+			f.Restore(tt.restore)
+
+			if string(limiter.data) != "clean" {
+				t.Errorf("restored %s from an unusable file", limiter.data)
+			}
+			if tt.wantWarn == "" && logs.Len() != 0 {
+				t.Errorf("logs = %q, want nothing", logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.wantWarn) {
+				t.Errorf("logs = %q, want a warning containing %q", logs.String(), tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestFileRestoresOtherSectionsPastAFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+	saveState(t, path, now)
+
+	f, logs := newTestFile(t, path)
+	limiter := &memorySection{restoreErr: errors.New("bad bucket")}
+	breaker := &memorySection{}
+	f.Register("rate_limit", limiter)
+	f.Register("db_breaker", breaker)
+	f.Restore(now)
+
+	if string(breaker.data) != `{"trips":3}` {
+		t.Errorf("breaker restored to %s", breaker.data)
+	}
+	if !strings.Contains(logs.String(), "starting rate_limit clean: bad bucket") {
+		t.Errorf("logs = %q, want the failed section reported", logs.String())
+	}
+}
+
+func writeFile(content string) func(t *testing.T, path string) {
+	return func(t *testing.T, path string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
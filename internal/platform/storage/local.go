@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// localScheme prefixes the locations handed out by LocalStorage
+const localScheme = "local://"
+
+// LocalStorage keeps objects as files under a root directory
+type LocalStorage struct {
+	root string
+}
+
+var _ ports.FileStorage = (*LocalStorage)(nil)
+
+// NewLocalStorage creates root if needed and stores objects beneath it
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating storage directory: %w", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers never see a partial object
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return localScheme + key, nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	path, err := s.locate(location)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ports.ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, location string) error {
+	path, err := s.locate(location)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) locate(location string) (string, error) {
+	key, ok := strings.CutPrefix(location, localScheme)
+	if !ok {
+		return "", fmt.Errorf("not a local storage location: %q", location)
+	}
+	return s.path(key)
+}
+
+// path maps a key to a file under root, refusing keys that would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid storage key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+type AuditRepository struct {
+	db *database.DB
+}
+
+var _ ports.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository(db *database.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO audit_events (id, actor_id, action, entity_type, entity_id, diff, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	diff, err := json.Marshal(event.Diff)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		event.ID,
+		event.ActorID,
+		event.Action,
+		event.EntityType,
+		event.EntityID,
+		string(diff),
+		event.CreatedAt,
+	)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
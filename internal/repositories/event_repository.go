@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+type EventRepository struct {
+	db *database.DB
+}
+
+func NewEventRepository(db *database.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+func (r *EventRepository) InsertBatch(ctx context.Context, events []domain.AnalyticsEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	values := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*3)
+	for i, event := range events {
+		n := i * 3
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d)", n+1, n+2, n+3))
+		properties := event.Properties
+		if properties == nil {
+			properties = map[string]interface{}{}
+		}
+		args = append(args, event.Name, properties, event.OccurredAt)
+	}
+
+	query := `INSERT INTO analytics_events (name, properties, occurred_at) VALUES ` + strings.Join(values, ", ")
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CreatePartition creates the partition holding events received on day's UTC date
+func (r *EventRepository) CreatePartition(ctx context.Context, day time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `SELECT create_analytics_events_partition($1::date)`, utcDate(day))
+	return err
+}
+
+func (r *EventRepository) DropPartitionsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var dropped int
+	err := r.db.QueryRowContext(ctx, `SELECT drop_analytics_events_partitions_before($1::date)`, utcDate(cutoff)).Scan(&dropped)
+	if err != nil {
+		return 0, err
+	}
+
+	return dropped, nil
+}
+
+// utcDate formats t's UTC date. Passing a time.Time instead would send a timestamptz,
+// which ::date converts in the session time zone rather than UTC.
+func utcDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestEventPartitionsFollowUTCDays(t *testing.T) {
+	db := testutil.OpenDB(t)
+	repo, tx := NewEventRepository(db), NewTransactor(db)
+	errRollback := errors.New("rollback")
+
+	// A session in New York would read a date bound as local midnight, so an event received
+	// at 00:30 UTC would land in the previous day's partition
+	day := time.Date(2099, 1, 2, 0, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	err := tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := db.ExecContext(ctx, `SET LOCAL TIME ZONE 'America/New_York'`); err != nil {
+			return err
+		}
+		for _, d := range []time.Time{day.AddDate(0, 0, -1), day} {
+			if err := repo.CreatePartition(ctx, d); err != nil {
+				t.Fatalf("CreatePartition(%v) error = %v", d, err)
+			}
+		}
+
+		var partition string
+		err := db.QueryRowContext(ctx, `
+			INSERT INTO analytics_events (name, properties, occurred_at, received_at)
+			VALUES ('page_view', '{}', $1, $1)
+			RETURNING tableoid::regclass::text`,
+			time.Date(2099, 1, 2, 0, 30, 0, 0, time.UTC)).Scan(&partition)
+		if err != nil {
+			t.Fatalf("insert error = %v", err)
+		}
+		if partition != "analytics_events_20990102" {
+			t.Errorf("event stored in %s, want analytics_events_20990102", partition)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+}
+
+func TestReboundLocalPartitions(t *testing.T) {
+	db := testutil.OpenDB(t)
+	repo, tx := NewEventRepository(db), NewTransactor(db)
+	errRollback := errors.New("rollback")
+
+	// Before 000014 a New York session bounded the 2099-01-01 partition at local midnight,
+	// so an event received at 03:00 UTC on the 2nd was stored in it
+	received := time.Date(2099, 1, 2, 3, 0, 0, 0, time.UTC)
+	err := tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := db.ExecContext(ctx, `SET LOCAL TIME ZONE 'America/New_York'`); err != nil {
+			return err
+		}
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE analytics_events_20990101 PARTITION OF analytics_events
+			FOR VALUES FROM ('2099-01-01') TO ('2099-01-02')`)
+		if err != nil {
+			t.Fatalf("create local partition: %v", err)
+		}
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO analytics_events (name, properties, occurred_at, received_at)
+			VALUES ('page_view', '{}', $1, $1)`, received)
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+
+		var rebound int
+		if err := db.QueryRowContext(ctx, `SELECT rebound_analytics_events_partitions()`).Scan(&rebound); err != nil {
+			t.Fatalf("rebound: %v", err)
+		}
+		if rebound < 1 {
+			t.Errorf("rebound %d partitions, want the local one", rebound)
+		}
+
+		// The next day now fits alongside, and the event moved to the UTC day it was received on
+		if err := repo.CreatePartition(ctx, received); err != nil {
+			t.Fatalf("CreatePartition() after rebound error = %v", err)
+		}
+		var partition string
+		err = db.QueryRowContext(ctx, `SELECT tableoid::regclass::text FROM analytics_events WHERE received_at = $1`, received).Scan(&partition)
+		if err != nil {
+			t.Fatalf("find event: %v", err)
+		}
+		if partition != "analytics_events_20990102" {
+			t.Errorf("event stored in %s, want analytics_events_20990102", partition)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+}
+
+func TestInsertBatchKeepsLargeIntegers(t *testing.T) {
+	db := testutil.OpenDB(t)
+	repo, tx := NewEventRepository(db), NewTransactor(db)
+	errRollback := errors.New("rollback")
+
+	err := tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := repo.CreatePartition(ctx, time.Now()); err != nil {
+			t.Fatalf("CreatePartition() error = %v", err)
+		}
+		event := domain.AnalyticsEvent{
+			Name:       "feature_used",
+			Properties: map[string]interface{}{"above_2_53": json.Number("9007199254740993")},
+			OccurredAt: time.Now().UTC(),
+		}
+		if err := repo.InsertBatch(ctx, []domain.AnalyticsEvent{event}); err != nil {
+			t.Fatalf("InsertBatch() error = %v", err)
+		}
+
+		var stored string
+		err := db.QueryRowContext(ctx, `
+			SELECT properties->>'above_2_53' FROM analytics_events
+			WHERE name = 'feature_used' ORDER BY received_at DESC LIMIT 1`).Scan(&stored)
+		if err != nil {
+			t.Fatalf("select error = %v", err)
+		}
+		if stored != "9007199254740993" {
+			t.Errorf("stored %s, want 9007199254740993", stored)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithinTx() error = %v", err)
+	}
+}
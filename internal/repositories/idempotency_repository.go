@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+type IdempotencyRepository struct {
+	db *database.DB
+}
+
+var _ ports.IdempotencyRepository = (*IdempotencyRepository)(nil)
+
+func NewIdempotencyRepository(db *database.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// An expired record is taken over in place, so a stale key never blocks a new request
+	query := `
+        INSERT INTO idempotency_keys (key, request_hash, expires_at, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (key) DO UPDATE
+        SET request_hash = EXCLUDED.request_hash,
+            response_status = NULL,
+            response_content_type = NULL,
+            response_body = NULL,
+            expires_at = EXCLUDED.expires_at,
+            created_at = EXCLUDED.created_at
+        WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+        RETURNING key`
+
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		record.Key,
+		record.RequestHash,
+		record.ExpiresAt,
+		record.CreatedAt,
+	).Scan(&record.Key)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return ports.ErrConflict
+		}
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) GetByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT key, request_hash, COALESCE(response_status, 0), COALESCE(response_content_type, ''),
+               response_body, expires_at, created_at
+        FROM idempotency_keys
+        WHERE key = $1`
+
+	record := &domain.IdempotencyRecord{}
+	err := r.db.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.ResponseStatus,
+		&record.ResponseContentType,
+		&record.ResponseBody,
+		&record.ExpiresAt,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ports.ErrNotFound
+		}
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE idempotency_keys
+        SET response_status = $1,
+            response_content_type = $2,
+            response_body = $3
+        WHERE key = $4`
+
+	result, err := r.db.ExecContext(ctx, query, status, contentType, body, key)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return 0, ports.ErrUnavailable
+		}
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
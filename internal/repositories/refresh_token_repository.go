@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+type RefreshTokenRepository struct {
+	db *database.DB
+}
+
+var _ ports.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+
+func NewRefreshTokenRepository(db *database.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+const insertRefreshToken = `
+        INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5)`
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.ExecContext(ctx, insertRefreshToken,
+		token.TokenHash,
+		token.UserID,
+		token.FamilyID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT token_hash, user_id, family_id, expires_at, revoked, created_at
+        FROM refresh_tokens
+        WHERE token_hash = $1`
+
+	token := &domain.RefreshToken{}
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.TokenHash,
+		&token.UserID,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&token.Revoked,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ports.ErrNotFound
+		}
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, oldHash string, next *domain.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if not committed
+
+	// Only one concurrent rotation of the same token can flip revoked
+	result, err := tx.ExecContext(ctx, `
+        UPDATE refresh_tokens
+        SET revoked = true
+        WHERE token_hash = $1 AND revoked = false`, oldHash)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now()
+	}
+	_, err = tx.ExecContext(ctx, insertRefreshToken,
+		next.TokenHash,
+		next.UserID,
+		next.FamilyID,
+		next.ExpiresAt,
+		next.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked = true WHERE family_id = $1 AND revoked = false`
+
+	if _, err := r.db.ExecContext(ctx, query, familyID); err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
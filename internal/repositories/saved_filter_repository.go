@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+// savedFilterConstraints declares the unique constraints on saved_filters and the fields they protect
+var savedFilterConstraints = database.ConstraintFields{
+	"saved_filters_pkey":           "id",
+	"idx_saved_filters_owner_name": "name",
+}
+
+type SavedFilterRepository struct {
+	db *database.DB
+}
+
+var _ ports.SavedFilterRepository = (*SavedFilterRepository)(nil)
+
+func NewSavedFilterRepository(db *database.DB) *SavedFilterRepository {
+	return &SavedFilterRepository{db: db}
+}
+
+func (r *SavedFilterRepository) Create(ctx context.Context, filter *domain.SavedFilter) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO saved_filters (id, owner_id, name, resource, params, shared, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	now := time.Now()
+	if filter.CreatedAt.IsZero() {
+		filter.CreatedAt = now
+	}
+	if filter.UpdatedAt.IsZero() {
+		filter.UpdatedAt = now
+	}
+	params, err := json.Marshal(filter.Params)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		filter.ID,
+		filter.OwnerID,
+		filter.Name,
+		filter.Resource,
+		string(params),
+		filter.Shared,
+		filter.CreatedAt,
+		filter.UpdatedAt,
+	)
+	if err != nil {
+		if field, ok := savedFilterConstraints.UniqueViolationField(err); ok {
+			return &ports.ConflictError{Field: field}
+		}
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *SavedFilterRepository) GetByID(ctx context.Context, id string) (*domain.SavedFilter, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, owner_id, name, resource, params, shared, created_at, updated_at
+        FROM saved_filters
+        WHERE id = $1`
+
+	filters, err := r.queryFilters(ctx, 1, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, ports.ErrNotFound
+	}
+
+	return &filters[0], nil
+}
+
+func (r *SavedFilterRepository) ListVisible(ctx context.Context, ownerID string) ([]domain.SavedFilter, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, owner_id, name, resource, params, shared, created_at, updated_at
+        FROM saved_filters
+        WHERE owner_id = $1 OR shared
+        ORDER BY name, id`
+
+	return r.queryFilters(ctx, 16, query, ownerID)
+}
+
+func (r *SavedFilterRepository) Update(ctx context.Context, filter *domain.SavedFilter) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE saved_filters
+        SET name = $1, resource = $2, params = $3, shared = $4, updated_at = $5
+        WHERE id = $6`
+
+	params, err := json.Marshal(filter.Params)
+	if err != nil {
+		return err
+	}
+	filter.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		filter.Name,
+		filter.Resource,
+		string(params),
+		filter.Shared,
+		filter.UpdatedAt,
+		filter.ID,
+	)
+	if err != nil {
+		if field, ok := savedFilterConstraints.UniqueViolationField(err); ok {
+			return &ports.ConflictError{Field: field}
+		}
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SavedFilterRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM saved_filters WHERE id = $1`, id)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SavedFilterRepository) queryFilters(ctx context.Context, capacity int, query string, args ...interface{}) ([]domain.SavedFilter, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := make([]domain.SavedFilter, 0, capacity)
+	for rows.Next() {
+		var filter domain.SavedFilter
+		var params []byte
+		if err := rows.Scan(
+			&filter.ID,
+			&filter.OwnerID,
+			&filter.Name,
+			&filter.Resource,
+			&params,
+			&filter.Shared,
+			&filter.CreatedAt,
+			&filter.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(params, &filter.Params); err != nil {
+			return nil, fmt.Errorf("saved filter %s has unreadable params: %w", filter.ID, err)
+		}
+		filters = append(filters, filter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestSavedFilterRepository(t *testing.T) {
+	db := testutil.OpenDB(t)
+	testutil.EnableLeakDetection(t, db)
+	users := NewUserRepository(db)
+	repo := NewSavedFilterRepository(db)
+	ctx := context.Background()
+
+	factory.User().WithID("a1").WithEmail("one@example.com").Create(t, users)
+	factory.User().WithID("a2").WithEmail("two@example.com").Create(t, users)
+	mine := factory.SavedFilter("a1").WithID("f1").WithName("Newest").
+		WithParam("sort", "created_at").WithParam("order", "desc").Create(t, repo)
+	factory.SavedFilter("a2").WithID("f2").WithName("Private").Create(t, repo)
+	factory.SavedFilter("a2").WithID("f3").WithName("Alphabetical").WithParam("sort", "email").Shared().Create(t, repo)
+
+	// Names are unique per owner only
+	var conflict *ports.ConflictError
+	err := repo.Create(ctx, factory.SavedFilter("a1").WithID("f4").WithName("Newest").Build())
+	if !errors.As(err, &conflict) || conflict.Field != "name" {
+		t.Errorf("Create() with a taken name error = %v, want a conflict on name", err)
+	}
+	if err := repo.Create(ctx, factory.SavedFilter("a2").WithID("f5").WithName("Newest").Build()); err != nil {
+		t.Errorf("Create() with another owner's name error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, "f1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !maps.Equal(got.Params, mine.Params) || got.OwnerID != "a1" {
+		t.Errorf("GetByID() = %+v, want %+v", got, mine)
+	}
+
+	visible, err := repo.ListVisible(ctx, "a1")
+	if err != nil {
+		t.Fatalf("ListVisible() error = %v", err)
+	}
+	if len(visible) != 2 || visible[0].ID != "f3" || visible[1].ID != "f1" {
+		t.Errorf("ListVisible() = %+v, want the shared f3 then the owned f1", visible)
+	}
+
+	mine.Name, mine.Shared = "Newest first", true
+	if err := repo.Update(ctx, mine); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, "f1"); got.Name != "Newest first" || !got.Shared {
+		t.Errorf("after Update() = %+v", got)
+	}
+
+	if err := repo.Delete(ctx, "f1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "f1"); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("GetByID() after Delete error = %v, want %v", err, ports.ErrNotFound)
+	}
+	if err := repo.Update(ctx, mine); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("Update() of a deleted filter error = %v, want %v", err, ports.ErrNotFound)
+	}
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+)
+
+// Shadow mirrors a configurable percentage of repository traffic to a
+// secondary implementation and reports divergence without ever affecting
+// the primary result. Wrap any repository with it during migration windows.
+type Shadow struct {
+	name        string
+	percent     atomic.Int32
+	mirrored    atomic.Int64
+	failures    atomic.Int64
+	divergences atomic.Int64
+}
+
+var _ ports.ShadowControl = (*Shadow)(nil)
+
+func NewShadow(name string, percent int) *Shadow {
+	s := &Shadow{name: name}
+	s.SetPercent(percent)
+	return s
+}
+
+// SetPercent changes the share of traffic mirrored, clamped to 0–100. Safe to call at runtime.
+func (s *Shadow) SetPercent(percent int) {
+	percent = max(0, min(100, percent))
+	s.percent.Store(int32(percent))
+}
+
+// Percent returns the share of traffic mirrored
+func (s *Shadow) Percent() int {
+	return int(s.percent.Load())
+}
+
+// Stats returns the current mirroring counters
+func (s *Shadow) Stats() ports.ShadowStats {
+	return ports.ShadowStats{
+		Mirrored:    s.mirrored.Load(),
+		Failures:    s.failures.Load(),
+		Divergences: s.divergences.Load(),
+	}
+}
+
+func (s *Shadow) sampled() bool {
+	p := s.percent.Load()
+	return p > 0 && (p >= 100 || rand.Int32N(100) < p)
+}
+
+// Write mirrors a mutation that already succeeded on the primary. Shadow
+// errors are logged and counted but never returned.
+func (s *Shadow) Write(ctx context.Context, op string, write func(ctx context.Context) error) {
+	if !s.sampled() {
+		return
+	}
+	s.mirrored.Add(1)
+
+	if err := write(context.WithoutCancel(ctx)); err != nil {
+		s.failures.Add(1)
+		log.Printf("shadow %s: %s failed: %v", s.name, op, err)
+	}
+}
+
+// ShadowRead compares the primary result of a read against the shadow target
+// in the background. Two errors count as agreement when errors.Is matches.
+func ShadowRead[T any](s *Shadow, ctx context.Context, op string, primary T, primaryErr error,
+	read func(ctx context.Context) (T, error), equal func(a, b T) bool) {
+	if !s.sampled() {
+		return
+	}
+	s.mirrored.Add(1)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+		defer cancel()
+
+		shadow, shadowErr := read(ctx)
+		switch {
+		case primaryErr != nil || shadowErr != nil:
+			if primaryErr != nil && shadowErr != nil && errors.Is(shadowErr, primaryErr) {
+				return
+			}
+			if primaryErr == nil {
+				s.failures.Add(1)
+			}
+			s.divergences.Add(1)
+			log.Printf("shadow %s: %s diverged: primary error %v, shadow error %v", s.name, op, primaryErr, shadowErr)
+		case !equal(primary, shadow):
+			s.divergences.Add(1)
+			log.Printf("shadow %s: %s diverged: results differ", s.name, op)
+		}
+	}()
+}
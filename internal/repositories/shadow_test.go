@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+// logBuffer collects log output written from shadow goroutines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func captureLogs(t *testing.T) *logBuffer {
+	var b logBuffer
+	log.SetOutput(&b)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &b
+}
+
+// waitForShadow waits until done accepts the shadow's counters
+func waitForShadow(t *testing.T, s *Shadow, done func(ports.ShadowStats) bool) ports.ShadowStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := s.Stats()
+		if done(stats) {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("shadow stats = %+v", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShadowReadDivergence(t *testing.T) {
+	alice := factory.User().WithID("alice").WithEmail("alice@example.com").Build()
+	renamed := factory.User().WithID("alice").WithEmail("alice@new.example.com").Build()
+
+	tests := []struct {
+		name            string
+		shadow          *testutil.UserRepository
+		read            func(ports.UserRepository) error
+		wantDivergences int64
+		wantFailures    int64
+		wantLog         string
+	}{
+		{name: "same user", shadow: testutil.NewUserRepository(alice), read: func(r ports.UserRepository) error {
+			_, err := r.GetByID(context.Background(), "alice")
+			return err
+		}},
+		{name: "different fields", shadow: testutil.NewUserRepository(renamed), read: func(r ports.UserRepository) error {
+			_, err := r.GetByID(context.Background(), "alice")
+			return err
+		}, wantDivergences: 1, wantLog: "shadow users: GetByID diverged: results differ"},
+		{name: "missing on shadow", shadow: testutil.NewUserRepository(), read: func(r ports.UserRepository) error {
+			_, err := r.GetByEmail(context.Background(), "alice@example.com")
+			return err
+		}, wantDivergences: 1, wantFailures: 1, wantLog: "shadow users: GetByEmail diverged: primary error <nil>"},
+		{name: "both missing", shadow: testutil.NewUserRepository(), read: func(r ports.UserRepository) error {
+			_, err := r.GetByID(context.Background(), "ghost")
+			if !errors.Is(err, ports.ErrNotFound) {
+				return err
+			}
+			return nil
+		}},
+		{name: "different counts", shadow: testutil.NewUserRepository(alice, factory.User().WithID("bob").WithEmail("bob@example.com").Build()), read: func(r ports.UserRepository) error {
+			_, err := r.Count(context.Background(), ports.UserFilter{})
+			return err
+		}, wantDivergences: 1, wantLog: "shadow users: Count diverged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			control := NewShadow("users", 100)
+			repo := NewShadowUserRepository(testutil.NewUserRepository(alice), tt.shadow, control)
+
+			if err := tt.read(repo); err != nil {
+				t.Fatalf("read error = %v; the primary result must be returned", err)
+			}
+
+			// Reads are compared in the background; both counters settle once the comparison is logged
+			waitForShadow(t, control, func(s ports.ShadowStats) bool {
+				return s.Mirrored == 1 && (tt.wantLog == "" || strings.Contains(logs.String(), tt.wantLog))
+			})
+			time.Sleep(10 * time.Millisecond) // let a matching comparison finish too
+			stats := control.Stats()
+			if stats.Divergences != tt.wantDivergences || stats.Failures != tt.wantFailures {
+				t.Errorf("stats = %+v, want %d divergences and %d failures", stats, tt.wantDivergences, tt.wantFailures)
+			}
+			if tt.wantLog == "" && strings.Contains(logs.String(), "diverged") {
+				t.Errorf("logged divergence for matching results: %s", logs)
+			}
+		})
+	}
+}
+
+func TestShadowWrite(t *testing.T) {
+	logs := captureLogs(t)
+	primary := testutil.NewUserRepository()
+	shadow := testutil.NewUserRepository()
+	control := NewShadow("users", 100)
+	repo := NewShadowUserRepository(primary, shadow, control)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, factory.User().WithID("u1").WithEmail("one@example.com").Build()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, ok := shadow.Stored("u1"); !ok {
+		t.Error("Create() was not mirrored to the shadow")
+	}
+
+	// A failing shadow is logged and counted but never surfaces to the caller
+	shadow.Err = errors.New("shadow down")
+	if err := repo.Create(ctx, factory.User().WithID("u2").WithEmail("two@example.com").Build()); err != nil {
+		t.Fatalf("Create() with a failing shadow error = %v", err)
+	}
+	if _, ok := primary.Stored("u2"); !ok {
+		t.Error("primary did not store u2")
+	}
+	if stats := control.Stats(); stats.Mirrored != 2 || stats.Failures != 1 {
+		t.Errorf("stats = %+v, want 2 mirrored with 1 failure", stats)
+	}
+	if !strings.Contains(logs.String(), "shadow users: Create failed: shadow down") {
+		t.Errorf("logs = %q", logs)
+	}
+
+	// Failed primary writes are not mirrored
+	if err := repo.Create(ctx, factory.User().WithID("u1").WithEmail("dup@example.com").Build()); err == nil {
+		t.Fatal("Create() of a duplicate succeeded")
+	}
+	if stats := control.Stats(); stats.Mirrored != 2 {
+		t.Errorf("stats = %+v, want the failed write left unmirrored", stats)
+	}
+}
+
+func TestShadowPercent(t *testing.T) {
+	control := NewShadow("users", 0)
+	repo := NewShadowUserRepository(testutil.NewUserRepository(), testutil.NewUserRepository(), control)
+	ctx := context.Background()
+
+	repo.ExistsByID(ctx, "u1")
+	if stats := control.Stats(); stats.Mirrored != 0 {
+		t.Errorf("shadowed %d calls at 0%%", stats.Mirrored)
+	}
+
+	control.SetPercent(150)
+	if control.Percent() != 100 {
+		t.Errorf("Percent() = %d, want it clamped to 100", control.Percent())
+	}
+	repo.ExistsByID(ctx, "u1")
+	waitForShadow(t, control, func(s ports.ShadowStats) bool { return s.Mirrored == 1 })
+}
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// ShadowUserRepository serves every call from primary while mirroring
+// writes and comparing reads against shadow, as controlled by Shadow
+type ShadowUserRepository struct {
+	primary ports.UserRepository
+	shadow  ports.UserRepository
+	control *Shadow
+}
+
+var _ ports.UserRepository = (*ShadowUserRepository)(nil)
+
+func NewShadowUserRepository(primary, shadow ports.UserRepository, control *Shadow) *ShadowUserRepository {
+	return &ShadowUserRepository{primary: primary, shadow: shadow, control: control}
+}
+
+func (r *ShadowUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if err := r.primary.Create(ctx, user); err != nil {
+		return err
+	}
+
+	// Mirror the row as the primary stored it so IDs and timestamps match
+	mirrored := *user
+	r.control.Write(ctx, "Create", func(ctx context.Context) error {
+		return r.shadow.Create(ctx, &mirrored)
+	})
+	return nil
+}
+
+func (r *ShadowUserRepository) CreateMany(ctx context.Context, users []*domain.User) error {
+	if err := r.primary.CreateMany(ctx, users); err != nil {
+		return err
+	}
+
+	mirrored := make([]*domain.User, len(users))
+	for i, user := range users {
+		copied := *user
+		mirrored[i] = &copied
+	}
+	r.control.Write(ctx, "CreateMany", func(ctx context.Context) error {
+		return r.shadow.CreateMany(ctx, mirrored)
+	})
+	return nil
+}
+
+func (r *ShadowUserRepository) ImportMany(ctx context.Context, users []*domain.User) ([]bool, error) {
+	inserted, err := r.primary.ImportMany(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only rows the primary actually wrote are mirrored
+	var mirrored []*domain.User
+	for i, user := range users {
+		if inserted[i] {
+			copied := *user
+			mirrored = append(mirrored, &copied)
+		}
+	}
+	r.control.Write(ctx, "ImportMany", func(ctx context.Context) error {
+		_, err := r.shadow.ImportMany(ctx, mirrored)
+		return err
+	})
+	return inserted, nil
+}
+
+func (r *ShadowUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user, err := r.primary.GetByID(ctx, id)
+	ShadowRead(r.control, ctx, "GetByID", user, err, func(ctx context.Context) (*domain.User, error) {
+		return r.shadow.GetByID(ctx, id)
+	}, sameUser)
+	return user, err
+}
+
+func (r *ShadowUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := r.primary.GetByEmail(ctx, email)
+	ShadowRead(r.control, ctx, "GetByEmail", user, err, func(ctx context.Context) (*domain.User, error) {
+		return r.shadow.GetByEmail(ctx, email)
+	}, sameUser)
+	return user, err
+}
+
+func (r *ShadowUserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	exists, err := r.primary.ExistsByID(ctx, id)
+	ShadowRead(r.control, ctx, "ExistsByID", exists, err, func(ctx context.Context) (bool, error) {
+		return r.shadow.ExistsByID(ctx, id)
+	}, func(a, b bool) bool { return a == b })
+	return exists, err
+}
+
+func (r *ShadowUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	exists, err := r.primary.ExistsByEmail(ctx, email)
+	ShadowRead(r.control, ctx, "ExistsByEmail", exists, err, func(ctx context.Context) (bool, error) {
+		return r.shadow.ExistsByEmail(ctx, email)
+	}, func(a, b bool) bool { return a == b })
+	return exists, err
+}
+
+func (r *ShadowUserRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	count, err := r.primary.CountByRole(ctx, role)
+	ShadowRead(r.control, ctx, "CountByRole", count, err, func(ctx context.Context) (int, error) {
+		return r.shadow.CountByRole(ctx, role)
+	}, func(a, b int) bool { return a == b })
+	return count, err
+}
+
+func (r *ShadowUserRepository) Update(ctx context.Context, user *domain.User) error {
+	if err := r.primary.Update(ctx, user); err != nil {
+		return err
+	}
+
+	mirrored := *user
+	r.control.Write(ctx, "Update", func(ctx context.Context) error {
+		return r.shadow.Update(ctx, &mirrored)
+	})
+	return nil
+}
+
+func (r *ShadowUserRepository) Delete(ctx context.Context, id string) error {
+	if err := r.primary.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.control.Write(ctx, "Delete", func(ctx context.Context) error {
+		return r.shadow.Delete(ctx, id)
+	})
+	return nil
+}
+
+func (r *ShadowUserRepository) Restore(ctx context.Context, id string) error {
+	if err := r.primary.Restore(ctx, id); err != nil {
+		return err
+	}
+
+	r.control.Write(ctx, "Restore", func(ctx context.Context) error {
+		return r.shadow.Restore(ctx, id)
+	})
+	return nil
+}
+
+func (r *ShadowUserRepository) GetDeletedByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := r.primary.GetDeletedByEmail(ctx, email)
+	ShadowRead(r.control, ctx, "GetDeletedByEmail", user, err, func(ctx context.Context) (*domain.User, error) {
+		return r.shadow.GetDeletedByEmail(ctx, email)
+	}, sameUser)
+	return user, err
+}
+
+func (r *ShadowUserRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	purged, err := r.primary.PurgeDeletedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	r.control.Write(ctx, "PurgeDeletedBefore", func(ctx context.Context) error {
+		_, err := r.shadow.PurgeDeletedBefore(ctx, cutoff)
+		return err
+	})
+	return purged, nil
+}
+
+func (r *ShadowUserRepository) List(ctx context.Context, filter ports.UserFilter) ([]domain.User, error) {
+	users, err := r.primary.List(ctx, filter)
+	ShadowRead(r.control, ctx, "List", users, err, func(ctx context.Context) ([]domain.User, error) {
+		return r.shadow.List(ctx, filter)
+	}, sameUsers)
+	return users, err
+}
+
+func (r *ShadowUserRepository) Count(ctx context.Context, filter ports.UserFilter) (int64, error) {
+	count, err := r.primary.Count(ctx, filter)
+	ShadowRead(r.control, ctx, "Count", count, err, func(ctx context.Context) (int64, error) {
+		return r.shadow.Count(ctx, filter)
+	}, func(a, b int64) bool { return a == b })
+	return count, err
+}
+
+func (r *ShadowUserRepository) SearchByEmail(ctx context.Context, prefix string, limit int) ([]domain.User, error) {
+	users, err := r.primary.SearchByEmail(ctx, prefix, limit)
+	ShadowRead(r.control, ctx, "SearchByEmail", users, err, func(ctx context.Context) ([]domain.User, error) {
+		return r.shadow.SearchByEmail(ctx, prefix, limit)
+	}, sameUsers)
+	return users, err
+}
+
+// ForEach is served by primary only; streaming both sides in lockstep isn't worth the extra connection
+func (r *ShadowUserRepository) ForEach(ctx context.Context, createdAfter time.Time, fn func(*domain.User) error) error {
+	return r.primary.ForEach(ctx, createdAfter, fn)
+}
+
+func sameUsers(a, b []domain.User) bool {
+	return slices.EqualFunc(a, b, func(x, y domain.User) bool { return sameUser(&x, &y) })
+}
+
+func sameUser(a, b *domain.User) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID &&
+		a.Email == b.Email &&
+		a.Password == b.Password &&
+		a.Role == b.Role &&
+		sameTime(a.EmailVerifiedAt, b.EmailVerifiedAt) &&
+		samePtr(a.AvatarURL, b.AvatarURL) &&
+		a.Version == b.Version &&
+		sameTime(a.DeletedAt, b.DeletedAt) &&
+		a.CreatedAt.Equal(b.CreatedAt) &&
+		a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func samePtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+type TokenRepository struct {
+	db *database.DB
+}
+
+var _ ports.TokenRepository = (*TokenRepository)(nil)
+
+func NewTokenRepository(db *database.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *domain.VerificationToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO verification_tokens (token_hash, user_id, expires_at, created_at)
+        VALUES ($1, $2, $3, $4)`
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.TokenHash,
+		token.UserID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*domain.VerificationToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT token_hash, user_id, expires_at, used_at, created_at
+        FROM verification_tokens
+        WHERE token_hash = $1`
+
+	token := &domain.VerificationToken{}
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.TokenHash,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ports.ErrNotFound
+		}
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// MarkUsed only updates a token that hasn't been used, so concurrent redemptions can't both succeed
+func (r *TokenRepository) MarkUsed(ctx context.Context, hash string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE verification_tokens
+        SET used_at = $1
+        WHERE token_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, at, hash)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
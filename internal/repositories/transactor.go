@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/platform/database"
+)
+
+// Transactor runs units of work in a database transaction that every repository
+// built on the same database.DB joins through the context
+type Transactor struct {
+	db *database.DB
+}
+
+var _ ports.Transactor = (*Transactor)(nil)
+
+func NewTransactor(db *database.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := t.db.WithinTx(ctx, fn)
+	if database.IsPoolSaturated(err) {
+		return ports.ErrUnavailable
+	}
+	return err
+}
+
+func (t *Transactor) LockEntity(ctx context.Context, entityType, id string) error {
+	return t.db.LockEntity(ctx, entityType, id)
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestTransactorCommitsAuditWithMutation(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name       string
+		auditID    string // an existing audit ID makes the audit insert fail
+		fnErr      error
+		wantCommit bool
+	}{
+		{name: "commit", auditID: "a-new", wantCommit: true},
+		{name: "unit of work fails", auditID: "a-new", fnErr: errAbort},
+		{name: "audit insert fails", auditID: "a-existing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.OpenDB(t)
+			users, audits, tx := NewUserRepository(db), NewAuditRepository(db), NewTransactor(db)
+			ctx := context.Background()
+
+			if err := audits.Create(ctx, &domain.AuditEvent{ID: "a-existing", Action: "seed", EntityType: "user", EntityID: "seed"}); err != nil {
+				t.Fatalf("seed audit: %v", err)
+			}
+
+			err := tx.WithinTx(ctx, func(ctx context.Context) error {
+				if err := users.Create(ctx, factory.User().WithID("u1").WithEmail("user@example.com").Build()); err != nil {
+					return err
+				}
+				event := &domain.AuditEvent{ID: tt.auditID, Action: domain.AuditUserCreated, EntityType: domain.AuditEntityUser, EntityID: "u1"}
+				if err := audits.Create(ctx, event); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if (err == nil) != tt.wantCommit {
+				t.Fatalf("WithinTx() error = %v, want commit %v", err, tt.wantCommit)
+			}
+
+			userExists, err := users.ExistsByID(ctx, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var audited int
+			if err := db.QueryRowContext(ctx, `SELECT count(*) FROM audit_events WHERE entity_id = 'u1'`).Scan(&audited); err != nil {
+				t.Fatal(err)
+			}
+			if userExists != tt.wantCommit || (audited == 1) != tt.wantCommit {
+				t.Errorf("user stored %v with %d audit rows, want both committed = %v", userExists, audited, tt.wantCommit)
+			}
+		})
+	}
+}
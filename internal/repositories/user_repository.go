@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"example.com/monolithic/internal/core/domain"
@@ -10,6 +13,13 @@ import (
 	"example.com/monolithic/internal/platform/database"
 )
 
+// userConstraints declares the unique constraints on users and the fields they protect
+var userConstraints = database.ConstraintFields{
+	"users_pkey":            "id",
+	"idx_users_email":       "email",
+	"idx_users_email_lower": "email",
+}
+
 type UserRepository struct {
 	db *database.DB
 }
@@ -23,9 +33,9 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	defer cancel()
 
 	query := `
-        INSERT INTO users (id, email, password, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id`
+        INSERT INTO users (id, email, password, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, version`
 
 	// Set timestamps if not already set
 	now := time.Now()
@@ -35,19 +45,26 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	if user.UpdatedAt.IsZero() {
 		user.UpdatedAt = now
 	}
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
 
 	err := r.db.QueryRowContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
-	).Scan(&user.ID)
+	).Scan(&user.ID, &user.Version)
 
 	if err != nil {
 		// Check for unique constraint violation
-		if database.IsUniqueViolation(err) {
-			return ports.ErrDuplicateEmail
+		if field, ok := userConstraints.UniqueViolationField(err); ok {
+			return &ports.ConflictError{Field: field}
+		}
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
 		}
 		return err
 	}
@@ -55,20 +72,123 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// CreateMany inserts all users in one transaction, joining the caller's WithinTx when there
+// is one; either every row commits or none do. A failing row is reported as a
+// *ports.BatchItemError carrying its index.
+func (r *UserRepository) CreateMany(ctx context.Context, users []*domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO users (id, email, password, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, version`
+
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for i, user := range users {
+			setCreateDefaults(user, now)
+			err := r.db.QueryRowContext(ctx, query,
+				user.ID,
+				user.Email,
+				user.Password,
+				user.Role,
+				user.CreatedAt,
+				user.UpdatedAt,
+			).Scan(&user.ID, &user.Version)
+			if err != nil {
+				if field, ok := userConstraints.UniqueViolationField(err); ok {
+					return &ports.BatchItemError{Index: i, Err: &ports.ConflictError{Field: field}}
+				}
+				return &ports.BatchItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+	if database.IsPoolSaturated(err) {
+		return ports.ErrUnavailable
+	}
+	return err
+}
+
+// ImportMany inserts users in one transaction, joining the caller's WithinTx when there is
+// one, and skips rows that collide with an existing user. inserted[i] reports whether
+// users[i] was written.
+func (r *UserRepository) ImportMany(ctx context.Context, users []*domain.User) ([]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// An existing row makes RETURNING yield nothing instead of aborting the transaction
+	query := `
+        INSERT INTO users (id, email, password, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT DO NOTHING
+        RETURNING id, version`
+
+	inserted := make([]bool, len(users))
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for i, user := range users {
+			setCreateDefaults(user, now)
+			err := r.db.QueryRowContext(ctx, query,
+				user.ID,
+				user.Email,
+				user.Password,
+				user.Role,
+				user.CreatedAt,
+				user.UpdatedAt,
+			).Scan(&user.ID, &user.Version)
+			switch {
+			case err == nil:
+				inserted[i] = true
+			case errors.Is(err, database.ErrNoRows):
+			default:
+				return &ports.BatchItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// setCreateDefaults fills the timestamps and role of a user about to be inserted
+func setCreateDefaults(user *domain.User, now time.Time) {
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	query := `
-        SELECT id, email, password, created_at, updated_at
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
         FROM users
-        WHERE id = $1`
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	user := &domain.User{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.AvatarURL,
+		&user.Version,
+		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -77,27 +197,105 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User,
 		if errors.Is(err, database.ErrNoRows) {
 			return nil, ports.ErrNotFound
 		}
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
 		return nil, err
 	}
 
 	return user, nil
 }
 
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
+        FROM users
+        WHERE email = $1 AND deleted_at IS NULL`
+
+	user := &domain.User{}
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.AvatarURL,
+		&user.Version,
+		&user.DeletedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ports.ErrNotFound
+		}
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return false, ports.ErrUnavailable
+		}
+		return false, err
+	}
+
+	return exists, nil
+}
+
 func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND deleted_at IS NULL)`
 
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
 	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return false, ports.ErrUnavailable
+		}
 		return false, err
 	}
 
 	return exists, nil
 }
 
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `SELECT count(*) FROM users WHERE role = $1 AND deleted_at IS NULL`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, role).Scan(&count)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return 0, ports.ErrUnavailable
+		}
+		return 0, err
+	}
+
+	return count, nil
+}
+
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
@@ -106,29 +304,49 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
         UPDATE users
         SET email = $1,
             password = $2,
-            updated_at = $3
-        WHERE id = $4`
+            role = $3,
+            email_verified_at = $4,
+            avatar_url = $5,
+            updated_at = $6,
+            version = version + 1
+        WHERE id = $7 AND version = $8 AND deleted_at IS NULL`
 
-	user.UpdatedAt = time.Now()
+	updatedAt := time.Now()
 
 	result, err := r.db.ExecContext(ctx, query,
 		user.Email,
 		user.Password,
-		user.UpdatedAt,
+		user.Role,
+		user.EmailVerifiedAt,
+		user.AvatarURL,
+		updatedAt,
 		user.ID,
+		user.Version,
 	)
 	if err != nil {
-		if database.IsUniqueViolation(err) {
-			return ports.ErrDuplicateEmail
+		if field, ok := userConstraints.UniqueViolationField(err); ok {
+			return &ports.ConflictError{Field: field}
+		}
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
 		}
 		return err
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
+		// Tell a stale version apart from a missing row
+		exists, err := r.ExistsByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ports.ErrVersionConflict
+		}
 		return ports.ErrNotFound
 	}
 
+	user.UpdatedAt = updatedAt
+	user.Version++
 	return nil
 }
 
@@ -136,10 +354,17 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `DELETE FROM users WHERE id = $1`
+	query := `
+        UPDATE users
+        SET deleted_at = $1,
+            version = version + 1
+        WHERE id = $2 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
 		return err
 	}
 
@@ -151,32 +376,264 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// Additional helper methods
+func (r *UserRepository) Restore(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
 
-func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := `
+        UPDATE users
+        SET deleted_at = NULL,
+            updated_at = $1,
+            version = version + 1
+        WHERE id = $2 AND deleted_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetDeletedByEmail(ctx context.Context, email string) (*domain.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	query := `
-        SELECT id, email, password, created_at, updated_at
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
         FROM users
-        WHERE email = $1`
+        WHERE email = $1 AND deleted_at IS NOT NULL`
 
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	users, err := r.queryUsers(ctx, 1, query, email)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ports.ErrNotFound
+	}
 
+	return &users[0], nil
+}
+
+func (r *UserRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE deleted_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff)
 	if err != nil {
-		if errors.Is(err, database.ErrNoRows) {
-			return nil, ports.ErrNotFound
+		if database.IsPoolSaturated(err) {
+			return 0, ports.ErrUnavailable
 		}
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *UserRepository) List(ctx context.Context, filter ports.UserFilter) ([]domain.User, error) {
+	orderBy, err := userOrderBy(filter.Sort)
+	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	where := userFilterWhere(filter)
+	query := `
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
+        FROM users` + where.String() + `
+        ORDER BY ` + orderBy + `
+        LIMIT ` + where.arg(filter.Limit) + ` OFFSET ` + where.arg(filter.Offset)
+
+	return r.queryUsers(ctx, filter.Limit, query, where.args...)
+}
+
+func (r *UserRepository) Count(ctx context.Context, filter ports.UserFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	where := userFilterWhere(filter)
+	query := `
+        SELECT count(*)
+        FROM users` + where.String()
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, where.args...).Scan(&count)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return 0, ports.ErrUnavailable
+		}
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// userFilterWhere builds the conditions List and Count share, so a page and its total always agree
+func userFilterWhere(filter ports.UserFilter) whereBuilder {
+	where := whereBuilder{conditions: []string{"deleted_at IS NULL"}}
+	if !filter.CreatedAfter.IsZero() {
+		where.add("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where.add("created_at <= $%d", filter.CreatedBefore)
+	}
+	return where
+}
+
+// SearchByEmail finds users whose email starts with prefix, case-insensitively.
+// The match is written as lower(email) LIKE so idx_users_email_lower_pattern can serve it.
+func (r *UserRepository) SearchByEmail(ctx context.Context, prefix string, limit int) ([]domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
+        FROM users
+        WHERE lower(email) LIKE lower($1) || '%' ESCAPE '\'
+          AND deleted_at IS NULL
+        ORDER BY email
+        LIMIT $2`
+
+	return r.queryUsers(ctx, limit, query, escapeLike(prefix), limit)
+}
+
+// ForEach walks the result set with a cursor so memory use doesn't grow with the table.
+// It has no timeout of its own; the caller's context bounds the scan.
+func (r *UserRepository) ForEach(ctx context.Context, createdAfter time.Time, fn func(*domain.User) error) error {
+	query := `
+        SELECT id, email, password, role, email_verified_at, avatar_url, version, deleted_at, created_at, updated_at
+        FROM users
+        WHERE created_at > $1 AND deleted_at IS NULL
+        ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, createdAfter)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return ports.ErrUnavailable
+		}
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Password,
+			&user.Role,
+			&user.EmailVerifiedAt,
+			&user.AvatarURL,
+			&user.Version,
+			&user.DeletedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *UserRepository) queryUsers(ctx context.Context, capacity int, query string, args ...interface{}) ([]domain.User, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if database.IsPoolSaturated(err) {
+			return nil, ports.ErrUnavailable
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0, capacity)
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Password,
+			&user.Role,
+			&user.EmailVerifiedAt,
+			&user.AvatarURL,
+			&user.Version,
+			&user.DeletedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// whereBuilder assembles a WHERE clause from fixed condition strings, binding each value
+// as the next positional argument so values never end up in the SQL text.
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition whose single %d verb becomes the placeholder for value
+func (b *whereBuilder) add(condition string, value interface{}) {
+	b.conditions = append(b.conditions, fmt.Sprintf(condition, len(b.args)+1))
+	b.args = append(b.args, value)
+}
+
+// arg binds value after the conditions' arguments and returns its placeholder
+func (b *whereBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// String returns the clause on its own line, or "" when there are no conditions
+func (b *whereBuilder) String() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "\n        WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// userSortColumns maps each of ports.UserSortFields to its column.
+// Only these strings are ever written into an ORDER BY clause.
+var userSortColumns = map[string]string{
+	"created_at": "created_at",
+	"email":      "email",
+	"updated_at": "updated_at",
+}
+
+// userOrderBy builds the ORDER BY clause for sort, with id as a tiebreaker so pages don't overlap
+func userOrderBy(sort ports.UserSort) (string, error) {
+	column, ok := userSortColumns[sort.Field]
+	if !ok {
+		return "", fmt.Errorf("unknown user sort field %q", sort.Field)
+	}
+	if sort.Desc {
+		return column + " DESC, id DESC", nil
+	}
+	return column + ", id", nil
+}
+
+// escapeLike escapes LIKE wildcards so user input only ever matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+	"example.com/monolithic/internal/factory"
+	"example.com/monolithic/internal/testutil"
+)
+
+func TestUserRepositoryCreate(t *testing.T) {
+	db := testutil.OpenDB(t)
+	testutil.EnableLeakDetection(t, db)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	factory.User().WithID("u1").WithEmail("user@example.com").Create(t, repo)
+
+	tests := []struct {
+		name      string
+		user      *domain.User
+		wantField string
+	}{
+		{name: "same id", user: factory.User().WithID("u1").WithEmail("other@example.com").Build(), wantField: "id"},
+		{name: "same email", user: factory.User().WithID("u2").WithEmail("user@example.com").Build(), wantField: "email"},
+		{name: "email in another case", user: factory.User().WithID("u3").WithEmail("USER@example.com").Build(), wantField: "email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.Create(ctx, tt.user)
+			var conflict *ports.ConflictError
+			if !errors.As(err, &conflict) || conflict.Field != tt.wantField {
+				t.Errorf("Create() error = %v, want a conflict on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryUpdate(t *testing.T) {
+	db := testutil.OpenDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	factory.User().WithID("u1").WithEmail("one@example.com").Create(t, repo)
+	factory.User().WithID("u2").WithEmail("two@example.com").Create(t, repo)
+
+	tests := []struct {
+		name    string
+		id      string
+		email   string
+		version int
+		wantErr error
+	}{
+		{name: "current version", id: "u1", email: "uno@example.com", version: 1},
+		{name: "stale version", id: "u1", email: "eins@example.com", version: 1, wantErr: ports.ErrVersionConflict},
+		{name: "taken email", id: "u1", email: "two@example.com", version: 2, wantErr: ports.ErrConflict},
+		{name: "missing user", id: "ghost", email: "ghost@example.com", version: 1, wantErr: ports.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := factory.User().WithID(tt.id).WithEmail(tt.email).Build()
+			user.Version = tt.version
+			err := repo.Update(ctx, user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.Version != tt.version+1 {
+				t.Errorf("version = %d, want %d", user.Version, tt.version+1)
+			}
+		})
+	}
+}
+
+func TestUserRepositorySoftDelete(t *testing.T) {
+	db := testutil.OpenDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	factory.User().WithID("u1").WithEmail("user@example.com").Create(t, repo)
+	if err := repo.Delete(ctx, "u1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, "u1"); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("GetByID() after delete error = %v, want %v", err, ports.ErrNotFound)
+	}
+	if err := repo.Delete(ctx, "u1"); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want %v", err, ports.ErrNotFound)
+	}
+	if _, err := repo.GetDeletedByEmail(ctx, "user@example.com"); err != nil {
+		t.Errorf("GetDeletedByEmail() error = %v", err)
+	}
+
+	if err := repo.Restore(ctx, "u1"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "u1"); err != nil {
+		t.Errorf("GetByID() after restore error = %v", err)
+	}
+	if err := repo.Restore(ctx, "u1"); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("Restore() of an active user error = %v, want %v", err, ports.ErrNotFound)
+	}
+}
+
+func TestUserRepositoryList(t *testing.T) {
+	db := testutil.OpenDB(t)
+	testutil.EnableLeakDetection(t, db)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	factory.User().WithID("c").WithEmail("carol@example.com").Create(t, repo)
+	factory.User().WithID("a").WithEmail("alice@example.com").Create(t, repo)
+	factory.User().WithID("b").WithEmail("bob@example.com").Create(t, repo)
+
+	tests := []struct {
+		name   string
+		filter ports.UserFilter
+		want   []string
+	}{
+		{name: "by email", filter: ports.UserFilter{Sort: ports.UserSort{Field: "email"}, Limit: 10}, want: []string{"a", "b", "c"}},
+		{name: "by email desc", filter: ports.UserFilter{Sort: ports.UserSort{Field: "email", Desc: true}, Limit: 2}, want: []string{"c", "b"}},
+		{name: "offset", filter: ports.UserFilter{Sort: ports.UserSort{Field: "email"}, Limit: 10, Offset: 2}, want: []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var ids []string
+			for _, user := range users {
+				ids = append(ids, user.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("ids = %v, want %v", ids, tt.want)
+				}
+			}
+
+			total, err := repo.Count(ctx, tt.filter)
+			if err != nil || total != 3 {
+				t.Errorf("Count() = %d, %v; want 3", total, err)
+			}
+		})
+	}
+
+	if _, err := repo.List(ctx, ports.UserFilter{Sort: ports.UserSort{Field: "password"}, Limit: 1}); err == nil {
+		t.Error("List() by an unknown field succeeded")
+	}
+}
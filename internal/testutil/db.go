@@ -0,0 +1,70 @@
+package testutil
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"example.com/monolithic/internal/platform/database"
+	"example.com/monolithic/internal/platform/database/migrations"
+)
+
+// DatabaseURLEnv names the variable holding the postgres:// URL of a disposable test database
+const DatabaseURLEnv = "TEST_DATABASE_URL"
+
+// DBConfig returns the connection settings in TEST_DATABASE_URL, skipping the
+// test when the variable is unset. OpenDB is usually what a test wants.
+func DBConfig(t testing.TB) database.Config {
+	t.Helper()
+	raw := os.Getenv(DatabaseURLEnv)
+	if raw == "" {
+		t.Skipf("%s not set", DatabaseURLEnv)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %s: %v", DatabaseURLEnv, err)
+	}
+	port := 5432
+	if p := u.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			t.Fatalf("parse %s port: %v", DatabaseURLEnv, err)
+		}
+	}
+	password, _ := u.User.Password()
+	return database.Config{
+		Host:        u.Hostname(),
+		Port:        port,
+		User:        u.User.Username(),
+		Password:    password,
+		Database:    u.Path[1:],
+		MaxPoolSize: 10,
+		MinPoolSize: 1,
+		SSLMode:     u.Query().Get("sslmode"),
+	}
+}
+
+// OpenDB connects to the database named by TEST_DATABASE_URL, migrates it and empties
+// the tables tests write to. The test is skipped when the variable is unset.
+func OpenDB(t testing.TB) *database.DB {
+	t.Helper()
+	cfg := DBConfig(t)
+
+	if err := migrations.RunMigrations(cfg.GetConnectionURL()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	_, err = db.ExecContext(context.Background(), `TRUNCATE users, verification_tokens, password_reset_tokens,
+        refresh_tokens, idempotency_keys, audit_events, saved_filters CASCADE`)
+	if err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return db
+}
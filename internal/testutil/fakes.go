@@ -0,0 +1,1049 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/monolithic/internal/core/domain"
+	"example.com/monolithic/internal/core/ports"
+)
+
+// snapshotter is implemented by the fakes a Transactor rolls back
+type snapshotter interface {
+	snapshot() func()
+}
+
+// Transactor is a fake ports.Transactor. WithinTx snapshots every store it was
+// built with and restores them when fn fails, so a failed unit of work leaves
+// no trace in any of them. Units of work run one at a time, which trivially
+// honours LockEntity. Err, when set, is returned instead of running fn.
+type Transactor struct {
+	Err error
+
+	stores []snapshotter
+	mu     sync.Mutex // held for a whole unit of work, so snapshots never interleave
+	calls  int
+	locks  []string
+}
+
+type fakeTxKey struct{}
+
+var _ ports.Transactor = (*Transactor)(nil)
+
+// NewTransactor returns a Transactor that rolls back the given fakes
+func NewTransactor(stores ...snapshotter) *Transactor {
+	return &Transactor{stores: stores}
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.Err != nil {
+		return t.Err
+	}
+
+	if ctx.Value(fakeTxKey{}) != nil {
+		return fn(ctx) // join the enclosing unit of work
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+
+	restores := make([]func(), len(t.stores))
+	for i, store := range t.stores {
+		restores[i] = store.snapshot()
+	}
+	if err := fn(context.WithValue(ctx, fakeTxKey{}, true)); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *Transactor) LockEntity(ctx context.Context, entityType, id string) error {
+	if ctx.Value(fakeTxKey{}) == nil {
+		return errors.New("LockEntity called outside WithinTx")
+	}
+	t.locks = append(t.locks, entityType+":"+id) // t.mu is held by the enclosing WithinTx
+	return nil
+}
+
+// Locks returns the entity locks taken so far as "type:id", in order
+func (t *Transactor) Locks() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.locks)
+}
+
+// Calls returns how many units of work WithinTx has run
+func (t *Transactor) Calls() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+// UserRepository is an in-memory ports.UserRepository with the same
+// uniqueness, versioning and soft-delete rules as the Postgres one.
+// Setting Err makes every method fail with it.
+type UserRepository struct {
+	Err error
+
+	mu      sync.Mutex
+	users   map[string]*domain.User
+	filters []ports.UserFilter // passed to List, in call order
+}
+
+var _ ports.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository(users ...*domain.User) *UserRepository {
+	r := &UserRepository{users: make(map[string]*domain.User)}
+	for _, user := range users {
+		stored := *user
+		if stored.Role == "" {
+			stored.Role = domain.RoleUser
+		}
+		if stored.Version == 0 {
+			stored.Version = 1
+		}
+		r.users[stored.ID] = &stored
+	}
+	return r
+}
+
+func (r *UserRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := make(map[string]*domain.User, len(r.users))
+	for id, user := range r.users {
+		copied := *user
+		saved[id] = &copied
+	}
+	return func() {
+		r.mu.Lock()
+		r.users = saved
+		r.mu.Unlock()
+	}
+}
+
+// Filters returns the filters List has been called with, which is what would reach SQL
+func (r *UserRepository) Filters() []ports.UserFilter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.filters)
+}
+
+// Stored returns a copy of the user with id, including deleted ones
+func (r *UserRepository) Stored(id string) (domain.User, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, false
+	}
+	return *user, true
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	return r.insert(user)
+}
+
+func (r *UserRepository) insert(user *domain.User) error {
+	if _, ok := r.users[user.ID]; ok {
+		return &ports.ConflictError{Field: "id"}
+	}
+	for _, other := range r.users {
+		if strings.EqualFold(other.Email, user.Email) {
+			return &ports.ConflictError{Field: "email"}
+		}
+	}
+
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+	user.Version = 1
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *UserRepository) CreateMany(ctx context.Context, users []*domain.User) error {
+	restore := r.snapshot()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	for i, user := range users {
+		if err := r.insert(user); err != nil {
+			r.mu.Unlock()
+			restore()
+			r.mu.Lock()
+			return &ports.BatchItemError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) ImportMany(ctx context.Context, users []*domain.User) ([]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	inserted := make([]bool, len(users))
+	for i, user := range users {
+		inserted[i] = r.insert(user) == nil
+	}
+	return inserted, nil
+}
+
+func (r *UserRepository) active(id string) (*domain.User, bool) {
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return nil, false
+	}
+	return user, true
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	user, ok := r.active(id)
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *UserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return false, r.Err
+	}
+	_, ok := r.active(id)
+	return ok, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	for _, user := range r.users {
+		if user.Email == email && user.DeletedAt == nil {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	if errors.Is(err, ports.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	count := 0
+	for _, user := range r.users {
+		if user.Role == role && user.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	stored, ok := r.active(user.ID)
+	if !ok {
+		return ports.ErrNotFound
+	}
+	if stored.Version != user.Version {
+		return ports.ErrVersionConflict
+	}
+	for id, other := range r.users {
+		if id != user.ID && strings.EqualFold(other.Email, user.Email) {
+			return &ports.ConflictError{Field: "email"}
+		}
+	}
+
+	user.UpdatedAt = time.Now()
+	user.Version++
+	updated := *user
+	updated.CreatedAt, updated.DeletedAt = stored.CreatedAt, stored.DeletedAt
+	r.users[user.ID] = &updated
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	user, ok := r.active(id)
+	if !ok {
+		return ports.ErrNotFound
+	}
+	now := time.Now()
+	user.DeletedAt = &now
+	user.Version++
+	return nil
+}
+
+func (r *UserRepository) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt == nil {
+		return ports.ErrNotFound
+	}
+	user.DeletedAt = nil
+	user.UpdatedAt = time.Now()
+	user.Version++
+	return nil
+}
+
+func (r *UserRepository) GetDeletedByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	for _, user := range r.users {
+		if user.Email == email && user.DeletedAt != nil {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+func (r *UserRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	var purged int64
+	for id, user := range r.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(cutoff) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// matching returns active users within filter's creation bounds, sorted by filter.Sort
+func (r *UserRepository) matching(filter ports.UserFilter) []domain.User {
+	var users []domain.User
+	for _, user := range r.users {
+		if user.DeletedAt != nil {
+			continue
+		}
+		if !filter.CreatedAfter.IsZero() && user.CreatedAt.Before(filter.CreatedAfter) {
+			continue
+		}
+		if !filter.CreatedBefore.IsZero() && user.CreatedAt.After(filter.CreatedBefore) {
+			continue
+		}
+		users = append(users, *user)
+	}
+
+	key := func(u domain.User) string {
+		switch filter.Sort.Field {
+		case "email":
+			return u.Email
+		case "updated_at":
+			return u.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		return u.CreatedAt.Format(time.RFC3339Nano)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := key(users[i])+users[i].ID, key(users[j])+users[j].ID
+		if filter.Sort.Desc {
+			return a > b
+		}
+		return a < b
+	})
+	return users
+}
+
+func (r *UserRepository) List(ctx context.Context, filter ports.UserFilter) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters = append(r.filters, filter)
+	if !slices.Contains(ports.UserSortFields, filter.Sort.Field) {
+		return nil, fmt.Errorf("unknown user sort field %q", filter.Sort.Field)
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	users := r.matching(filter)
+	start := min(filter.Offset, len(users))
+	end := min(start+filter.Limit, len(users))
+	return users[start:end], nil
+}
+
+func (r *UserRepository) Count(ctx context.Context, filter ports.UserFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *UserRepository) SearchByEmail(ctx context.Context, prefix string, limit int) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	users := []domain.User{}
+	for _, user := range r.matching(ports.UserFilter{Sort: ports.UserSort{Field: "email"}}) {
+		if strings.HasPrefix(strings.ToLower(user.Email), strings.ToLower(prefix)) && len(users) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *UserRepository) ForEach(ctx context.Context, createdAfter time.Time, fn func(*domain.User) error) error {
+	r.mu.Lock()
+	users := r.matching(ports.UserFilter{CreatedAfter: createdAfter, Sort: ports.UserSort{Field: "created_at"}})
+	err := r.Err
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if !users[i].CreatedAt.After(createdAfter) {
+			continue
+		}
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuditRepository records audit events in memory. Setting Err makes Create fail with it.
+type AuditRepository struct {
+	Err error
+
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+var _ ports.AuditRepository = (*AuditRepository)(nil)
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := slices.Clone(r.events)
+	return func() {
+		r.mu.Lock()
+		r.events = saved
+		r.mu.Unlock()
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// Events returns the recorded events, oldest first
+func (r *AuditRepository) Events() []domain.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// EventRepository records analytics events and partition days in memory.
+// Setting Err makes every method fail with it.
+type EventRepository struct {
+	Err error
+
+	mu         sync.Mutex
+	events     []domain.AnalyticsEvent
+	partitions []time.Time
+}
+
+var _ ports.EventRepository = (*EventRepository)(nil)
+
+func NewEventRepository() *EventRepository {
+	return &EventRepository{}
+}
+
+func (r *EventRepository) InsertBatch(ctx context.Context, events []domain.AnalyticsEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *EventRepository) CreatePartition(ctx context.Context, day time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	r.partitions = append(r.partitions, day)
+	return nil
+}
+
+func (r *EventRepository) DropPartitionsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	return 0, nil
+}
+
+// Events returns the written events in the order they were written
+func (r *EventRepository) Events() []domain.AnalyticsEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// Partitions returns the days partitions were created for, in call order
+func (r *EventRepository) Partitions() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.partitions)
+}
+
+// TokenRepository stores verification tokens in memory
+type TokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]domain.VerificationToken
+}
+
+var _ ports.TokenRepository = (*TokenRepository)(nil)
+
+func NewTokenRepository() *TokenRepository {
+	return &TokenRepository{tokens: make(map[string]domain.VerificationToken)}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *domain.VerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*domain.VerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &token, nil
+}
+
+func (r *TokenRepository) MarkUsed(ctx context.Context, hash string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok || token.UsedAt != nil {
+		return ports.ErrNotFound
+	}
+	token.UsedAt = &at
+	r.tokens[hash] = token
+	return nil
+}
+
+// PasswordResetRepository stores password reset tokens in memory
+type PasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[string]domain.PasswordResetToken
+}
+
+var _ ports.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+func NewPasswordResetRepository() *PasswordResetRepository {
+	return &PasswordResetRepository{tokens: make(map[string]domain.PasswordResetToken)}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *PasswordResetRepository) GetByHash(ctx context.Context, hash string) (*domain.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &token, nil
+}
+
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, hash string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok || token.UsedAt != nil {
+		return ports.ErrNotFound
+	}
+	token.UsedAt = &at
+	r.tokens[hash] = token
+	return nil
+}
+
+// RefreshTokenRepository stores refresh tokens in memory with the same rotation
+// rules as the Postgres one
+type RefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]domain.RefreshToken
+}
+
+var _ ports.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{tokens: make(map[string]domain.RefreshToken)}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, oldHash string, next *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.tokens[oldHash]
+	if !ok || old.Revoked {
+		return ports.ErrNotFound
+	}
+	old.Revoked = true
+	r.tokens[oldHash] = old
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now()
+	}
+	r.tokens[next.TokenHash] = *next
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+// Active returns the tokens that are not revoked
+func (r *RefreshTokenRepository) Active() []domain.RefreshToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []domain.RefreshToken
+	for _, token := range r.tokens {
+		if !token.Revoked {
+			active = append(active, token)
+		}
+	}
+	return active
+}
+
+// AccessTokens issues readable, unsigned tokens of the form "access:<user>:<role>:<n>"
+// that expire after TTL, or an hour when it is zero
+type AccessTokens struct {
+	TTL time.Duration
+
+	mu     sync.Mutex
+	issued int
+}
+
+var _ ports.AccessTokens = (*AccessTokens)(nil)
+
+func (a *AccessTokens) Issue(userID, role string) (string, time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.issued++
+	ttl := a.TTL
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	return fmt.Sprintf("access:%s:%s:%d", userID, role, a.issued), time.Now().Add(ttl), nil
+}
+
+func (a *AccessTokens) Verify(token string) (ports.AccessClaims, error) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 4 || parts[0] != "access" {
+		return ports.AccessClaims{}, ports.ErrInvalidToken
+	}
+	return ports.AccessClaims{UserID: parts[1], Role: parts[2]}, nil
+}
+
+// PasswordHasher "hashes" by prefixing, which keeps tests fast and hashes readable
+type PasswordHasher struct{}
+
+var _ ports.PasswordHasher = PasswordHasher{}
+
+const hashPrefix = "hashed:"
+
+func (PasswordHasher) Hash(password string) (string, error) {
+	return hashPrefix + password, nil
+}
+
+func (PasswordHasher) Compare(hash, password string) error {
+	if !strings.HasPrefix(hash, hashPrefix) {
+		return ports.ErrNotHashed
+	}
+	if hash != hashPrefix+password {
+		return ports.ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (PasswordHasher) NeedsRehash(hash string) bool {
+	return !strings.HasPrefix(hash, hashPrefix)
+}
+
+// IDGenerator returns id-1, id-2, ... in order
+type IDGenerator struct {
+	mu   sync.Mutex
+	next int
+}
+
+var _ ports.IDGenerator = (*IDGenerator)(nil)
+
+func (g *IDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	return fmt.Sprintf("id-%d", g.next)
+}
+
+// EmailSender keeps every message it is asked to send
+type EmailSender struct {
+	mu       sync.Mutex
+	messages []ports.EmailMessage
+}
+
+var _ ports.EmailSender = (*EmailSender)(nil)
+
+func (s *EmailSender) Send(ctx context.Context, msg ports.EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (s *EmailSender) Messages() []ports.EmailMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// FileStorage keeps objects in memory; locations are the keys they were put under
+type FileStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+var _ ports.FileStorage = (*FileStorage)(nil)
+
+func NewFileStorage() *FileStorage {
+	return &FileStorage{objects: make(map[string][]byte)}
+}
+
+func (s *FileStorage) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return key, nil
+}
+
+func (s *FileStorage) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[location]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *FileStorage) Delete(ctx context.Context, location string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, location)
+	return nil
+}
+
+// IdempotencyRepository keeps idempotency records in memory
+type IdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+var _ ports.IdempotencyRepository = (*IdempotencyRepository)(nil)
+
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if held, ok := r.records[record.Key]; ok && held.ExpiresAt.After(time.Now()) {
+		return ports.ErrConflict
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+func (r *IdempotencyRepository) GetByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[key]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[key]
+	if !ok {
+		return ports.ErrNotFound
+	}
+	record.ResponseStatus, record.ResponseContentType, record.ResponseBody = status, contentType, slices.Clone(body)
+	r.records[key] = record
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, key)
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SavedFilterRepository keeps saved filters in memory with the Postgres one's
+// per-owner name uniqueness. Setting Err makes every method fail with it.
+type SavedFilterRepository struct {
+	Err error
+
+	mu      sync.Mutex
+	filters map[string]domain.SavedFilter
+}
+
+var _ ports.SavedFilterRepository = (*SavedFilterRepository)(nil)
+
+func NewSavedFilterRepository(filters ...*domain.SavedFilter) *SavedFilterRepository {
+	r := &SavedFilterRepository{filters: make(map[string]domain.SavedFilter)}
+	for _, filter := range filters {
+		r.filters[filter.ID] = *filter
+	}
+	return r
+}
+
+// nameTaken reports whether ownerID has a filter other than id called name
+func (r *SavedFilterRepository) nameTaken(id, ownerID, name string) bool {
+	for _, other := range r.filters {
+		if other.ID != id && other.OwnerID == ownerID && other.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SavedFilterRepository) Create(ctx context.Context, filter *domain.SavedFilter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	if _, ok := r.filters[filter.ID]; ok {
+		return &ports.ConflictError{Field: "id"}
+	}
+	if r.nameTaken(filter.ID, filter.OwnerID, filter.Name) {
+		return &ports.ConflictError{Field: "name"}
+	}
+	now := time.Now()
+	filter.CreatedAt, filter.UpdatedAt = now, now
+	r.filters[filter.ID] = *filter
+	return nil
+}
+
+func (r *SavedFilterRepository) GetByID(ctx context.Context, id string) (*domain.SavedFilter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	filter, ok := r.filters[id]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &filter, nil
+}
+
+func (r *SavedFilterRepository) ListVisible(ctx context.Context, ownerID string) ([]domain.SavedFilter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var visible []domain.SavedFilter
+	for _, filter := range r.filters {
+		if filter.OwnerID == ownerID || filter.Shared {
+			visible = append(visible, filter)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		if visible[i].Name != visible[j].Name {
+			return visible[i].Name < visible[j].Name
+		}
+		return visible[i].ID < visible[j].ID
+	})
+	return visible, nil
+}
+
+func (r *SavedFilterRepository) Update(ctx context.Context, filter *domain.SavedFilter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	stored, ok := r.filters[filter.ID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+	if r.nameTaken(filter.ID, stored.OwnerID, filter.Name) {
+		return &ports.ConflictError{Field: "name"}
+	}
+	stored.Name, stored.Resource, stored.Params, stored.Shared = filter.Name, filter.Resource, filter.Params, filter.Shared
+	stored.UpdatedAt = time.Now()
+	filter.UpdatedAt = stored.UpdatedAt
+	r.filters[filter.ID] = stored
+	return nil
+}
+
+func (r *SavedFilterRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	if _, ok := r.filters[id]; !ok {
+		return ports.ErrNotFound
+	}
+	delete(r.filters, id)
+	return nil
+}
@@ -0,0 +1,26 @@
+// Package testutil holds fakes of the ports and helpers shared by tests, including those that run against a real database
+package testutil
+
+import (
+	"testing"
+
+	"example.com/monolithic/internal/platform/database"
+)
+
+// EnableLeakDetection turns on Rows leak detection for db and fails t at cleanup if any rows were left open
+func EnableLeakDetection(t testing.TB, db *database.DB) {
+	t.Helper()
+	db.SetLeakDetection(&database.LeakConfig{})
+	t.Cleanup(func() {
+		AssertNoLeakedRows(t, db)
+		db.SetLeakDetection(nil)
+	})
+}
+
+// AssertNoLeakedRows fails t for every result set opened through db that hasn't been closed
+func AssertNoLeakedRows(t testing.TB, db *database.DB) {
+	t.Helper()
+	for _, leaked := range db.OpenRows(0) {
+		t.Errorf("rows not closed after %s\nquery: %s\nopened at:\n%s", leaked.Age, leaked.Query, leaked.Stack)
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// refreshTimeout bounds a token refresh, which runs on after the request that started it gives up
+const refreshTimeout = 30 * time.Second
+
+// Tokens are a session's credentials: the access token sent with each request and the
+// refresh token exchanged for a new pair once the access token expires
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// TokenStore holds a session's current tokens and must be safe for concurrent use.
+// A store that persists them lets a program keep its session across restarts.
+type TokenStore interface {
+	Tokens() Tokens
+	SetTokens(tokens Tokens)
+}
+
+// MemoryTokenStore keeps tokens in memory
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens Tokens
+}
+
+func (s *MemoryTokenStore) Tokens() Tokens {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokens
+}
+
+func (s *MemoryTokenStore) SetTokens(tokens Tokens) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+}
+
+// AuthTransport sends the stored access token as a bearer token. When the server
+// answers 401, it exchanges the stored refresh token at RefreshURL for new tokens
+// and sends the request once more. Requests that hit 401 together share a single
+// refresh, and a request that was sent with an already replaced token just retries.
+type AuthTransport struct {
+	Base  http.RoundTripper // nil means http.DefaultTransport
+	Store TokenStore
+	// RefreshURL is the server's POST /api/auth/refresh endpoint
+	RefreshURL string
+	Hooks      *Hooks
+
+	mu       sync.Mutex
+	inflight *refreshCall
+}
+
+// refreshCall is a refresh in progress, which every request waiting for it shares
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tokens := t.Store.Tokens()
+	resp, err := t.send(req, tokens.AccessToken)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || tokens.RefreshToken == "" {
+		return resp, err
+	}
+
+	next, ok := rewind(req)
+	if !ok {
+		return resp, nil
+	}
+	if err := t.refresh(req.Context(), tokens.AccessToken); err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			resp.Body.Close()
+			return nil, ctxErr
+		}
+		// The session can't be renewed; the caller sees the 401
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.send(next, t.Store.Tokens().AccessToken)
+}
+
+func (t *AuthTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// send sends req with token as its bearer token, leaving req itself unchanged
+func (t *AuthTransport) send(req *http.Request, token string) (*http.Response, error) {
+	if token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return t.base().RoundTrip(req)
+}
+
+// refresh replaces the stored tokens unless the access token is no longer stale, joining
+// the refresh already in progress if there is one. It returns early, with ctx's error,
+// when ctx is done; the refresh itself carries on for the other requests waiting on it.
+func (t *AuthTransport) refresh(ctx context.Context, stale string) error {
+	t.mu.Lock()
+	if t.Store.Tokens().AccessToken != stale {
+		t.mu.Unlock()
+		return nil
+	}
+	call := t.inflight
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		t.inflight = call
+		go t.run(context.WithoutCancel(ctx), call)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *AuthTransport) run(ctx context.Context, call *refreshCall) {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	call.err = t.exchange(ctx)
+	t.Hooks.refresh(call.err)
+
+	t.mu.Lock()
+	t.inflight = nil
+	t.mu.Unlock()
+	close(call.done)
+}
+
+// exchange trades the stored refresh token for new tokens and stores them
+func (t *AuthTransport) exchange(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"refresh_token": t.Store.Tokens().RefreshToken})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.RefreshURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	var issued tokenResponse
+	if err := decodeResponse(resp, &issued); err != nil {
+		return err
+	}
+	t.Store.SetTokens(Tokens{AccessToken: issued.AccessToken, RefreshToken: issued.RefreshToken})
+	return nil
+}
+
+// tokenResponse is the data of the server's login and refresh responses
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is the server's address, such as https://api.example.com
+	BaseURL string
+	// Transport sends the requests; nil means http.DefaultTransport
+	Transport http.RoundTripper
+	// Tokens holds the session; nil means a MemoryTokenStore. Login fills it.
+	Tokens TokenStore
+	// Retry sets how refused requests are retried; New sets its Base and Hooks
+	Retry RetryTransport
+	// IdempotencyKeys sends an Idempotency-Key with every Create call, generated unless
+	// the context carries one from WithIdempotencyKey, so creates are retried like other
+	// safe requests without risk of creating twice
+	IdempotencyKeys bool
+	Hooks           Hooks
+}
+
+// Client calls the API. Every method stops waiting, including between retries, once
+// its context is done.
+type Client struct {
+	baseURL         *url.URL
+	public          *http.Client // for the auth endpoints, which take no access token
+	authed          *http.Client
+	tokens          TokenStore
+	idempotencyKeys bool
+}
+
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("client: BaseURL must be an absolute URL, got %q", cfg.BaseURL)
+	}
+	tokens := cfg.Tokens
+	if tokens == nil {
+		tokens = &MemoryTokenStore{}
+	}
+	hooks := cfg.Hooks
+
+	// Retries go around authentication, so every attempt carries the current access token
+	public := cfg.Retry
+	public.Base, public.Hooks = cfg.Transport, &hooks
+	authed := cfg.Retry
+	authed.Base = &AuthTransport{
+		Base:       cfg.Transport,
+		Store:      tokens,
+		RefreshURL: base.JoinPath("/api/auth/refresh").String(),
+		Hooks:      &hooks,
+	}
+	authed.Hooks = &hooks
+
+	return &Client{
+		baseURL:         base,
+		public:          &http.Client{Transport: &public},
+		authed:          &http.Client{Transport: &authed},
+		tokens:          tokens,
+		idempotencyKeys: cfg.IdempotencyKeys,
+	}, nil
+}
+
+// APIError is an error response from the server
+type APIError struct {
+	StatusCode int
+	Code       string // such as UNAUTHORIZED or VALIDATION_FAILED; empty when the body had none
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// User is a user account
+type User struct {
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	AvatarURL       string     `json:"avatar_url,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// CreateUserRequest is a signup
+type CreateUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Login starts a session, storing its tokens for the requests that follow
+func (c *Client) Login(ctx context.Context, email, password string) error {
+	var issued tokenResponse
+	err := c.do(ctx, c.public, http.MethodPost, "/api/auth/login", nil,
+		map[string]string{"email": email, "password": password}, &issued)
+	if err != nil {
+		return err
+	}
+	c.tokens.SetTokens(Tokens{AccessToken: issued.AccessToken, RefreshToken: issued.RefreshToken})
+	return nil
+}
+
+// CreateUser signs up a user
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.create(ctx, "/api/users", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser fetches a user by ID
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := c.Do(ctx, http.MethodGet, "/api/users/"+url.PathEscape(id), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Do sends in, when not nil, as JSON to path and decodes the response's data into out,
+// when not nil. Responses with an error status are returned as *APIError.
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	return c.do(ctx, c.authed, method, path, nil, in, out)
+}
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey makes the Create call made with ctx send key, so a caller retrying
+// the call itself, after an error Client didn't retry, still can't create twice
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// create POSTs in to path, with an Idempotency-Key when the client is configured to send one
+func (c *Client) create(ctx context.Context, path string, in, out interface{}) error {
+	var header http.Header
+	if key, ok := ctx.Value(idempotencyKeyKey{}).(string); ok && key != "" {
+		header = http.Header{"Idempotency-Key": {key}}
+	} else if c.idempotencyKeys {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return err
+		}
+		header = http.Header{"Idempotency-Key": {key}}
+	}
+	return c.do(ctx, c.authed, http.MethodPost, path, header, in, out)
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (c *Client) do(ctx context.Context, hc *http.Client, method, path string, header http.Header, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data) // replayable, so the request can be retried
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.JoinPath(path).String(), body)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		// Report cancellation as the context error rather than wrapped in a *url.Error
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return ctxErr
+		}
+		return err
+	}
+	return decodeResponse(resp, out)
+}
+
+// decodeResponse reads the server's response envelope, decoding its data into out
+// when not nil, and closes the body
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&envelope)
+
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		if decodeErr == nil && envelope.Error != nil {
+			apiErr.Code, apiErr.Message = envelope.Error.Code, envelope.Error.Message
+		}
+		return apiErr
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("api: decoding %d response: %w", resp.StatusCode, decodeErr)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
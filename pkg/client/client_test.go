@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeData and writeError answer in the server's response envelope
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"code": code, "message": message}})
+}
+
+// authServer accepts only the access token "fresh", which refreshing the refresh token "r1" issues
+type authServer struct {
+	refreshes atomic.Int64
+	refuse    bool // refresh fails with 401
+}
+
+func (s *authServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/auth/refresh":
+		s.refreshes.Add(1)
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		time.Sleep(20 * time.Millisecond) // let concurrent requests pile up behind the refresh
+		if s.refuse || req.RefreshToken != "r1" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid refresh token")
+			return
+		}
+		writeData(w, http.StatusOK, map[string]string{"access_token": "fresh", "refresh_token": "r2"})
+	default:
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "token expired")
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/api/users/")
+		writeData(w, http.StatusOK, map[string]string{"id": id, "email": id + "@example.com", "role": "user"})
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler, cfg Config) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestClientRefreshesOn401(t *testing.T) {
+	server := &authServer{}
+	var refreshed atomic.Int64
+	store := &MemoryTokenStore{}
+	store.SetTokens(Tokens{AccessToken: "stale", RefreshToken: "r1"})
+	c := newTestClient(t, server, Config{Tokens: store, Hooks: Hooks{
+		OnRefresh: func(err error) {
+			if err != nil {
+				t.Errorf("OnRefresh(%v), want nil", err)
+			}
+			refreshed.Add(1)
+		},
+	}})
+
+	const requests = 8
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			user, err := c.GetUser(context.Background(), id)
+			if err != nil {
+				t.Errorf("GetUser(%s) error = %v", id, err)
+				return
+			}
+			if user.ID != id {
+				t.Errorf("GetUser(%s) = %+v", id, user)
+			}
+		}(fmt.Sprintf("u%d", i))
+	}
+	wg.Wait()
+
+	if got := server.refreshes.Load(); got != 1 {
+		t.Errorf("refreshed %d times for %d concurrent 401s, want once", got, requests)
+	}
+	if got := refreshed.Load(); got != 1 {
+		t.Errorf("OnRefresh ran %d times, want once", got)
+	}
+	if got := store.Tokens(); got != (Tokens{AccessToken: "fresh", RefreshToken: "r2"}) {
+		t.Errorf("stored tokens = %+v, want the refreshed pair", got)
+	}
+}
+
+func TestClientRefreshFailure(t *testing.T) {
+	store := &MemoryTokenStore{}
+	store.SetTokens(Tokens{AccessToken: "stale", RefreshToken: "r1"})
+	var refreshErr error
+	c := newTestClient(t, &authServer{refuse: true}, Config{Tokens: store, Hooks: Hooks{
+		OnRefresh: func(err error) { refreshErr = err },
+	}})
+
+	_, err := c.GetUser(context.Background(), "u1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "UNAUTHORIZED" {
+		t.Fatalf("GetUser() error = %v, want the 401", err)
+	}
+	if refreshErr == nil {
+		t.Error("OnRefresh reported success for a refused refresh")
+	}
+	if got := store.Tokens(); got.AccessToken != "stale" {
+		t.Errorf("stored tokens = %+v, want them unchanged", got)
+	}
+}
+
+func TestClientRetriesAfter429(t *testing.T) {
+	var calls atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set(RetryAfterHeader, "1")
+			writeError(w, http.StatusTooManyRequests, "RATE_LIMITED", "slow down")
+			return
+		}
+		writeData(w, http.StatusOK, map[string]string{"id": "u1"})
+	})
+	var waits []time.Duration
+	var attempts []int
+	c := newTestClient(t, handler, Config{Hooks: Hooks{
+		OnAttempt: func(_ *http.Request, attempt int, resp *http.Response, err error, _ time.Duration) {
+			if err != nil {
+				t.Errorf("attempt %d error = %v", attempt, err)
+				return
+			}
+			attempts = append(attempts, resp.StatusCode)
+		},
+		OnRetry: func(_ *http.Request, _ int, wait time.Duration) { waits = append(waits, wait) },
+	}})
+
+	start := time.Now()
+	if _, err := c.GetUser(context.Background(), "u1"); err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, before Retry-After elapsed", elapsed)
+	}
+	if len(waits) != 1 || waits[0] != time.Second {
+		t.Errorf("OnRetry waits = %v, want [1s]", waits)
+	}
+	if len(attempts) != 2 || attempts[0] != http.StatusTooManyRequests || attempts[1] != http.StatusOK {
+		t.Errorf("OnAttempt statuses = %v, want [429 200]", attempts)
+	}
+}
+
+func TestClientCancelsWait(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RetryAfterHeader, "20")
+		writeError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "try later")
+	})
+	c := newTestClient(t, handler, Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetUser(ctx, "u1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetUser() error = %v, want the context's", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetUser() returned after %s, long after its context ended", elapsed)
+	}
+}
+
+func TestClientCreateUserIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      bool
+		key       string // passed with WithIdempotencyKey
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "opted in", keys: true, wantCalls: 2},
+		{name: "caller's key", keys: true, key: "k1", wantCalls: 2},
+		{name: "caller's key without opting in", key: "k1", wantCalls: 2},
+		{name: "not opted in", wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				keys = append(keys, r.Header.Get("Idempotency-Key"))
+				if len(keys) == 1 {
+					w.Header().Set(RetryAfterHeader, "0")
+					writeError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "try later")
+					return
+				}
+				var req CreateUserRequest
+				json.Unmarshal(body, &req)
+				writeData(w, http.StatusCreated, map[string]string{"id": "u1", "email": req.Email})
+			})
+			c := newTestClient(t, handler, Config{IdempotencyKeys: tt.keys})
+
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = WithIdempotencyKey(ctx, tt.key)
+			}
+			user, err := c.CreateUser(ctx, CreateUserRequest{Email: "new@example.com", Password: "Secret123!"})
+			if tt.wantErr {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+					t.Errorf("CreateUser() error = %v, want the 503", err)
+				}
+			} else if err != nil || user.Email != "new@example.com" {
+				t.Fatalf("CreateUser() = %+v, %v", user, err)
+			}
+
+			if len(keys) != tt.wantCalls {
+				t.Fatalf("sent %d requests, want %d", len(keys), tt.wantCalls)
+			}
+			for _, key := range keys {
+				if key != keys[0] {
+					t.Errorf("Idempotency-Keys = %q, want the same key on every attempt", keys)
+				}
+			}
+			if tt.key != "" && keys[0] != tt.key {
+				t.Errorf("Idempotency-Key = %q, want %q", keys[0], tt.key)
+			}
+			if !tt.keys && tt.key == "" && keys[0] != "" {
+				t.Errorf("Idempotency-Key = %q sent without opting in", keys[0])
+			}
+		})
+	}
+}
+
+func TestClientLogin(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email, Password string
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/auth/login" || r.Header.Get("Authorization") != "" {
+			t.Errorf("login sent to %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if req.Password != "Secret123!" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid email or password")
+			return
+		}
+		writeData(w, http.StatusOK, map[string]string{"access_token": "a1", "refresh_token": "r1"})
+	})
+	store := &MemoryTokenStore{}
+	c := newTestClient(t, handler, Config{Tokens: store})
+
+	var apiErr *APIError
+	if err := c.Login(context.Background(), "alice@example.com", "wrong"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Login(wrong password) error = %v, want a 401", err)
+	}
+	if err := c.Login(context.Background(), "alice@example.com", "Secret123!"); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if got := store.Tokens(); got != (Tokens{AccessToken: "a1", RefreshToken: "r1"}) {
+		t.Errorf("stored tokens = %+v, want the issued pair", got)
+	}
+}
+
+func TestNewRequiresAbsoluteBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "api.example.com", "/api"} {
+		if _, err := New(Config{BaseURL: baseURL}); err == nil {
+			t.Errorf("New(BaseURL: %q) accepted it", baseURL)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Hooks let callers observe the client for metrics and logging. Any of them may be nil,
+// and they run on the goroutine making the request, so they should return quickly.
+type Hooks struct {
+	// OnAttempt runs after each attempt at a request, retries included, with its
+	// 1-based number and either its response or its transport error
+	OnAttempt func(req *http.Request, attempt int, resp *http.Response, err error, elapsed time.Duration)
+	// OnRetry runs before waiting wait to send req again as attempt+1
+	OnRetry func(req *http.Request, attempt int, wait time.Duration)
+	// OnRefresh runs after each access token refresh, with nil when it succeeded
+	OnRefresh func(err error)
+}
+
+func (h *Hooks) attempt(req *http.Request, attempt int, resp *http.Response, err error, elapsed time.Duration) {
+	if h != nil && h.OnAttempt != nil {
+		h.OnAttempt(req, attempt, resp, err, elapsed)
+	}
+}
+
+func (h *Hooks) retry(req *http.Request, attempt int, wait time.Duration) {
+	if h != nil && h.OnRetry != nil {
+		h.OnRetry(req, attempt, wait)
+	}
+}
+
+func (h *Hooks) refresh(err error) {
+	if h != nil && h.OnRefresh != nil {
+		h.OnRefresh(err)
+	}
+}
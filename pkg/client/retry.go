@@ -0,0 +1,153 @@
+// Package client helps Go programs call the API the way the server expects:
+// waiting as long as Retry-After asks before retrying, refreshing access tokens,
+// and bounding requests by the timeout the server advertises. Client puts the
+// pieces together; RetryTransport and AuthTransport can also be used on their own.
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers the server sets to guide retries and deadlines
+const (
+	RetryAfterHeader     = "Retry-After"
+	RequestTimeoutHeader = "X-Request-Timeout"
+)
+
+// RetryAfter reads how long resp asks the caller to wait before retrying.
+// Both forms of the header are understood: delay seconds and an HTTP date.
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get(RetryAfterHeader))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at)), true
+	}
+	return 0, false
+}
+
+// RequestTimeout reads how long the server lets the route behind resp run
+func RequestTimeout(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get(RequestTimeoutHeader))
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// RetryTransport retries requests the server refused with 429 or a 5xx other than
+// 501, waiting as long as Retry-After asks, or backing off exponentially from
+// BaseDelay with jitter when the header is missing. Only requests that are safe
+// to repeat are retried: GET, HEAD, OPTIONS, PUT and DELETE, and POSTs carrying
+// an Idempotency-Key. Waits end early when the request's context is done.
+type RetryTransport struct {
+	Base       http.RoundTripper // nil means http.DefaultTransport
+	MaxRetries int               // 0 means 3
+	BaseDelay  time.Duration     // 0 means 500ms
+	// MaxWait is the longest wait honoured; a response asking for more is returned as is. 0 means 30s.
+	MaxWait time.Duration
+	Hooks   *Hooks
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	maxRetries := t.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	delay := t.BaseDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+	maxWait := t.MaxWait
+	if maxWait <= 0 {
+		maxWait = 30 * time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := base.RoundTrip(req)
+		t.Hooks.attempt(req, attempt+1, resp, err, time.Since(start))
+		if err != nil || attempt == maxRetries || !retryable(req, resp) {
+			return resp, err
+		}
+
+		wait, ok := RetryAfter(resp)
+		if !ok {
+			wait = backoff(delay, attempt)
+		}
+		if wait > maxWait {
+			return resp, nil
+		}
+
+		next, ok := rewind(req)
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		t.Hooks.retry(req, attempt+1, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// backoff is the wait before retry attempt+1: delay doubled per earlier attempt, with the
+// upper half randomized so clients refused together don't all come back together
+func backoff(delay time.Duration, attempt int) time.Duration {
+	ceiling := delay << attempt
+	half := ceiling / 2
+	return half + rand.N(ceiling-half+1)
+}
+
+// retryable reports whether resp refused req for load or a server fault and req can safely be sent again
+func retryable(req *http.Request, resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+	default:
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// rewind returns a copy of req with a fresh body to send again; ok is false when the body can't be replayed
+func rewind(req *http.Request) (next *http.Request, ok bool) {
+	next = req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next.Body = body
+	return next, true
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOK: true},
+		{name: "zero", value: "0", want: 0, wantOK: true},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+		{name: "missing", value: ""},
+		{name: "negative", value: "-1"},
+		{name: "garbage", value: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set(RetryAfterHeader, tt.value)
+			}
+			got, ok := RetryAfter(resp)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("RetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	resp := &http.Response{Header: http.Header{RetryAfterHeader: {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}}
+	if got, ok := RetryAfter(resp); !ok || got <= 58*time.Second || got > time.Minute {
+		t.Errorf("RetryAfter(date a minute away) = %s, %v", got, ok)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	resp := &http.Response{Header: http.Header{RequestTimeoutHeader: {"60"}}}
+	if got, ok := RequestTimeout(resp); !ok || got != time.Minute {
+		t.Errorf("RequestTimeout() = %s, %v; want 1m", got, ok)
+	}
+	if _, ok := RequestTimeout(&http.Response{Header: http.Header{}}); ok {
+		t.Error("RequestTimeout() without the header reported one")
+	}
+}
+
+// refusingServer answers the first refusals requests with status and Retry-After, then 200 echoing the body
+func refusingServer(t *testing.T, refusals int64, status int, retryAfter string) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= refusals {
+			if retryAfter != "" {
+				w.Header().Set(RetryAfterHeader, retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		status     int
+		retryAfter string
+		refusals   int64
+		wantStatus int
+		wantCalls  int64
+	}{
+		{name: "get after 503", method: http.MethodGet, status: http.StatusServiceUnavailable, retryAfter: "0", refusals: 2, wantStatus: http.StatusOK, wantCalls: 3},
+		{name: "put after 429", method: http.MethodPut, status: http.StatusTooManyRequests, retryAfter: "0", refusals: 1, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "without Retry-After backs off", method: http.MethodGet, status: http.StatusServiceUnavailable, refusals: 1, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "post is not retried", method: http.MethodPost, status: http.StatusServiceUnavailable, retryAfter: "0", refusals: 1, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "post with Idempotency-Key", method: http.MethodPost, header: http.Header{"Idempotency-Key": {"k1"}}, status: http.StatusServiceUnavailable, retryAfter: "0", refusals: 1, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "gives up after MaxRetries", method: http.MethodGet, status: http.StatusServiceUnavailable, retryAfter: "0", refusals: 10, wantStatus: http.StatusServiceUnavailable, wantCalls: 4},
+		{name: "wait too long", method: http.MethodGet, status: http.StatusServiceUnavailable, retryAfter: "120", refusals: 1, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "get after 500", method: http.MethodGet, status: http.StatusInternalServerError, refusals: 1, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "delete after 502", method: http.MethodDelete, status: http.StatusBadGateway, refusals: 1, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "not implemented", method: http.MethodGet, status: http.StatusNotImplemented, refusals: 1, wantStatus: http.StatusNotImplemented, wantCalls: 1},
+		{name: "client errors", method: http.MethodGet, status: http.StatusBadRequest, refusals: 1, wantStatus: http.StatusBadRequest, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := refusingServer(t, tt.refusals, tt.status, tt.retryAfter)
+			client := &http.Client{Transport: &RetryTransport{BaseDelay: time.Millisecond}}
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{"a":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+			if resp.StatusCode == http.StatusOK && string(body) != `{"a":1}` {
+				t.Errorf("retried body = %q, want the original", body)
+			}
+		})
+	}
+}
+
+func TestRetryTransportWaitsForRetryAfter(t *testing.T) {
+	srv, _ := refusingServer(t, 1, http.StatusTooManyRequests, "1")
+	client := &http.Client{Transport: &RetryTransport{}}
+
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, before Retry-After elapsed", elapsed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	delay := 100 * time.Millisecond
+	for attempt := 0; attempt < 4; attempt++ {
+		ceiling := delay << attempt
+		for i := 0; i < 50; i++ {
+			if got := backoff(delay, attempt); got < ceiling/2 || got > ceiling {
+				t.Fatalf("backoff(%s, %d) = %s, want within [%s, %s]", delay, attempt, got, ceiling/2, ceiling)
+			}
+		}
+	}
+}